@@ -26,6 +26,23 @@ func convertEnvStringToTimeDuration(env map[string]string, field string) time.Du
 	return time.Duration(int64(data) * int64(math.Pow10(9)))
 }
 
+// convertEnvStringToTimeDurationOr is for optional settings, an empty env falls back to the default
+func convertEnvStringToTimeDurationOr(env map[string]string, field string, fallback time.Duration) time.Duration {
+	if env[field] == "" {
+		return fallback
+	}
+	return convertEnvStringToTimeDuration(env, field)
+}
+
+// convertEnvStringToPositiveTimeDurationOr is convertEnvStringToTimeDurationOr for intervals, zero or less is rejected
+func convertEnvStringToPositiveTimeDurationOr(env map[string]string, field string, fallback time.Duration) time.Duration {
+	data := convertEnvStringToTimeDurationOr(env, field, fallback)
+	if data <= 0 {
+		log.Fatalf("load %v failed: must be more than 0 second", field)
+	}
+	return data
+}
+
 func LoadConfig(path string) IConfig {
 	envMap, err := godotenv.Read(path)
 	if err != nil {
@@ -61,6 +78,15 @@ func LoadConfig(path string) IConfig {
 			accessExpiresAt:  convertEnvStringToInt(envMap, "JWT_ACCESS_EXPIRES"),
 			refreshExpiresAt: convertEnvStringToInt(envMap, "JWT_REFRESH_EXPIRES"),
 		},
+		payment: &payment{
+			provider:      envMap["PAYMENT_PROVIDER"],
+			webhookSecret: envMap["PAYMENT_WEBHOOK_SECRET"],
+		},
+		job: &job{
+			interval:               convertEnvStringToPositiveTimeDurationOr(envMap, "JOB_INTERVAL", time.Minute),
+			orderPaymentTimeout:    convertEnvStringToPositiveTimeDurationOr(envMap, "ORDER_PAYMENT_TIMEOUT", 24*time.Hour),
+			recommendationInterval: convertEnvStringToPositiveTimeDurationOr(envMap, "RECOMMENDATION_INTERVAL", time.Hour),
+		},
 	}
 }
 
@@ -68,12 +94,16 @@ type IConfig interface {
 	App() IAppConfig
 	Db() IDbConfig
 	Jwt() IJwtConfig
+	Payment() IPaymentConfig
+	Job() IJobConfig
 }
 
 type config struct {
-	app *app
-	db  *db
-	jwt *jwt
+	app     *app
+	db      *db
+	jwt     *jwt
+	payment *payment
+	job     *job
 }
 
 type IAppConfig interface {
@@ -185,3 +215,43 @@ type jwt struct {
 	accessExpiresAt  int
 	refreshExpiresAt int
 }
+
+type IPaymentConfig interface {
+	Provider() string
+	WebhookSecret() []byte
+}
+
+func (p *payment) Provider() string { return p.provider }
+
+func (p *payment) WebhookSecret() []byte { return []byte(p.webhookSecret) }
+
+func (c *config) Payment() IPaymentConfig {
+	return c.payment
+}
+
+type payment struct {
+	provider      string
+	webhookSecret string
+}
+
+type IJobConfig interface {
+	Interval() time.Duration
+	OrderPaymentTimeout() time.Duration
+	RecommendationInterval() time.Duration
+}
+
+func (j *job) Interval() time.Duration { return j.interval }
+
+func (j *job) OrderPaymentTimeout() time.Duration { return j.orderPaymentTimeout }
+
+func (j *job) RecommendationInterval() time.Duration { return j.recommendationInterval }
+
+func (c *config) Job() IJobConfig {
+	return c.job
+}
+
+type job struct {
+	interval               time.Duration
+	orderPaymentTimeout    time.Duration
+	recommendationInterval time.Duration
+}
@@ -0,0 +1,137 @@
+package addressHandlers
+
+import (
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/addresses"
+	"go_learn_project_rest_api/modules/addresses/addressUsecases"
+	"go_learn_project_rest_api/modules/entities"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type addressHandlersErrCode string
+
+const (
+	findAddressErr    addressHandlersErrCode = "addresses-001"
+	findOneAddressErr addressHandlersErrCode = "addresses-002"
+	insertAddressErr  addressHandlersErrCode = "addresses-003"
+	updateAddressErr  addressHandlersErrCode = "addresses-004"
+	deleteAddressErr  addressHandlersErrCode = "addresses-005"
+)
+
+type IAddressHandlers interface {
+	FindAddress(fiber.Ctx) error
+	FindOneAddress(fiber.Ctx) error
+	InsertAddress(fiber.Ctx) error
+	UpdateAddress(fiber.Ctx) error
+	DeleteAddress(fiber.Ctx) error
+}
+
+type addressHandlers struct {
+	cfg             config.IConfig
+	addressUsecases addressUsecases.IAddressUsecases
+}
+
+func AddressHandlers(cfg config.IConfig, addressUsecases addressUsecases.IAddressUsecases) IAddressHandlers {
+	return &addressHandlers{
+		cfg:             cfg,
+		addressUsecases: addressUsecases,
+	}
+}
+
+func (h *addressHandlers) FindAddress(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+
+	result, err := h.addressUsecases.FindAddress(userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *addressHandlers) FindOneAddress(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	addressId := strings.Trim(c.Params("address_id"), " ")
+
+	result, err := h.addressUsecases.FindOneAddress(userId, addressId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOneAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *addressHandlers) InsertAddress(c fiber.Ctx) error {
+	req := new(addresses.Address)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertAddressErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("user_id"), " ")
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertAddressErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.addressUsecases.InsertAddress(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(insertAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, result).Res()
+}
+
+func (h *addressHandlers) UpdateAddress(c fiber.Ctx) error {
+	req := new(addresses.UpdateAddressReq)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateAddressErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("user_id"), " ")
+	req.Id = strings.Trim(c.Params("address_id"), " ")
+
+	result, err := h.addressUsecases.UpdateAddress(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(updateAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *addressHandlers) DeleteAddress(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	addressId := strings.Trim(c.Params("address_id"), " ")
+
+	if err := h.addressUsecases.DeleteAddress(userId, addressId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(deleteAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusNoContent, nil).Res()
+}
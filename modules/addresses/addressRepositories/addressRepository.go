@@ -0,0 +1,318 @@
+package addressRepositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go_learn_project_rest_api/modules/addresses"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const addressColumns = `
+		"id",
+		"user_id",
+		"recipient",
+		"phone",
+		"line1",
+		"line2",
+		"district",
+		"province",
+		"postal_code",
+		"country",
+		"is_default",
+		"created_at"::TEXT AS "created_at",
+		"updated_at"::TEXT AS "updated_at"`
+
+type IAddressRepository interface {
+	FindAddress(userId string) ([]*addresses.Address, error)
+	FindOneAddress(userId, addressId string) (*addresses.Address, error)
+	InsertAddress(*addresses.Address) (string, error)
+	UpdateAddress(*addresses.UpdateAddressReq) error
+	DeleteAddress(userId, addressId string) error
+}
+
+type addressRepository struct {
+	db *sqlx.DB
+}
+
+func AddressRepository(db *sqlx.DB) IAddressRepository {
+	return &addressRepository{
+		db: db,
+	}
+}
+
+func (r *addressRepository) FindAddress(userId string) ([]*addresses.Address, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM "addresses"
+	WHERE "user_id" = $1
+	ORDER BY "is_default" DESC, "created_at" DESC;`, addressColumns)
+
+	result := make([]*addresses.Address, 0)
+	if err := r.db.Select(&result, query, userId); err != nil {
+		return nil, fmt.Errorf("get addresses failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *addressRepository) FindOneAddress(userId, addressId string) (*addresses.Address, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM "addresses"
+	WHERE "user_id" = $1
+	AND "id" = $2;`, addressColumns)
+
+	result := new(addresses.Address)
+	if err := r.db.Get(result, query, userId, addressId); err != nil {
+		return nil, fmt.Errorf("get address failed: %v", err)
+	}
+	return result, nil
+}
+
+// lockUser serializes the address changes of a user, the user row is locked since a user without addresses has no row to lock.
+// Two first addresses made at once would both be the default otherwise, and the second would hit the partial unique index
+func lockUser(ctx context.Context, tx *sqlx.Tx, userId string) error {
+	var id string
+	if err := tx.GetContext(ctx, &id, `SELECT "id" FROM "users" WHERE "id" = $1 FOR NO KEY UPDATE;`, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user not found")
+		}
+		return fmt.Errorf("lock user failed: %v", err)
+	}
+	return nil
+}
+
+// clearDefault unsets the current default address, so the partial unique index is never violated
+func clearDefault(ctx context.Context, tx *sqlx.Tx, userId string) error {
+	query := `
+	UPDATE "addresses" SET
+		"is_default" = FALSE
+	WHERE "user_id" = $1
+	AND "is_default";`
+
+	if _, err := tx.ExecContext(ctx, query, userId); err != nil {
+		return fmt.Errorf("clear default address failed: %v", err)
+	}
+	return nil
+}
+
+// promoteDefault makes the newest address the default one when the user has none left,
+// exceptId is only taken when it is the last address of the user
+func promoteDefault(ctx context.Context, tx *sqlx.Tx, userId, exceptId string) error {
+	query := `
+	UPDATE "addresses" SET
+		"is_default" = TRUE
+	WHERE "id" = (
+		SELECT "id"
+		FROM "addresses"
+		WHERE "user_id" = $1
+		ORDER BY "id"::TEXT = $2, "created_at" DESC, "id" DESC
+		LIMIT 1
+	)
+	AND NOT EXISTS (
+		SELECT 1
+		FROM "addresses"
+		WHERE "user_id" = $1
+		AND "is_default"
+	);`
+
+	if _, err := tx.ExecContext(ctx, query, userId, exceptId); err != nil {
+		return fmt.Errorf("promote default address failed: %v", err)
+	}
+	return nil
+}
+
+func (r *addressRepository) InsertAddress(req *addresses.Address) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if err := lockUser(ctx, tx, req.UserId); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	// The first address of a user is always the default one
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM "addresses" WHERE "user_id" = $1;`, req.UserId); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("count addresses failed: %v", err)
+	}
+	if count == 0 {
+		req.IsDefault = true
+	}
+
+	if req.IsDefault {
+		if err := clearDefault(ctx, tx, req.UserId); err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	query := `
+	INSERT INTO "addresses" (
+		"user_id",
+		"recipient",
+		"phone",
+		"line1",
+		"line2",
+		"district",
+		"province",
+		"postal_code",
+		"country",
+		"is_default"
+	)
+	VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, COALESCE(NULLIF($9, ''), 'TH'), $10)
+		RETURNING "id";`
+
+	if err := tx.QueryRowxContext(
+		ctx,
+		query,
+		req.UserId,
+		req.Recipient,
+		req.Phone,
+		req.Line1,
+		req.Line2,
+		req.District,
+		req.Province,
+		req.PostalCode,
+		req.Country,
+		req.IsDefault,
+	).Scan(&req.Id); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("insert address failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	return req.Id, nil
+}
+
+func (r *addressRepository) UpdateAddress(req *addresses.UpdateAddressReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := lockUser(ctx, tx, req.UserId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if req.IsDefault != nil && *req.IsDefault {
+		if err := clearDefault(ctx, tx, req.UserId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Empty fields keep their current value, line2 and is_default only when nil
+	query := `
+	UPDATE "addresses" SET
+		"recipient" = COALESCE(NULLIF($1, ''), "recipient"),
+		"phone" = COALESCE(NULLIF($2, ''), "phone"),
+		"line1" = COALESCE(NULLIF($3, ''), "line1"),
+		"line2" = COALESCE($4, "line2"),
+		"district" = COALESCE(NULLIF($5, ''), "district"),
+		"province" = COALESCE(NULLIF($6, ''), "province"),
+		"postal_code" = COALESCE(NULLIF($7, ''), "postal_code"),
+		"country" = COALESCE(NULLIF($8, ''), "country"),
+		"is_default" = COALESCE($9, "is_default")
+	WHERE "user_id" = $10
+	AND "id" = $11;`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		req.Recipient,
+		req.Phone,
+		req.Line1,
+		req.Line2,
+		req.District,
+		req.Province,
+		req.PostalCode,
+		req.Country,
+		req.IsDefault,
+		req.UserId,
+		req.Id,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update address failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("address not found")
+	}
+
+	// An unset default moves to the newest other address, the only address stays the default
+	if req.IsDefault != nil && !*req.IsDefault {
+		if err := promoteDefault(ctx, tx, req.UserId, req.Id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+func (r *addressRepository) DeleteAddress(userId, addressId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := lockUser(ctx, tx, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := `
+	DELETE FROM "addresses"
+	WHERE "user_id" = $1
+	AND "id" = $2
+		RETURNING "is_default";`
+
+	var isDefault bool
+	if err := tx.QueryRowxContext(ctx, query, userId, addressId).Scan(&isDefault); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("address not found")
+		}
+		return fmt.Errorf("delete address failed: %v", err)
+	}
+
+	// The newest remaining address takes over as the default
+	if isDefault {
+		if err := promoteDefault(ctx, tx, userId, addressId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package addressUsecases
+
+import (
+	"go_learn_project_rest_api/modules/addresses"
+	"go_learn_project_rest_api/modules/addresses/addressRepositories"
+)
+
+type IAddressUsecases interface {
+	FindAddress(userId string) ([]*addresses.Address, error)
+	FindOneAddress(userId, addressId string) (*addresses.Address, error)
+	InsertAddress(*addresses.Address) (*addresses.Address, error)
+	UpdateAddress(*addresses.UpdateAddressReq) (*addresses.Address, error)
+	DeleteAddress(userId, addressId string) error
+}
+
+type addressUsecases struct {
+	addressRepository addressRepositories.IAddressRepository
+}
+
+func AddressUsecases(addressRepository addressRepositories.IAddressRepository) IAddressUsecases {
+	return &addressUsecases{
+		addressRepository: addressRepository,
+	}
+}
+
+func (u *addressUsecases) FindAddress(userId string) ([]*addresses.Address, error) {
+	return u.addressRepository.FindAddress(userId)
+}
+
+func (u *addressUsecases) FindOneAddress(userId, addressId string) (*addresses.Address, error) {
+	return u.addressRepository.FindOneAddress(userId, addressId)
+}
+
+func (u *addressUsecases) InsertAddress(req *addresses.Address) (*addresses.Address, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	addressId, err := u.addressRepository.InsertAddress(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.addressRepository.FindOneAddress(req.UserId, addressId)
+}
+
+func (u *addressUsecases) UpdateAddress(req *addresses.UpdateAddressReq) (*addresses.Address, error) {
+	if err := u.addressRepository.UpdateAddress(req); err != nil {
+		return nil, err
+	}
+	return u.addressRepository.FindOneAddress(req.UserId, req.Id)
+}
+
+func (u *addressUsecases) DeleteAddress(userId, addressId string) error {
+	return u.addressRepository.DeleteAddress(userId, addressId)
+}
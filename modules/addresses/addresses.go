@@ -0,0 +1,70 @@
+package addresses
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Address struct {
+	Id         string `db:"id" json:"id"`
+	UserId     string `db:"user_id" json:"user_id"`
+	Recipient  string `db:"recipient" json:"recipient"`
+	Phone      string `db:"phone" json:"phone"`
+	Line1      string `db:"line1" json:"line1"`
+	Line2      string `db:"line2" json:"line2"`
+	District   string `db:"district" json:"district"`
+	Province   string `db:"province" json:"province"`
+	PostalCode string `db:"postal_code" json:"postal_code"`
+	Country    string `db:"country" json:"country"`
+	IsDefault  bool   `db:"is_default" json:"is_default"`
+	CreatedAt  string `db:"created_at" json:"created_at"`
+	UpdatedAt  string `db:"updated_at" json:"updated_at"`
+}
+
+// UpdateAddressReq keeps the fields left empty, line2 and is_default are pointers so they can be cleared
+type UpdateAddressReq struct {
+	Id         string  `json:"-"`
+	UserId     string  `json:"-"`
+	Recipient  string  `json:"recipient"`
+	Phone      string  `json:"phone"`
+	Line1      string  `json:"line1"`
+	Line2      *string `json:"line2"` // nil keeps it, an empty string clears it
+	District   string  `json:"district"`
+	Province   string  `json:"province"`
+	PostalCode string  `json:"postal_code"`
+	Country    string  `json:"country"`
+	IsDefault  *bool   `json:"is_default"` // nil keeps it, false hands the default to another address
+}
+
+func (a *Address) Validate() error {
+	fields := map[string]string{
+		"recipient":   a.Recipient,
+		"phone":       a.Phone,
+		"line1":       a.Line1,
+		"district":    a.District,
+		"province":    a.Province,
+		"postal_code": a.PostalCode,
+	}
+	for _, key := range []string{"recipient", "phone", "line1", "district", "province", "postal_code"} {
+		if strings.TrimSpace(fields[key]) == "" {
+			return fmt.Errorf("%s is required", key)
+		}
+	}
+	return nil
+}
+
+// Format flattens the address into the one line form stored in orders.address
+func (a *Address) Format() string {
+	parts := make([]string, 0)
+	for _, p := range []string{a.Line1, a.Line2, a.District, a.Province, a.PostalCode, a.Country} {
+		if strings.TrimSpace(p) != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Contact is the one line contact stored in orders.contact
+func (a *Address) Contact() string {
+	return fmt.Sprintf("%s %s", a.Recipient, a.Phone)
+}
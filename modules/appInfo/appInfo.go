@@ -1,13 +1,68 @@
 package appInfo
 
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	CategoryFlat = "flat"
+	CategoryTree = "tree"
+)
+
 type CategoryFilter struct {
-	Title string `query:"title"`
+	Title      string `query:"title"`      // flat view only
+	View       string `query:"view"`       // flat, tree
+	Breadcrumb string `query:"breadcrumb"` // category id or slug, gives the path from its root
 }
 type Category struct {
-	Id    int    `db:"id" json:"id"`
-	Title string `db:"title" json:"title"`
+	Id        int         `db:"id" json:"id"`
+	Title     string      `db:"title" json:"title"`
+	Slug      string      `db:"slug" json:"slug"`
+	ParentId  *int        `db:"parent_id" json:"parent_id"`
+	SortOrder int         `db:"sort_order" json:"sort_order"`
+	Children  []*Category `db:"-" json:"children,omitempty"`
 }
 
 type RequestCategoryId struct {
 	Id int `json:"category_id"`
 }
+
+// UpdateCategoryReq leaves empty fields as they are, ParentId 0 moves the category to the root
+type UpdateCategoryReq struct {
+	Id        int    `json:"category_id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	ParentId  *int   `json:"parent_id"`
+	SortOrder *int   `json:"sort_order"`
+}
+
+// Slugify lowercases the title and joins its words with -, e.g. Food & Beverage gives food-beverage
+func Slugify(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+	})
+	return strings.Join(words, "-")
+}
+
+// BuildTree nests categories under their parents, keeping the order they come in.
+// A category whose parent is not in the list becomes a root
+func BuildTree(categories []*Category) []*Category {
+	nodes := make(map[int]*Category, len(categories))
+	for _, c := range categories {
+		c.Children = nil
+		nodes[c.Id] = c
+	}
+
+	roots := make([]*Category, 0)
+	for _, c := range categories {
+		if c.ParentId != nil {
+			if parent, ok := nodes[*c.ParentId]; ok {
+				parent.Children = append(parent.Children, c)
+				continue
+			}
+		}
+		roots = append(roots, c)
+	}
+	return roots
+}
@@ -17,12 +17,14 @@ const (
 	findCategoryErrCode        appInfoHandlerErrCode = "appInfo-002"
 	insertCategoryErrCode      appInfoHandlerErrCode = "appInfo-003"
 	deleteCategoryErrCode      appInfoHandlerErrCode = "appInfo-004"
+	updateCategoryErrCode      appInfoHandlerErrCode = "appInfo-005"
 )
 
 type IAppInfoHandler interface {
 	GenerateApiKey(fiber.Ctx) error
 	FindCategory(fiber.Ctx) error
 	InsertCategory(fiber.Ctx) error
+	UpdateCategory(fiber.Ctx) error
 	DeleteCategory(fiber.Ctx) error
 }
 
@@ -66,6 +68,16 @@ func (h *appInfoHandler) FindCategory(c fiber.Ctx) error {
 			err.Error(),
 		).Res()
 	}
+	if req.View == "" {
+		req.View = appInfo.CategoryFlat
+	}
+	if req.View != appInfo.CategoryFlat && req.View != appInfo.CategoryTree {
+		return entities.NewResponse(c).Error(
+			fiber.StatusBadRequest,
+			string(findCategoryErrCode),
+			"view must be flat or tree",
+		).Res()
+	}
 
 	category, err := h.appInfoUsecases.FindCategory(req)
 	if err != nil {
@@ -96,6 +108,16 @@ func (h *appInfoHandler) InsertCategory(c fiber.Ctx) error {
 		).Res()
 	}
 
+	for _, cate := range req {
+		if cate.Title == "" {
+			return entities.NewResponse(c).Error(
+				fiber.StatusBadRequest,
+				string(insertCategoryErrCode),
+				"category title is required",
+			).Res()
+		}
+	}
+
 	if err := h.appInfoUsecases.InsertCategory(req); err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.StatusInternalServerError,
@@ -106,6 +128,40 @@ func (h *appInfoHandler) InsertCategory(c fiber.Ctx) error {
 	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, req).Res()
 }
 
+func (h *appInfoHandler) UpdateCategory(c fiber.Ctx) error {
+	req := new(appInfo.UpdateCategoryReq)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.StatusBadRequest,
+			string(updateCategoryErrCode),
+			err.Error(),
+		).Res()
+	}
+	if req.Id <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.StatusBadRequest,
+			string(updateCategoryErrCode),
+			"category_id is invalid",
+		).Res()
+	}
+	if req.ParentId != nil && *req.ParentId < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.StatusBadRequest,
+			string(updateCategoryErrCode),
+			"parent_id is invalid",
+		).Res()
+	}
+
+	if err := h.appInfoUsecases.UpdateCategory(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.StatusBadRequest,
+			string(updateCategoryErrCode),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, req).Res()
+}
+
 func (h *appInfoHandler) DeleteCategory(c fiber.Ctx) error {
 	req := new(appInfo.RequestCategoryId)
 	if err := c.Bind().Body(&req); err != nil {
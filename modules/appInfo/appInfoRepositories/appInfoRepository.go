@@ -11,7 +11,9 @@ import (
 
 type IAppInfoRepository interface {
 	FindCategory(*appInfo.CategoryFilter) ([]*appInfo.Category, error)
+	FindCategoryBreadcrumb(string) ([]*appInfo.Category, error)
 	InsertCategory([]*appInfo.Category) error
+	UpdateCategory(*appInfo.UpdateCategoryReq) error
 	DeleteCategory(int) error
 }
 
@@ -29,7 +31,10 @@ func (r *appInfoRepository) FindCategory(req *appInfo.CategoryFilter) ([]*appInf
 	query := `
         SELECT 
             id,
-            title
+            title,
+            slug,
+            parent_id,
+            sort_order
         FROM categories
     `
 
@@ -40,6 +45,9 @@ func (r *appInfoRepository) FindCategory(req *appInfo.CategoryFilter) ([]*appInf
         `
 		filterValue = append(filterValue, "%"+strings.ToLower(req.Title)+"%")
 	}
+	query += `
+        ORDER BY sort_order, title, id;
+    `
 
 	category := make([]*appInfo.Category, 0)
 	if err := r.db.Select(&category, query, filterValue...); err != nil {
@@ -49,6 +57,54 @@ func (r *appInfoRepository) FindCategory(req *appInfo.CategoryFilter) ([]*appInf
 	return category, nil
 }
 
+// FindCategoryBreadcrumb walks up from the category (id or slug) and returns the path from its root
+func (r *appInfoRepository) FindCategoryBreadcrumb(key string) ([]*appInfo.Category, error) {
+	query := `
+        WITH RECURSIVE path AS (
+            SELECT
+                *
+            FROM (
+                SELECT
+                    c.id,
+                    c.title,
+                    c.slug,
+                    c.parent_id,
+                    c.sort_order,
+                    0 AS depth
+                FROM categories c
+                WHERE c.id::TEXT = $1
+                OR c.slug = $1
+                ORDER BY (c.id::TEXT = $1) DESC
+                LIMIT 1
+            ) AS start
+            UNION ALL
+            SELECT
+                c.id,
+                c.title,
+                c.slug,
+                c.parent_id,
+                c.sort_order,
+                p.depth + 1
+            FROM categories c
+            JOIN path p ON c.id = p.parent_id
+        )
+        SELECT
+            id,
+            title,
+            slug,
+            parent_id,
+            sort_order
+        FROM path
+        ORDER BY depth DESC;
+    `
+
+	breadcrumb := make([]*appInfo.Category, 0)
+	if err := r.db.Select(&breadcrumb, query, key); err != nil {
+		return nil, fmt.Errorf("select category breadcrumb failed: %v", err)
+	}
+	return breadcrumb, nil
+}
+
 func (r *appInfoRepository) InsertCategory(req []*appInfo.Category) error {
 	ctx := context.Background()
 	tx, err := r.db.BeginTxx(ctx, nil)
@@ -58,21 +114,26 @@ func (r *appInfoRepository) InsertCategory(req []*appInfo.Category) error {
 
 	query := `
         INSERT INTO categories (
-            title
+            title,
+            slug,
+            parent_id,
+            sort_order
         ) VALUES
     `
 
 	valuesStack := make([]any, 0)
+	var index int
 	for i, cate := range req {
-		valuesStack = append(valuesStack, cate.Title)
+		valuesStack = append(valuesStack, cate.Title, cate.Slug, cate.ParentId, cate.SortOrder)
 
 		if i != len(req)-1 {
 			query += fmt.Sprintf(`
-		($%d),`, i+1)
+		($%d, $%d, $%d, $%d),`, index+1, index+2, index+3, index+4)
 		} else {
 			query += fmt.Sprintf(`
-		($%d)`, i+1)
+		($%d, $%d, $%d, $%d)`, index+1, index+2, index+3, index+4)
 		}
+		index += 4
 	}
 	query += `
         RETURNING id;
@@ -87,10 +148,16 @@ func (r *appInfoRepository) InsertCategory(req []*appInfo.Category) error {
 	var i int
 	for rows.Next() {
 		if err := rows.Scan(&req[i].Id); err != nil {
+			rows.Close()
+			tx.Rollback()
 			return fmt.Errorf("scan categories id failed: %v", err)
 		}
 		i++
 	}
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert category failed: %v", err)
+	}
 
 	if err := tx.Commit(); err != nil {
 		tx.Rollback()
@@ -100,13 +167,106 @@ func (r *appInfoRepository) InsertCategory(req []*appInfo.Category) error {
 	return nil
 }
 
+func (r *appInfoRepository) UpdateCategory(req *appInfo.UpdateCategoryReq) error {
+	ctx := context.Background()
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if req.ParentId != nil && *req.ParentId != 0 {
+		// Moves are serialized so two of them can not build a cycle together
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE;`); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("lock categories failed: %v", err)
+		}
+
+		query := `
+        WITH RECURSIVE tree AS (
+            SELECT
+                id
+            FROM categories
+            WHERE id = $1
+            UNION
+            SELECT
+                c.id
+            FROM categories c
+            JOIN tree t ON c.parent_id = t.id
+        )
+        SELECT EXISTS (SELECT 1 FROM tree WHERE id = $2);
+    `
+		var isCycle bool
+		if err := tx.QueryRowxContext(ctx, query, req.Id, *req.ParentId).Scan(&isCycle); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("check category parent failed: %v", err)
+		}
+		if isCycle {
+			tx.Rollback()
+			return fmt.Errorf("category can not be moved under itself or its children")
+		}
+	}
+
+	fields := make([]string, 0)
+	values := make([]any, 0)
+	if req.Title != "" {
+		values = append(values, req.Title)
+		fields = append(fields, fmt.Sprintf(`title = $%d`, len(values)))
+	}
+	if req.Slug != "" {
+		values = append(values, req.Slug)
+		fields = append(fields, fmt.Sprintf(`slug = $%d`, len(values)))
+	}
+	if req.ParentId != nil {
+		values = append(values, *req.ParentId)
+		fields = append(fields, fmt.Sprintf(`parent_id = NULLIF($%d, 0)`, len(values)))
+	}
+	if req.SortOrder != nil {
+		values = append(values, *req.SortOrder)
+		fields = append(fields, fmt.Sprintf(`sort_order = $%d`, len(values)))
+	}
+	if len(fields) == 0 {
+		tx.Rollback()
+		return fmt.Errorf("nothing to update")
+	}
+	values = append(values, req.Id)
+
+	query := fmt.Sprintf(`UPDATE categories SET %s WHERE id = $%d;`, strings.Join(fields, ", "), len(values))
+	result, err := tx.ExecContext(ctx, query, values...)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update category failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("category %d not found", req.Id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
 func (r *appInfoRepository) DeleteCategory(id int) error {
 	ctx := context.Background()
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	query := `DELETE FROM categories WHERE id = $1;`
+
+	// Children move up to the parent of the deleted category
+	query := `
+        UPDATE categories SET
+            parent_id = (SELECT parent_id FROM categories WHERE id = $1)
+        WHERE parent_id = $1;
+    `
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("move child categories failed: %v", err)
+	}
+
+	query = `DELETE FROM categories WHERE id = $1;`
 
 	if _, err := tx.ExecContext(ctx, query, id); err != nil {
 		tx.Rollback()
@@ -1,6 +1,7 @@
 package appInfoUsecases
 
 import (
+	"fmt"
 	"go_learn_project_rest_api/modules/appInfo"
 	"go_learn_project_rest_api/modules/appInfo/appInfoRepositories"
 )
@@ -8,6 +9,7 @@ import (
 type IAppInfoUsecases interface {
 	FindCategory(*appInfo.CategoryFilter) ([]*appInfo.Category, error)
 	InsertCategory([]*appInfo.Category) error
+	UpdateCategory(*appInfo.UpdateCategoryReq) error
 	DeleteCategory(int) error
 }
 
@@ -22,20 +24,58 @@ func AppInfoUsecases(repo appInfoRepositories.IAppInfoRepository) IAppInfoUsecas
 }
 
 func (u *appInfoUsecases) FindCategory(req *appInfo.CategoryFilter) ([]*appInfo.Category, error) {
+	if req.Breadcrumb != "" {
+		return u.appInfoRepositories.FindCategoryBreadcrumb(req.Breadcrumb)
+	}
+
+	if req.View == appInfo.CategoryTree {
+		// A title filter would cut branches out of the tree
+		req.Title = ""
+	}
 	category, err := u.appInfoRepositories.FindCategory(req)
 	if err != nil {
 		return nil, err
 	}
+	if req.View == appInfo.CategoryTree {
+		return appInfo.BuildTree(category), nil
+	}
 	return category, nil
 }
 
 func (u *appInfoUsecases) InsertCategory(req []*appInfo.Category) error {
+	for _, cate := range req {
+		if cate.Slug == "" {
+			cate.Slug = cate.Title
+		}
+		cate.Slug = appInfo.Slugify(cate.Slug)
+		if cate.Slug == "" {
+			return fmt.Errorf("slug of category %s is required", cate.Title)
+		}
+		if cate.ParentId != nil && *cate.ParentId == 0 {
+			cate.ParentId = nil
+		}
+	}
 	if err := u.appInfoRepositories.InsertCategory(req); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (u *appInfoUsecases) UpdateCategory(req *appInfo.UpdateCategoryReq) error {
+	if req.ParentId != nil && *req.ParentId == req.Id {
+		return fmt.Errorf("category can not be its own parent")
+	}
+	if req.Slug != "" {
+		if req.Slug = appInfo.Slugify(req.Slug); req.Slug == "" {
+			return fmt.Errorf("slug is invalid")
+		}
+	}
+	if err := u.appInfoRepositories.UpdateCategory(req); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (u *appInfoUsecases) DeleteCategory(id int) error {
 	if err := u.appInfoRepositories.DeleteCategory(id); err != nil {
 		return err
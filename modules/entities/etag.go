@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrVersionConflict is returned by an update when the row changed after the client read it
+	ErrVersionConflict = errors.New("it was changed by someone else, fetch it again and retry")
+	ErrIfMatchRequired = errors.New("If-Match header is required, send the ETag of the last GET")
+)
+
+// ETag is the strong entity tag of a row version
+func ETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ParseIfMatch gives the version an If-Match header asks for, * matches any version and gives 0
+func ParseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, ErrIfMatchRequired
+	}
+	if header == "*" {
+		return 0, nil
+	}
+
+	// If-Match compares strongly, a weak tag can never match
+	if len(header) < 3 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, fmt.Errorf("If-Match must be a single strong ETag")
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("If-Match must be a single strong ETag")
+	}
+	return version, nil
+}
@@ -1,5 +1,11 @@
 package entities
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
 type PaginationReq struct {
 	Page      int `query:"page"`
 	Limit     int `query:"limit"`
@@ -11,3 +17,77 @@ type SortReq struct {
 	OrderBy string `query:"order_by"`
 	Sort    string `query:"sort"`
 }
+
+// CursorReq switches a listing to keyset pagination, paginate=cursor starts from the first page
+type CursorReq struct {
+	Paginate  string  `query:"paginate"` // offset, cursor
+	Cursor    string  `query:"cursor"`
+	SkipCount bool    `query:"skip_count"`
+	After     *Cursor `query:"-"` // decoded Cursor, nil on the first page
+}
+
+// Cursor points at the row a keyset page continues from, it is only valid for the sort it was made with
+type Cursor struct {
+	OrderBy  string `json:"o"`
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	Id       string `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+func (r *CursorReq) IsCursor() bool {
+	return r.Paginate == "cursor" || r.Cursor != ""
+}
+
+// Decode checks the cursor against the sort of the request, the sort must be normalized beforehand
+func (r *CursorReq) Decode(sort *SortReq) error {
+	if r.Cursor == "" {
+		return nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(r.Cursor)
+	if err != nil {
+		return fmt.Errorf("cursor is invalid")
+	}
+	cursor := new(Cursor)
+	if err := json.Unmarshal(raw, cursor); err != nil || cursor.Id == "" {
+		return fmt.Errorf("cursor is invalid")
+	}
+	if cursor.OrderBy != sort.OrderBy || cursor.Sort != sort.Sort {
+		return fmt.Errorf("cursor does not match order_by and sort")
+	}
+	r.After = cursor
+	return nil
+}
+
+func (c *Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// CursorPage works out the cursors around a page, key gives the sort value and id of row i in display order
+// and hasMore tells whether a row was found beyond the page in the direction it was read
+func CursorPage(req *CursorReq, sort *SortReq, rows int, hasMore bool, key func(i int) (string, string)) (next, prev string) {
+	if rows == 0 {
+		return "", ""
+	}
+	cursor := func(i int, backward bool) string {
+		value, id := key(i)
+		return (&Cursor{
+			OrderBy:  sort.OrderBy,
+			Sort:     sort.Sort,
+			Value:    value,
+			Id:       id,
+			Backward: backward,
+		}).Encode()
+	}
+
+	backward := req.After != nil && req.After.Backward
+	if hasMore || backward {
+		next = cursor(rows-1, false)
+	}
+	if (hasMore && backward) || (req.After != nil && !backward) {
+		prev = cursor(0, true)
+	}
+	return next, prev
+}
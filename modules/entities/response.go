@@ -65,3 +65,12 @@ type PaginateRes struct {
 	TotalPage int `json:"total_page"`
 	TotalItem int `json:"total_item"`
 }
+
+// CursorPaginateRes is the keyset counterpart of PaginateRes, an empty cursor means there is no page that way
+type CursorPaginateRes struct {
+	Data       any    `json:"data"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+	TotalItem  *int   `json:"total_item,omitempty"` // nil when skip_count is set
+}
@@ -1,15 +1,11 @@
 package fileHandlers
 
 import (
-	"fmt"
 	"go_learn_project_rest_api/config"
 	"go_learn_project_rest_api/modules/entities"
 	"go_learn_project_rest_api/modules/files"
 	"go_learn_project_rest_api/modules/files/fileUsecases"
 	"go_learn_project_rest_api/pkgs/utils"
-	"math"
-	"path/filepath"
-	"strings"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -53,27 +49,13 @@ func (h *fileHandlers) UploadFiles(c fiber.Ctx) error {
 	filesReq := form.File["files"]
 	destination := c.FormValue("destination")
 
-	extMap := map[string]string{
-		"png":  "png",
-		"jpg":  "jpg",
-		"jpeg": "jpeg",
-	}
-
 	for _, file := range filesReq {
-		ext := strings.TrimPrefix(filepath.Ext(file.Filename), ".")
-		if extMap[ext] != ext || extMap[ext] == "" {
-			return entities.NewResponse(c).Error(
-				fiber.StatusBadRequest,
-				string(uploadErr),
-				"extension is not acceptable",
-			).Res()
-		}
-
-		if file.Size > int64(h.cfg.App().FileLimit()) {
+		ext, err := files.ValidateImage(file, h.cfg.App().FileLimit())
+		if err != nil {
 			return entities.NewResponse(c).Error(
 				fiber.StatusBadRequest,
 				string(uploadErr),
-				fmt.Sprintf("file size must less than %d MiB", int(math.Ceil(float64(h.cfg.App().FileLimit())/math.Pow(1024, 2)))),
+				err.Error(),
 			).Res()
 		}
 
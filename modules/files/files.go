@@ -1,6 +1,12 @@
 package files
 
-import "mime/multipart"
+import (
+	"fmt"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+)
 
 type FileReq struct {
 	File        *multipart.FileHeader `form:"file"`
@@ -18,3 +24,22 @@ type DeleteFileReq struct {
 	Destination string `json:"destination"`
 	FileName    string `json:"filename"`
 }
+
+var imageExtMap = map[string]string{
+	"png":  "png",
+	"jpg":  "jpg",
+	"jpeg": "jpeg",
+}
+
+// ValidateImage checks the extension and size of an uploaded image and returns its extension
+func ValidateImage(file *multipart.FileHeader, limit int) (string, error) {
+	ext := strings.TrimPrefix(filepath.Ext(file.Filename), ".")
+	if imageExtMap[ext] != ext || imageExtMap[ext] == "" {
+		return "", fmt.Errorf("extension is not acceptable")
+	}
+
+	if file.Size > int64(limit) {
+		return "", fmt.Errorf("file size must less than %d MiB", int(math.Ceil(float64(limit)/math.Pow(1024, 2))))
+	}
+	return ext, nil
+}
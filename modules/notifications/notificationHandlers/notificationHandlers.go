@@ -0,0 +1,62 @@
+package notificationHandlers
+
+import (
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/notifications/notificationUsecases"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type notificationHandlersErrCode string
+
+const (
+	findNotificationErr notificationHandlersErrCode = "notifications-001"
+	readNotificationErr notificationHandlersErrCode = "notifications-002"
+)
+
+type INotificationHandlers interface {
+	FindNotification(fiber.Ctx) error
+	ReadNotification(fiber.Ctx) error
+}
+
+type notificationHandlers struct {
+	cfg                  config.IConfig
+	notificationUsecases notificationUsecases.INotificationUsecases
+}
+
+func NotificationHandlers(cfg config.IConfig, notificationUsecases notificationUsecases.INotificationUsecases) INotificationHandlers {
+	return &notificationHandlers{
+		cfg:                  cfg,
+		notificationUsecases: notificationUsecases,
+	}
+}
+
+func (h *notificationHandlers) FindNotification(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+
+	result, err := h.notificationUsecases.FindNotification(userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findNotificationErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *notificationHandlers) ReadNotification(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	notificationId := strings.Trim(c.Params("notification_id"), " ")
+
+	if err := h.notificationUsecases.ReadNotification(userId, notificationId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(readNotificationErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, nil).Res()
+}
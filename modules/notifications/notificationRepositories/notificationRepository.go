@@ -0,0 +1,92 @@
+package notificationRepositories
+
+import (
+	"context"
+	"fmt"
+	"go_learn_project_rest_api/modules/notifications"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type INotificationRepository interface {
+	FindNotification(userId string) ([]*notifications.Notification, error)
+	InsertNotification(*notifications.Notification) error
+	ReadNotification(userId, notificationId string) error
+}
+
+type notificationRepository struct {
+	db *sqlx.DB
+}
+
+func NotificationRepository(db *sqlx.DB) INotificationRepository {
+	return &notificationRepository{
+		db: db,
+	}
+}
+
+func (r *notificationRepository) FindNotification(userId string) ([]*notifications.Notification, error) {
+	query := `
+	SELECT
+		"id",
+		"user_id",
+		COALESCE("order_id", '') AS "order_id",
+		"type",
+		"message",
+		COALESCE("read_at"::TEXT, '') AS "read_at",
+		"created_at"::TEXT AS "created_at"
+	FROM "notifications"
+	WHERE "user_id" = $1
+	ORDER BY "created_at" DESC
+	LIMIT 50;`
+
+	result := make([]*notifications.Notification, 0)
+	if err := r.db.Select(&result, query, userId); err != nil {
+		return nil, fmt.Errorf("get notifications failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *notificationRepository) InsertNotification(req *notifications.Notification) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	query := `
+	INSERT INTO "notifications" (
+		"user_id",
+		"order_id",
+		"type",
+		"message"
+	)
+	VALUES ($1, NULLIF($2, ''), $3, $4)
+	RETURNING "id";`
+
+	if err := r.db.QueryRowContext(
+		ctx,
+		query,
+		req.UserId,
+		req.OrderId,
+		req.Type,
+		req.Message,
+	).Scan(&req.Id); err != nil {
+		return fmt.Errorf("insert notification failed: %v", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) ReadNotification(userId, notificationId string) error {
+	query := `
+	UPDATE "notifications" SET
+		"read_at" = COALESCE("read_at", now())
+	WHERE "user_id" = $1
+	AND "id" = $2;`
+
+	res, err := r.db.ExecContext(context.Background(), query, userId, notificationId)
+	if err != nil {
+		return fmt.Errorf("read notification failed: %v", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return fmt.Errorf("notification not found")
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package notificationUsecases
+
+import (
+	"go_learn_project_rest_api/modules/notifications"
+	"go_learn_project_rest_api/modules/notifications/notificationRepositories"
+)
+
+type INotificationUsecases interface {
+	FindNotification(userId string) ([]*notifications.Notification, error)
+	ReadNotification(userId, notificationId string) error
+}
+
+type notificationUsecases struct {
+	notificationRepository notificationRepositories.INotificationRepository
+}
+
+func NotificationUsecases(notificationRepository notificationRepositories.INotificationRepository) INotificationUsecases {
+	return &notificationUsecases{
+		notificationRepository: notificationRepository,
+	}
+}
+
+func (u *notificationUsecases) FindNotification(userId string) ([]*notifications.Notification, error) {
+	return u.notificationRepository.FindNotification(userId)
+}
+
+func (u *notificationUsecases) ReadNotification(userId, notificationId string) error {
+	return u.notificationRepository.ReadNotification(userId, notificationId)
+}
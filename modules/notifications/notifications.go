@@ -0,0 +1,16 @@
+package notifications
+
+const (
+	SlipRejected = "slip_rejected"
+	SlipApproved = "slip_approved"
+)
+
+type Notification struct {
+	Id        string `db:"id" json:"id"`
+	UserId    string `db:"user_id" json:"user_id"`
+	OrderId   string `db:"order_id" json:"order_id"`
+	Type      string `db:"type" json:"type"`
+	Message   string `db:"message" json:"message"`
+	ReadAt    string `db:"read_at" json:"read_at"`
+	CreatedAt string `db:"created_at" json:"created_at"`
+}
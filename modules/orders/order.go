@@ -1,8 +1,13 @@
 package orders
 
 import (
+	"errors"
+	"fmt"
+	"go_learn_project_rest_api/modules/addresses"
 	"go_learn_project_rest_api/modules/entities"
 	"go_learn_project_rest_api/modules/products"
+	"go_learn_project_rest_api/modules/taxes"
+	"math"
 )
 
 type OrderFilter struct {
@@ -12,30 +17,327 @@ type OrderFilter struct {
 	EndDate   string `query:"end_date"`
 	*entities.PaginationReq
 	*entities.SortReq
+	*entities.CursorReq
 }
 
+// CursorValue is the value of the sort key a cursor continues from
+func (o *Order) CursorValue(orderBy string) string {
+	if orderBy == `"o"."created_at"` {
+		return o.CreatedAt
+	}
+	return o.Id
+}
+
+// PaidStatus are the statuses of an order that has been paid for
+var PaidStatus = []string{"paid", "shipping", "completed"}
+
+// CancelableStatus are the statuses a customer can still cancel an order from, nothing has been paid or shipped yet
+var CancelableStatus = []string{"waiting", "pending_verification"}
+
+// ErrOrderNotFound is returned by an update of an order that does not exist or belongs to another user
+var ErrOrderNotFound = errors.New("order not found")
+
+// ErrStatusNotAllowed is returned by an update when the order is not in one of the statuses it may change from
+var ErrStatusNotAllowed = errors.New("order status does not allow this change")
+
+// PaidOrderStatus is PaidStatus as a condition on orders aliased o
+const PaidOrderStatus = `o.status IN ('paid', 'shipping', 'completed')`
+
 type Order struct {
-	Id           string           `db:"id" json:"id"`
-	UserId       string           `db:"user_id" json:"user_id"`
-	TransferSlip *TransferSlip    `db:"transfer_slip" json:"transfer_slip"`
-	Products     []*ProductsOrder `json:"products"`
-	Address      string           `db:"address" json:"address"`
-	Contact      string           `db:"contact" json:"contact"`
-	Status       string           `db:"status" json:"status"`
-	TotalPaid    float64          `db:"total_paid" json:"total_paid"`
-	CreatedAt    string           `db:"created_at" json:"created_at"`
-	UpdatedAt    string           `db:"updated_at" json:"updated_at"`
+	Id              string             `db:"id" json:"id"`
+	UserId          string             `db:"user_id" json:"user_id"`
+	TransferSlip    *TransferSlip      `db:"transfer_slip" json:"transfer_slip"`
+	Products        []*ProductsOrder   `json:"products"`
+	Address         string             `db:"address" json:"address"`
+	Contact         string             `db:"contact" json:"contact"`
+	Note            string             `db:"note" json:"note"`
+	AddressId       string             `db:"address_id" json:"address_id"`
+	ShippingAddress *addresses.Address `db:"shipping_address" json:"shipping_address"`
+	Status          string             `db:"status" json:"status"`
+	CouponCode      string             `db:"coupon_code" json:"coupon_code"`
+	Discount        float64            `db:"discount" json:"discount"`
+	TaxTotal        float64            `db:"tax_total" json:"tax_total"`
+	TotalPaid       float64            `db:"total_paid" json:"total_paid"`
+	RefundedAmount  float64            `db:"refunded_amount" json:"refunded_amount"`
+	Refunds         []*Refund          `json:"refunds"`
+	Comments        []*OrderComment    `json:"comments,omitempty"` // staff only, never set for customers
+	CreatedAt       string             `db:"created_at" json:"created_at"`
+	UpdatedAt       string             `db:"updated_at" json:"updated_at"`
+	Version         int                `db:"version" json:"version"` // the ETag, an update has to send it as If-Match
 }
 
+const (
+	SlipPending  = "pending"
+	SlipApproved = "approved"
+	SlipRejected = "rejected"
+)
+
 type TransferSlip struct {
-	Id        string `json:"id"`
-	FileName  string `json:"filename"`
-	Url       string `json:"url"`
-	CreatedAt string `json:"created_at"`
+	Id         string `json:"id"`
+	FileName   string `json:"filename"`
+	Url        string `json:"url"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason"`
+	VerifiedAt string `json:"verified_at"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type VerifySlipReq struct {
+	OrderId string `json:"-"`
+	Approve bool   `json:"-"`
+	Reason  string `json:"reason"`
 }
 
 type ProductsOrder struct {
-	Id      string            `db:"id" json:"id"`
-	Qty     int               `db:"qty" json:"qty"`
-	Product *products.Product `db:"product" json:"product"`
+	Id          string            `db:"id" json:"id"`
+	Qty         int               `db:"qty" json:"qty"`
+	CanceledQty int               `db:"canceled_qty" json:"canceled_qty"`
+	Product     *products.Product `db:"product" json:"product"`
+	VariantId   string            `db:"variant_id" json:"variant_id"`
+	Variant     *products.Variant `db:"variant" json:"variant"` // snapshot of the variant ordered
+	Tax         *taxes.LineTax    `db:"tax" json:"tax"`
+}
+
+// RefundLineTax reprices the qty left after canceling qty of the line with the rate snapshotted on it,
+// the line keeps the share of its discount for the qty left. It gives the new line tax and the amount to refund
+func RefundLineTax(line *ProductsOrder, qty int) (*taxes.LineTax, float64) {
+	var rate *taxes.TaxRate
+	mode := taxes.Inclusive
+	gross := line.Product.Price * float64(line.Qty)
+	discount := 0.0
+	if line.Tax != nil {
+		rate = &taxes.TaxRate{Title: line.Tax.Title, Rate: line.Tax.Rate}
+		mode = line.Tax.Mode
+		gross = line.Tax.Gross
+		if line.Qty > 0 {
+			discount = line.Tax.Discount * float64(line.Qty-qty) / float64(line.Qty)
+		}
+	}
+	tax := taxes.CalculateDiscounted(rate, line.Product.Price, line.Qty-qty, mode, discount)
+	return tax, math.Round((gross-tax.Gross)*100) / 100
+}
+
+// LegacyDiscountRate is the part of the order discount that lines without a tax snapshot carry, by their amount.
+// Those lines come from before the discount was spread over the lines, so the order holds their share
+func (o *Order) LegacyDiscountRate() float64 {
+	subtotal, discount := 0.0, o.Discount
+	for _, line := range o.Products {
+		if line.Tax != nil {
+			discount -= line.Tax.Discount
+		} else if line.Product != nil {
+			subtotal += line.Product.Price * float64(line.Qty)
+		}
+	}
+	if subtotal <= 0 || discount <= 0 {
+		return 0
+	}
+	return math.Min(discount/subtotal, 1)
+}
+
+// RefundableAmount is what is left to give back: TotalPaid leaves out the lines refunded before, so their amounts are added back,
+// and every refund made so far is taken off, a failed one too since it is still owed to the customer
+func (o *Order) RefundableAmount() float64 {
+	paid := math.Max(o.TotalPaid, 0)
+	for _, refund := range o.Refunds {
+		for _, line := range refund.Lines {
+			paid += line.Amount
+		}
+		paid -= refund.Amount
+	}
+	return math.Max(math.Round(paid*100)/100, 0)
+}
+
+// OrderedVariantId is the variant the line was ordered with, from the snapshot since variant_id is nulled when the variant is deleted
+func (p *ProductsOrder) OrderedVariantId() string {
+	if p.Variant != nil {
+		return p.Variant.Id
+	}
+	return p.VariantId
+}
+
+const (
+	RefundPending   = "pending"
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+)
+
+type Refund struct {
+	Id        string        `db:"id" json:"id"`
+	OrderId   string        `db:"order_id" json:"order_id"`
+	PaymentId string        `db:"payment_id" json:"payment_id"`
+	Amount    float64       `db:"amount" json:"amount"`
+	Reason    string        `db:"reason" json:"reason"`
+	Status    string        `db:"status" json:"status"`
+	Lines     []*RefundLine `db:"lines" json:"lines"`
+	CreatedAt string        `db:"created_at" json:"created_at"`
+	UpdatedAt string        `db:"updated_at" json:"updated_at"`
+
+	PriorRefunds int `db:"-" json:"-"` // refunds of the order the amount was bounded with
+}
+
+type RefundLine struct {
+	ProductsOrderId string         `json:"products_order_id"`
+	ProductId       string         `json:"product_id"`
+	VariantId       string         `json:"variant_id,omitempty"`
+	Qty             int            `json:"qty"`
+	Amount          float64        `json:"amount"`
+	Tax             *taxes.LineTax `json:"-"` // line tax after the cancellation, nil for a line without a snapshot
+	LineQty         int            `json:"-"` // qty of the order line the refund was priced on
+}
+
+// RefundReq cancels the given quantities of order lines, Amount overrides the computed refund
+type RefundReq struct {
+	OrderId string        `json:"-"`
+	Lines   []*RefundLine `json:"lines"`
+	Amount  *float64      `json:"amount"`
+	Reason  string        `json:"reason"`
+	Restock *bool         `json:"restock"`
+}
+
+// OrderComment is an internal note of the staff on an order
+type OrderComment struct {
+	Id        string `db:"id" json:"id"`
+	OrderId   string `db:"order_id" json:"order_id"`
+	UserId    string `db:"user_id" json:"user_id"`
+	Username  string `db:"username" json:"username"`
+	Body      string `db:"body" json:"body"`
+	CreatedAt string `db:"created_at" json:"created_at"`
+}
+
+type ReorderRes struct {
+	Order   *Order         `json:"order"`
+	Skipped []*ReorderSkip `json:"skipped"`
+}
+
+// ReorderSkip is a line of the old order that could not be put in the new one
+type ReorderSkip struct {
+	ProductId string `json:"product_id"`
+	Title     string `json:"title"`
+	Qty       int    `json:"qty"`
+	Reason    string `json:"reason"`
+}
+
+type Invoice struct {
+	Id       string `db:"id" json:"id"`
+	OrderId  string `db:"order_id" json:"order_id"`
+	Number   string `db:"number" json:"number"`
+	IssuedAt string `db:"issued_at" json:"issued_at"`
+}
+
+// InvoiceLine is an order line as the invoice prints it, Amount is before the discount
+type InvoiceLine struct {
+	Title  string
+	Price  float64
+	Qty    int
+	Tax    *taxes.LineTax
+	Amount float64
+}
+
+type InvoiceTotals struct {
+	Lines    []*InvoiceLine
+	Subtotal float64
+	Discount float64 // what the coupon took off the subtotal, tax included
+	TaxTotal float64
+	Total    float64
+}
+
+// InvoiceTotals prices the lines from their snapshot, the discount is what makes the subtotal the total paid
+func (o *Order) InvoiceTotals() *InvoiceTotals {
+	totals := &InvoiceTotals{
+		Lines:    make([]*InvoiceLine, 0, len(o.Products)),
+		TaxTotal: o.TaxTotal,
+		Total:    o.TotalPaid,
+	}
+	for _, line := range o.Products {
+		if line.Product == nil || line.Qty == 0 {
+			continue
+		}
+		l := &InvoiceLine{
+			Title:  line.Product.Title,
+			Price:  line.Product.Price,
+			Qty:    line.Qty,
+			Tax:    line.Tax,
+			Amount: line.Product.Price * float64(line.Qty),
+		}
+		if line.Variant != nil {
+			l.Title += " (" + line.Variant.Label() + ")"
+		}
+		if line.Tax != nil {
+			rate := &taxes.TaxRate{Title: line.Tax.Title, Rate: line.Tax.Rate}
+			l.Amount = taxes.Calculate(rate, line.Product.Price, line.Qty, line.Tax.Mode).Gross
+		}
+		totals.Lines = append(totals.Lines, l)
+		totals.Subtotal += l.Amount
+	}
+	totals.Subtotal = math.Round(totals.Subtotal*100) / 100
+	totals.Discount = math.Max(math.Round((totals.Subtotal-totals.Total)*100)/100, 0)
+	return totals
+}
+
+// OrderExportHeader is the column order of OrderExportRow in both export formats
+var OrderExportHeader = []string{
+	"order_id",
+	"user_id",
+	"status",
+	"address",
+	"contact",
+	"product_id",
+	"product_title",
+	"price",
+	"qty",
+	"canceled_qty",
+	"total",
+	"created_at",
+}
+
+// OrderExportRow is an order line, the order columns repeat on every line of the order
+type OrderExportRow struct {
+	OrderId      string  `db:"order_id"`
+	UserId       string  `db:"user_id"`
+	Status       string  `db:"status"`
+	Address      string  `db:"address"`
+	Contact      string  `db:"contact"`
+	ProductId    string  `db:"product_id"`
+	ProductTitle string  `db:"product_title"`
+	Price        float64 `db:"price"`
+	Qty          int     `db:"qty"`
+	CanceledQty  int     `db:"canceled_qty"`
+	Total        float64 `db:"total"` // what was paid for the qty left, after its discount share and tax
+	CreatedAt    string  `db:"created_at"`
+}
+
+// CsvRecord is the row in OrderExportHeader order, amounts keep two decimals
+func (r *OrderExportRow) CsvRecord() []string {
+	return []string{
+		r.OrderId,
+		r.UserId,
+		r.Status,
+		r.Address,
+		r.Contact,
+		r.ProductId,
+		r.ProductTitle,
+		fmt.Sprintf("%.2f", r.Price),
+		fmt.Sprintf("%d", r.Qty),
+		fmt.Sprintf("%d", r.CanceledQty),
+		fmt.Sprintf("%.2f", r.Total),
+		r.CreatedAt,
+	}
+}
+
+// XlsxRow is the row in OrderExportHeader order, numbers stay numbers so the sheet can sum them
+func (r *OrderExportRow) XlsxRow() []any {
+	return []any{
+		r.OrderId,
+		r.UserId,
+		r.Status,
+		r.Address,
+		r.Contact,
+		r.ProductId,
+		r.ProductTitle,
+		r.Price,
+		r.Qty,
+		r.CanceledQty,
+		r.Total,
+		r.CreatedAt,
+	}
 }
@@ -1,31 +1,58 @@
 package orderHandlers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"go_learn_project_rest_api/config"
 	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/files"
 	"go_learn_project_rest_api/modules/orders"
 	"go_learn_project_rest_api/modules/orders/orderUsecases"
+	"go_learn_project_rest_api/modules/promotions"
+	"go_learn_project_rest_api/pkgs/utils"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
-	"github.com/google/uuid"
 )
 
 type ordersHandlersErrCode string
 
 const (
-	findOneOrderErr ordersHandlersErrCode = "orders-001"
-	findOrderErr    ordersHandlersErrCode = "orders-002"
-	insertOrderErr  ordersHandlersErrCode = "orders-003"
-	updateOrderErr  ordersHandlersErrCode = "orders-004"
+	findOneOrderErr  ordersHandlersErrCode = "orders-001"
+	findOrderErr     ordersHandlersErrCode = "orders-002"
+	insertOrderErr   ordersHandlersErrCode = "orders-003"
+	updateOrderErr   ordersHandlersErrCode = "orders-004"
+	exportOrderErr   ordersHandlersErrCode = "orders-005"
+	uploadSlipErr    ordersHandlersErrCode = "orders-006"
+	verifySlipErr    ordersHandlersErrCode = "orders-007"
+	insertRefundErr  ordersHandlersErrCode = "orders-008"
+	updateRefundErr  ordersHandlersErrCode = "orders-009"
+	invoiceErr       ordersHandlersErrCode = "orders-010"
+	reorderErr       ordersHandlersErrCode = "orders-011"
+	findCommentErr   ordersHandlersErrCode = "orders-012"
+	insertCommentErr ordersHandlersErrCode = "orders-013"
 )
 
+const maxNoteLength = 500
+
 type IOrderHandlers interface {
 	FindOneOrder(fiber.Ctx) error
 	FindOrder(fiber.Ctx) error
 	InsertOrder(fiber.Ctx) error
 	UpdateOrder(fiber.Ctx) error
+	ExportOrder(fiber.Ctx) error
+	UploadTransferSlip(fiber.Ctx) error
+	ApproveTransferSlip(fiber.Ctx) error
+	RejectTransferSlip(fiber.Ctx) error
+	InsertRefund(fiber.Ctx) error
+	UpdateRefund(fiber.Ctx) error
+	Invoice(fiber.Ctx) error
+	Reorder(fiber.Ctx) error
+	FindOrderComment(fiber.Ctx) error
+	InsertOrderComment(fiber.Ctx) error
 }
 
 type orderHandlers struct {
@@ -52,20 +79,30 @@ func (h *orderHandlers) FindOneOrder(c fiber.Ctx) error {
 		).Res()
 	}
 
+	// Internal comments are for staff only
+	if c.Locals("roleId").(int) == 2 {
+		order.Comments, err = h.orderUsecases.FindOrderComment(orderId)
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrInternalServerError.Code,
+				string(findOneOrderErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	c.Set(fiber.HeaderETag, entities.ETag(order.Version))
 	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, order).Res()
 }
 
-func (h *orderHandlers) FindOrder(c fiber.Ctx) error {
+func (h *orderHandlers) bindOrderFilter(c fiber.Ctx) (*orders.OrderFilter, error) {
 	req := &orders.OrderFilter{
 		SortReq:       &entities.SortReq{},
 		PaginationReq: &entities.PaginationReq{},
+		CursorReq:     &entities.CursorReq{},
 	}
 	if err := c.Bind().Query(req); err != nil {
-		return entities.NewResponse(c).Error(
-			fiber.ErrBadRequest.Code,
-			string(findOrderErr),
-			err.Error(),
-		).Res()
+		return nil, err
 	}
 
 	// Paginate
@@ -98,24 +135,42 @@ func (h *orderHandlers) FindOrder(c fiber.Ctx) error {
 	if req.StartDate != "" {
 		start, err := time.Parse("2006-01-02", req.StartDate)
 		if err != nil {
-			return entities.NewResponse(c).Error(
-				fiber.ErrBadRequest.Code,
-				string(findOrderErr),
-				"start date is invalid",
-			).Res()
+			return nil, fmt.Errorf("start date is invalid")
 		}
 		req.StartDate = start.Format("2006-01-02")
 	}
 	if req.EndDate != "" {
 		end, err := time.Parse("2006-01-02", req.EndDate)
 		if err != nil {
+			return nil, fmt.Errorf("end date is invalid")
+		}
+		req.EndDate = end.Format("2006-01-02")
+	}
+	return req, nil
+}
+
+func (h *orderHandlers) FindOrder(c fiber.Ctx) error {
+	req, err := h.bindOrderFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findOrderErr),
+			err.Error(),
+		).Res()
+	}
+
+	if req.IsCursor() {
+		if err := req.Decode(req.SortReq); err != nil {
 			return entities.NewResponse(c).Error(
 				fiber.ErrBadRequest.Code,
 				string(findOrderErr),
-				"end date is invalid",
+				err.Error(),
 			).Res()
 		}
-		req.EndDate = end.Format("2006-01-02")
+		return entities.NewResponse(c).SuccessResponse(
+			fiber.StatusOK,
+			h.orderUsecases.FindOrderCursor(req),
+		).Res()
 	}
 
 	return entities.NewResponse(c).SuccessResponse(
@@ -124,6 +179,45 @@ func (h *orderHandlers) FindOrder(c fiber.Ctx) error {
 	).Res()
 }
 
+func (h *orderHandlers) ExportOrder(c fiber.Ctx) error {
+	req, err := h.bindOrderFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(exportOrderErr),
+			err.Error(),
+		).Res()
+	}
+
+	formatMap := map[string]string{
+		"csv":  "csv",
+		"xlsx": "xlsx",
+	}
+	format := formatMap[strings.ToLower(c.Query("format", "csv"))]
+	if format == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(exportOrderErr),
+			"format is invalid",
+		).Res()
+	}
+
+	// The file is written out first, a failed export is answered with an error instead of a cut file
+	f, size, err := utils.SpoolFile(func(w io.Writer) error {
+		return h.orderUsecases.ExportOrder(req, format, w)
+	})
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(exportOrderErr),
+			err.Error(),
+		).Res()
+	}
+
+	c.Attachment(fmt.Sprintf("orders_%s.%s", time.Now().Format("20060102150405"), format))
+	return c.SendStream(f, int(size))
+}
+
 func (h *orderHandlers) InsertOrder(c fiber.Ctx) error {
 	userId := c.Locals("userId").(string)
 
@@ -148,13 +242,30 @@ func (h *orderHandlers) InsertOrder(c fiber.Ctx) error {
 		req.UserId = userId
 	}
 
+	req.Note = strings.TrimSpace(req.Note)
+	if len([]rune(req.Note)) > maxNoteLength {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertOrderErr),
+			fmt.Sprintf("note must not be longer than %d characters", maxNoteLength),
+		).Res()
+	}
+
 	req.Status = "waiting"
+	req.Comments = nil
 	req.TotalPaid = 0
+	req.Discount = 0
+	req.CouponCode = promotions.NormalizeCode(req.CouponCode)
 
 	order, err := h.orderUsecases.InsertOrder(req)
 	if err != nil {
+		code := fiber.ErrInternalServerError.Code
+		var couponErr *promotions.CouponError
+		if errors.As(err, &couponErr) {
+			code = fiber.ErrBadRequest.Code
+		}
 		return entities.NewResponse(c).Error(
-			fiber.ErrInternalServerError.Code,
+			code,
 			string(insertOrderErr),
 			err.Error(),
 		).Res()
@@ -173,47 +284,282 @@ func (h *orderHandlers) UpdateOrder(c fiber.Ctx) error {
 		).Res()
 	}
 	req.Id = orderId
+	req.UserId = ""
+
+	version, err := entities.ParseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		code := fiber.StatusBadRequest
+		if errors.Is(err, entities.ErrIfMatchRequired) {
+			code = fiber.StatusPreconditionRequired
+		}
+		return entities.NewResponse(c).Error(
+			code,
+			string(updateOrderErr),
+			err.Error(),
+		).Res()
+	}
+	req.Version = version
 
 	statusMap := map[string]string{
 		"waiting":   "waiting",
+		"paid":      "paid",
 		"shipping":  "shipping",
 		"completed": "completed",
 		"canceled":  "canceled",
 	}
+	// A customer can only cancel an own order that has not been paid yet, the other statuses come from admins and payments
+	var fromStatus []string
 	if c.Locals("roleId").(int) == 2 {
 		req.Status = statusMap[strings.ToLower(req.Status)]
-	} else if strings.ToLower(req.Status) == statusMap["canceled"] {
-		req.Status = statusMap["canceled"]
+	} else {
+		req.UserId = strings.Trim(c.Params("user_id"), " ")
+		if req.Status != "" && strings.ToLower(req.Status) != statusMap["canceled"] {
+			return entities.NewResponse(c).Error(
+				fiber.ErrForbidden.Code,
+				string(updateOrderErr),
+				"only admin can change the order status to "+req.Status,
+			).Res()
+		}
+		if req.Status != "" {
+			req.Status = statusMap["canceled"]
+			fromStatus = orders.CancelableStatus
+		}
 	}
 
-	if req.TransferSlip != nil {
-		if req.TransferSlip.Id == "" {
-			req.TransferSlip.Id = uuid.NewString()
+	// Transfer slips only come from the upload endpoint, never from the request body
+	req.TransferSlip = nil
+
+	order, err := h.orderUsecases.UpdateOrder(req, fromStatus)
+	if err != nil {
+		code, msg := fiber.ErrInternalServerError.Code, err.Error()
+		switch {
+		case errors.Is(err, entities.ErrVersionConflict):
+			code, msg = fiber.StatusPreconditionFailed, "order "+msg
+		case errors.Is(err, orders.ErrOrderNotFound):
+			code = fiber.ErrNotFound.Code
+		case errors.Is(err, orders.ErrStatusNotAllowed):
+			code = fiber.ErrBadRequest.Code
 		}
-		if req.TransferSlip.CreatedAt == "" {
-			loc, err := time.LoadLocation("Asia/Bangkok")
-			if err != nil {
-				return entities.NewResponse(c).Error(
-					fiber.ErrInternalServerError.Code,
-					string(updateOrderErr),
-					err.Error(),
-				).Res()
-			}
-			now := time.Now().In(loc)
-
-			// YYYY-MM-DD HH:MM:SS
-			// 2006-01-02 15:04:05
-			req.TransferSlip.CreatedAt = now.Format("2006-01-02 15:04:05")
+		return entities.NewResponse(c).Error(
+			code,
+			string(updateOrderErr),
+			msg,
+		).Res()
+	}
+	c.Set(fiber.HeaderETag, entities.ETag(order.Version))
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, order).Res()
+}
+
+func (h *orderHandlers) UploadTransferSlip(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(uploadSlipErr),
+			err.Error(),
+		).Res()
+	}
+
+	ext, err := files.ValidateImage(file, h.cfg.App().FileLimit())
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(uploadSlipErr),
+			err.Error(),
+		).Res()
+	}
+
+	order, err := h.orderUsecases.UploadTransferSlip(userId, orderId, &files.FileReq{
+		File:        file,
+		Destination: fmt.Sprintf("slips/%s/", orderId),
+		FileName:    utils.RandFileName(ext),
+		Extension:   ext,
+	})
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(uploadSlipErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, order).Res()
+}
+
+func (h *orderHandlers) ApproveTransferSlip(c fiber.Ctx) error {
+	return h.verifyTransferSlip(c, true)
+}
+
+func (h *orderHandlers) RejectTransferSlip(c fiber.Ctx) error {
+	return h.verifyTransferSlip(c, false)
+}
+
+func (h *orderHandlers) verifyTransferSlip(c fiber.Ctx, approve bool) error {
+	req := new(orders.VerifySlipReq)
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(req); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(verifySlipErr),
+				err.Error(),
+			).Res()
 		}
 	}
+	req.OrderId = strings.Trim(c.Params("order_id"), " ")
+	req.Approve = approve
+	req.Reason = strings.TrimSpace(req.Reason)
 
-	order, err := h.orderUsecases.UpdateOrder(req)
+	order, err := h.orderUsecases.VerifyTransferSlip(req)
 	if err != nil {
 		return entities.NewResponse(c).Error(
-			fiber.ErrInternalServerError.Code,
-			string(updateOrderErr),
+			fiber.ErrBadRequest.Code,
+			string(verifySlipErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, order).Res()
+}
+
+func (h *orderHandlers) InsertRefund(c fiber.Ctx) error {
+	req := &orders.RefundReq{
+		Lines: make([]*orders.RefundLine, 0),
+	}
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertRefundErr),
+			err.Error(),
+		).Res()
+	}
+	req.OrderId = strings.Trim(c.Params("order_id"), " ")
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	order, err := h.orderUsecases.InsertRefund(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertRefundErr),
 			err.Error(),
 		).Res()
 	}
 	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, order).Res()
 }
+
+func (h *orderHandlers) UpdateRefund(c fiber.Ctx) error {
+	req := new(orders.Refund)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateRefundErr),
+			err.Error(),
+		).Res()
+	}
+	req.OrderId = strings.Trim(c.Params("order_id"), " ")
+	req.Id = strings.Trim(c.Params("refund_id"), " ")
+
+	statusMap := map[string]string{
+		"succeeded": orders.RefundSucceeded,
+		"failed":    orders.RefundFailed,
+	}
+	req.Status = statusMap[strings.ToLower(req.Status)]
+	if req.Status == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateRefundErr),
+			"status is invalid",
+		).Res()
+	}
+
+	order, err := h.orderUsecases.UpdateRefund(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateRefundErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, order).Res()
+}
+
+func (h *orderHandlers) Invoice(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	// Render into memory first, so a failure can still be answered with a json error
+	buf := new(bytes.Buffer)
+	invoice, err := h.orderUsecases.InvoicePdf(userId, orderId, h.cfg.App().Name(), buf)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(invoiceErr),
+			err.Error(),
+		).Res()
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.pdf"`, invoice.Number))
+	return c.Send(buf.Bytes())
+}
+
+func (h *orderHandlers) Reorder(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	res, err := h.orderUsecases.Reorder(userId, orderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(reorderErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, res).Res()
+}
+
+func (h *orderHandlers) FindOrderComment(c fiber.Ctx) error {
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	result, err := h.orderUsecases.FindOrderComment(orderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findCommentErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *orderHandlers) InsertOrderComment(c fiber.Ctx) error {
+	req := new(orders.OrderComment)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertCommentErr),
+			err.Error(),
+		).Res()
+	}
+	req.OrderId = strings.Trim(c.Params("order_id"), " ")
+	req.UserId = c.Locals("userId").(string)
+	req.Body = strings.TrimSpace(req.Body)
+
+	if req.Body == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertCommentErr),
+			"body is required",
+		).Res()
+	}
+
+	result, err := h.orderUsecases.InsertOrderComment(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertCommentErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, result).Res()
+}
@@ -15,11 +15,15 @@ import (
 type IFindOrderBuilder interface {
 	initQuery()
 	initCountQuery()
+	initExportQuery()
 	buildWhereSearch()
 	buildWhereStatus()
 	buildWhereDate()
 	buildSort()
 	buildPaginate()
+	buildSeek()
+	buildSeekLimit()
+	buildExportSort()
 	closeQuery()
 	getQuery() string
 	setQuery(query string)
@@ -71,21 +75,41 @@ func (b *findOrderBuilder) initQuery() {
 					SELECT
 						spo.id,
 						spo.qty,
-						spo.product
+						spo.canceled_qty,
+						spo.product,
+						spo.variant_id,
+						spo.variant,
+						spo.tax
 					FROM products_orders spo
 					WHERE spo.order_id = o.id
 				) AS pt
 			) AS products,
 			o.address,
 			o.contact,
+			o.note,
+			o.address_id,
+			o.shipping_address,
+			o.coupon_code,
+			o.discount,
+			o.tax_total,
 			(
 				SELECT
-					SUM(COALESCE((po.product->>'price')::FLOAT*(po.qty)::FLOAT, 0))
+					SUM(COALESCE((po.tax->>'gross')::FLOAT, (po.product->>'price')::FLOAT*(po.qty)::FLOAT, 0))
+					-- Lines with a discount share are already discounted, older orders take the discount off the total
+					- o.discount + COALESCE(SUM((po.tax->>'discount')::FLOAT), 0)
 				FROM products_orders po
 				WHERE po.order_id = o.id
 			) AS total_paid,
+			(
+				SELECT
+					COALESCE(SUM(r.amount), 0)
+				FROM refunds r
+				WHERE r.order_id = o.id
+				AND r.status = 'succeeded'
+			) AS refunded_amount,
 			o.created_at,
-			o.updated_at
+			o.updated_at,
+			o.version
 		FROM orders o
 		WHERE 1 = 1`
 }
@@ -98,6 +122,38 @@ func (b *findOrderBuilder) initCountQuery() {
 		WHERE 1 = 1`
 }
 
+// initExportQuery prices a line as total_paid does, the tax snapshot gross is after its discount share and tax,
+// a line without a snapshot takes its share of what the order discount has left. Refunded lines keep their row with qty 0
+func (b *findOrderBuilder) initExportQuery() {
+	b.query += `
+		SELECT
+			o.id AS order_id,
+			o.user_id,
+			o.status::TEXT AS status,
+			o.address,
+			o.contact,
+			COALESCE(po.product->>'id', '') AS product_id,
+			COALESCE(po.product->>'title', '') AS product_title,
+			COALESCE((po.product->>'price')::FLOAT, 0) AS price,
+			po.qty,
+			po.canceled_qty,
+			COALESCE(ROUND(COALESCE(
+				(po.tax->>'gross')::FLOAT,
+				(po.product->>'price')::FLOAT*po.qty*(1 - LEAST(COALESCE(GREATEST(o.discount - od.line_discount, 0)/NULLIF(od.legacy_subtotal, 0), 0), 1))
+			)::NUMERIC, 2)::FLOAT, 0) AS total,
+			o.created_at::TEXT AS created_at
+		FROM orders o
+		JOIN products_orders po ON po.order_id = o.id
+		CROSS JOIN LATERAL (
+			SELECT
+				COALESCE(SUM((spo.product->>'price')::FLOAT*spo.qty) FILTER (WHERE spo.tax IS NULL), 0) AS legacy_subtotal,
+				COALESCE(SUM((spo.tax->>'discount')::FLOAT), 0) AS line_discount
+			FROM products_orders spo
+			WHERE spo.order_id = o.id
+		) od
+		WHERE 1 = 1`
+}
+
 func (b *findOrderBuilder) buildWhereSearch() {
 	if b.req.Search != "" {
 		b.values = append(
@@ -165,12 +221,68 @@ func (b *findOrderBuilder) buildWhereDate() {
 	}
 }
 
+// sortKey gives the column sorted on and the cast a cursor value needs to compare with it
+func (b *findOrderBuilder) sortKey() (string, string) {
+	if b.req.OrderBy == `"o"."created_at"` || b.req.OrderBy == "created_at" {
+		return `"o"."created_at"`, "::TIMESTAMP"
+	}
+	return `"o"."id"`, ""
+}
+
+// direction is the sort the rows are read in, a backward cursor reads the other way
+func (b *findOrderBuilder) direction() string {
+	sort := "DESC"
+	if strings.ToUpper(b.req.Sort) == "ASC" {
+		sort = "ASC"
+	}
+	if b.req.CursorReq != nil && b.req.After != nil && b.req.After.Backward {
+		if sort == "ASC" {
+			return "DESC"
+		}
+		return "ASC"
+	}
+	return sort
+}
+
 func (b *findOrderBuilder) buildSort() {
-	b.values = append(b.values, b.req.OrderBy)
+	// Column names can not be bound as parameters, so only whitelisted ones are written into the query
+	orderBy, _ := b.sortKey()
+	sort := b.direction()
+
+	b.query += fmt.Sprintf(`
+		ORDER BY %s %s, "o"."id" %s`, orderBy, sort, sort)
+}
+
+// buildSeek continues after the cursor row instead of skipping rows with OFFSET
+func (b *findOrderBuilder) buildSeek() {
+	if b.req.CursorReq == nil || b.req.After == nil {
+		return
+	}
+
+	op := ">"
+	if b.direction() == "DESC" {
+		op = "<"
+	}
+
+	orderBy, cast := b.sortKey()
+	if orderBy == `"o"."id"` {
+		b.values = append(b.values, b.req.After.Id)
+		b.query += fmt.Sprintf(`
+		AND "o"."id" %s $%d`, op, b.lastIndex+1)
+	} else {
+		b.values = append(b.values, b.req.After.Value, b.req.After.Id)
+		b.query += fmt.Sprintf(`
+		AND (%s, "o"."id") %s ($%d%s, $%d)`, orderBy, op, b.lastIndex+1, cast, b.lastIndex+2)
+	}
+	b.lastIndex = len(b.values)
+}
+
+// buildSeekLimit reads one row more than the page, it tells whether another page follows
+func (b *findOrderBuilder) buildSeekLimit() {
+	b.values = append(b.values, b.req.Limit+1)
 
-	// Fix
 	b.query += fmt.Sprintf(`
-		ORDER BY $%d %s`, b.lastIndex+1, b.req.Sort)
+		LIMIT $%d`, b.lastIndex+1)
 
 	b.lastIndex = len(b.values)
 }
@@ -189,6 +301,27 @@ func (b *findOrderBuilder) buildPaginate() {
 	b.lastIndex = len(b.values)
 }
 
+func (b *findOrderBuilder) buildExportSort() {
+	// Column names can not be bound as parameters, so only whitelisted ones are written into the query
+	orderByMap := map[string]string{
+		"id":               `"o"."id"`,
+		"created_at":       `"o"."created_at"`,
+		`"o"."id"`:         `"o"."id"`,
+		`"o"."created_at"`: `"o"."created_at"`,
+	}
+	orderBy := orderByMap[b.req.OrderBy]
+	if orderBy == "" {
+		orderBy = orderByMap["created_at"]
+	}
+	sort := "DESC"
+	if strings.ToUpper(b.req.Sort) == "ASC" {
+		sort = "ASC"
+	}
+
+	b.query += fmt.Sprintf(`
+		ORDER BY %s %s, "o"."id", "po"."id"`, orderBy, sort)
+}
+
 func (b *findOrderBuilder) closeQuery() {
 	b.query += `
 	) AS at`
@@ -215,6 +348,7 @@ func (b *findOrderBuilder) reset() {
 func (en *findOrderEngineer) FindOrder() []*orders.Order {
 	_, cancel := context.WithTimeout(context.Background(), time.Second*30)
 	defer cancel()
+	defer en.builder.reset()
 
 	en.builder.initQuery()
 	en.builder.buildWhereSearch()
@@ -236,8 +370,36 @@ func (en *findOrderEngineer) FindOrder() []*orders.Order {
 	if err := json.Unmarshal(raw, &ordersData); err != nil {
 		log.Printf("unmarshal orders failed: %v\n", err)
 	}
+	return ordersData
+}
+
+func (en *findOrderEngineer) FindOrderCursor() []*orders.Order {
+	_, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	defer en.builder.reset()
+
+	en.builder.initQuery()
+	en.builder.buildWhereSearch()
+	en.builder.buildWhereStatus()
+	en.builder.buildWhereDate()
+	en.builder.buildSeek()
+	en.builder.buildSort()
+	en.builder.buildSeekLimit()
+	en.builder.closeQuery()
 
-	en.builder.reset()
+	raw := make([]byte, 0)
+	ordersData := make([]*orders.Order, 0)
+	if err := en.builder.getDb().Get(&raw, en.builder.getQuery(), en.builder.getValues()...); err != nil {
+		log.Printf("get orders failed: %v\n", err)
+		return ordersData
+	}
+	// json_agg gives null when nothing matched
+	if raw == nil {
+		return ordersData
+	}
+	if err := json.Unmarshal(raw, &ordersData); err != nil {
+		log.Printf("unmarshal orders failed: %v\n", err)
+	}
 	return ordersData
 }
 
@@ -249,13 +411,45 @@ func (en *findOrderEngineer) CountOrder() int {
 	en.builder.buildWhereSearch()
 	en.builder.buildWhereStatus()
 	en.builder.buildWhereDate()
+	defer en.builder.reset()
 
 	var count int
 	if err := en.builder.getDb().Get(&count, en.builder.getQuery(), en.builder.getValues()...); err != nil {
 		log.Printf("count orders failed: %v\n", err)
 		return 0
 	}
-
-	en.builder.reset()
 	return count
 }
+
+func (en *findOrderEngineer) ExportOrder(fn func(*orders.OrderExportRow) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+	defer en.builder.reset()
+
+	en.builder.initExportQuery()
+	en.builder.buildWhereSearch()
+	en.builder.buildWhereStatus()
+	en.builder.buildWhereDate()
+	en.builder.buildExportSort()
+
+	rows, err := en.builder.getDb().QueryxContext(ctx, en.builder.getQuery(), en.builder.getValues()...)
+	if err != nil {
+		return fmt.Errorf("export orders failed: %v", err)
+	}
+	defer rows.Close()
+
+	// Rows are handed over one by one, so the whole result set never sits in memory
+	for rows.Next() {
+		row := new(orders.OrderExportRow)
+		if err := rows.StructScan(row); err != nil {
+			return fmt.Errorf("scan export order failed: %v", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("export orders failed: %v", err)
+	}
+	return nil
+}
@@ -2,8 +2,13 @@ package orderPatterns
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"go_learn_project_rest_api/modules/orders"
+	"go_learn_project_rest_api/modules/promotions"
+	"go_learn_project_rest_api/modules/taxes"
+	"math"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,6 +18,8 @@ type IInsertOrderBuilder interface {
 	initTransaction() error
 	insertOrder() error
 	insertProductsOrder() error
+	reserveStock() error
+	applyCoupon() error
 	getOrderId() string
 	commit() error
 }
@@ -48,10 +55,14 @@ func (b *insertOrderBuilder) insertOrder() error {
 		"contact",
 		"address",
 		"transfer_slip",
-		"status"
+		"status",
+		"address_id",
+		"shipping_address",
+		"tax_total",
+		"note"
 	)
 	VALUES
-	($1, $2, $3, $4, $5)
+	($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7, $8, $9)
 		RETURNING "id";`
 
 	if err := b.tx.QueryRowxContext(
@@ -62,6 +73,10 @@ func (b *insertOrderBuilder) insertOrder() error {
 		b.req.Address,
 		b.req.TransferSlip,
 		b.req.Status,
+		b.req.AddressId,
+		b.req.ShippingAddress,
+		b.req.TaxTotal,
+		b.req.Note,
 	).Scan(&b.req.Id); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert order failed: %v", err)
@@ -76,7 +91,10 @@ func (b *insertOrderBuilder) insertProductsOrder() error {
 	INSERT INTO "products_orders" (
 		"order_id",
 		"qty",
-		"product"
+		"product",
+		"variant_id",
+		"variant",
+		"tax"
 	)
 	VALUES`
 
@@ -88,17 +106,20 @@ func (b *insertOrderBuilder) insertProductsOrder() error {
 			b.req.Id,
 			b.req.Products[i].Qty,
 			b.req.Products[i].Product,
+			b.req.Products[i].VariantId,
+			b.req.Products[i].Variant,
+			b.req.Products[i].Tax,
 		)
 
 		if i != len(b.req.Products)-1 {
 			query += fmt.Sprintf(`
-			($%d, $%d, $%d),`, lastIndex+1, lastIndex+2, lastIndex+3)
+			($%d, $%d, $%d, NULLIF($%d, '')::uuid, $%d, $%d),`, lastIndex+1, lastIndex+2, lastIndex+3, lastIndex+4, lastIndex+5, lastIndex+6)
 		} else {
 			query += fmt.Sprintf(`
-			($%d, $%d, $%d);`, lastIndex+1, lastIndex+2, lastIndex+3)
+			($%d, $%d, $%d, NULLIF($%d, '')::uuid, $%d, $%d);`, lastIndex+1, lastIndex+2, lastIndex+3, lastIndex+4, lastIndex+5, lastIndex+6)
 		}
 
-		lastIndex += 3
+		lastIndex += 6
 	}
 
 	if _, err := b.tx.ExecContext(ctx, query, values...); err != nil {
@@ -107,6 +128,181 @@ func (b *insertOrderBuilder) insertProductsOrder() error {
 	}
 	return nil
 }
+func (b *insertOrderBuilder) reserveStock() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// Products without stock tracking keep a NULL stock and always pass
+	query := `
+	UPDATE "products" SET
+		"stock" = "stock" - $1
+	WHERE "id" = $2
+	AND ("stock" IS NULL OR "stock" >= $1);`
+
+	// A variant line takes the stock of its variant
+	variantQuery := `
+	UPDATE "product_variants" SET
+		"stock" = "stock" - $1
+	WHERE "id" = $2
+	AND ("stock" IS NULL OR "stock" >= $1);`
+
+	for _, p := range b.req.Products {
+		lineQuery, id := query, p.Product.Id
+		if p.VariantId != "" {
+			lineQuery, id = variantQuery, p.VariantId
+		}
+		result, err := b.tx.ExecContext(ctx, lineQuery, p.Qty, id)
+		if err != nil {
+			b.tx.Rollback()
+			return fmt.Errorf("reserve stock failed: %v", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			b.tx.Rollback()
+			return fmt.Errorf("product %s is out of stock", p.Product.Title)
+		}
+	}
+	return nil
+}
+func (b *insertOrderBuilder) applyCoupon() error {
+	if b.req.CouponCode == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// Lock the coupon row, concurrent orders with the same code wait here so usage limits hold
+	coupon := new(promotions.Coupon)
+	query := `
+	SELECT
+		"id",
+		"code",
+		"type"::TEXT AS "type",
+		"value",
+		"min_spend",
+		"max_discount",
+		"usage_limit",
+		"usage_limit_per_user",
+		"started_at",
+		"expired_at",
+		"is_active"
+	FROM "coupons"
+	WHERE "code" = $1
+	FOR UPDATE;`
+	if err := b.tx.GetContext(ctx, coupon, query, b.req.CouponCode); err != nil {
+		b.tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return promotions.CouponErrorf("coupon %s not found", b.req.CouponCode)
+		}
+		return fmt.Errorf("get coupon failed: %v", err)
+	}
+
+	coupon.ProductIds = make([]string, 0)
+	if err := b.tx.SelectContext(ctx, &coupon.ProductIds, `SELECT "product_id" FROM "coupons_products" WHERE "coupon_id" = $1;`, coupon.Id); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("get coupon products failed: %v", err)
+	}
+	coupon.CategoryIds = make([]int, 0)
+	if err := b.tx.SelectContext(ctx, &coupon.CategoryIds, `SELECT "category_id" FROM "coupons_categories" WHERE "coupon_id" = $1;`, coupon.Id); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("get coupon categories failed: %v", err)
+	}
+
+	var used, usedByUser int
+	var now time.Time
+	query = `
+	SELECT
+		COUNT(*),
+		COUNT(*) FILTER (WHERE "user_id" = $2),
+		LOCALTIMESTAMP
+	FROM "coupon_redemptions"
+	WHERE "coupon_id" = $1;`
+	if err := b.tx.QueryRowxContext(ctx, query, coupon.Id, b.req.UserId).Scan(&used, &usedByUser, &now); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("count coupon redemptions failed: %v", err)
+	}
+	if err := coupon.CheckAvailable(now, used, usedByUser); err != nil {
+		b.tx.Rollback()
+		return err
+	}
+
+	lines := make([]*promotions.CouponLine, 0)
+	for _, p := range b.req.Products {
+		line := &promotions.CouponLine{
+			ProductId:   p.Product.Id,
+			CategoryIds: make([]int, 0),
+			Price:       p.Product.Price,
+			Qty:         p.Qty,
+		}
+		// A coupon of a parent category covers the products of its subcategories
+		query := `
+		WITH RECURSIVE tree AS (
+			SELECT
+				"category_id" AS "id"
+			FROM "products_categories"
+			WHERE "product_id" = $1
+			UNION
+			SELECT
+				c."parent_id"
+			FROM "categories" c
+			JOIN tree t ON c."id" = t."id"
+			WHERE c."parent_id" IS NOT NULL
+		)
+		SELECT "id" FROM tree;`
+		if err := b.tx.SelectContext(ctx, &line.CategoryIds, query, p.Product.Id); err != nil {
+			b.tx.Rollback()
+			return fmt.Errorf("get product categories failed: %v", err)
+		}
+		lines = append(lines, line)
+	}
+
+	discount, err := coupon.CalculateDiscount(lines)
+	if err != nil {
+		b.tx.Rollback()
+		return err
+	}
+
+	// Tax is on what is paid, so every line is taxed again with its share of the discount
+	b.req.TaxTotal = 0
+	for i, share := range coupon.SpreadDiscount(lines, discount) {
+		p := b.req.Products[i]
+		if p.Tax == nil {
+			continue
+		}
+		rate := &taxes.TaxRate{Title: p.Tax.Title, Rate: p.Tax.Rate}
+		p.Tax = taxes.CalculateDiscounted(rate, p.Product.Price, p.Qty, p.Tax.Mode, share)
+		b.req.TaxTotal += p.Tax.Tax
+	}
+	b.req.TaxTotal = math.Round(b.req.TaxTotal*100) / 100
+
+	query = `
+	INSERT INTO "coupon_redemptions" (
+		"coupon_id",
+		"order_id",
+		"user_id",
+		"discount"
+	)
+	VALUES
+	($1, $2, $3, $4);`
+	if _, err := b.tx.ExecContext(ctx, query, coupon.Id, b.req.Id, b.req.UserId, discount); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("insert coupon_redemptions failed: %v", err)
+	}
+
+	query = `
+	UPDATE "orders" SET
+		"coupon_code" = $1,
+		"discount" = $2,
+		"tax_total" = $3
+	WHERE "id" = $4;`
+	if _, err := b.tx.ExecContext(ctx, query, coupon.Code, discount, b.req.TaxTotal, b.req.Id); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("update order discount failed: %v", err)
+	}
+
+	b.req.Discount = discount
+	return nil
+}
 func (b *insertOrderBuilder) commit() error {
 	if err := b.tx.Commit(); err != nil {
 		return err
@@ -132,9 +328,16 @@ func (en *insertOrderEngineer) InsertOrder() (string, error) {
 	if err := en.builder.insertOrder(); err != nil {
 		return "", err
 	}
+	// The coupon goes before the lines, their tax snapshot is taken on the discounted amount
+	if err := en.builder.applyCoupon(); err != nil {
+		return "", err
+	}
 	if err := en.builder.insertProductsOrder(); err != nil {
 		return "", err
 	}
+	if err := en.builder.reserveStock(); err != nil {
+		return "", err
+	}
 	if err := en.builder.commit(); err != nil {
 		return "", err
 	}
@@ -2,11 +2,16 @@ package orderRepositories
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go_learn_project_rest_api/modules/entities"
 	"go_learn_project_rest_api/modules/orders"
 	"go_learn_project_rest_api/modules/orders/orderPatterns"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -14,8 +19,19 @@ import (
 type IOrderRepository interface {
 	FindOneOrder(string) (*orders.Order, error)
 	FindOrder(*orders.OrderFilter) ([]*orders.Order, int)
+	FindOrderCursor(*orders.OrderFilter) ([]*orders.Order, bool)
+	CountOrder(*orders.OrderFilter) int
 	InsertOrder(*orders.Order) (string, error)
-	UpdateOrder(*orders.Order) error
+	UpdateOrder(req *orders.Order, fromStatus []string) error
+	ExportOrder(*orders.OrderFilter, func(*orders.OrderExportRow) error) error
+	UpdateTransferSlip(orderId string, slip *orders.TransferSlip, fromStatus []string, toStatus string) error
+	InsertRefund(refund *orders.Refund, restock bool) error
+	UpdateRefund(*orders.Refund) error
+	FindOneRefund(orderId, refundId string) (*orders.Refund, error)
+	CancelExpiredOrder(ctx context.Context, olderThan time.Duration, limit int) ([]string, error)
+	FindOrInsertInvoice(orderId string) (*orders.Invoice, error)
+	FindOrderComment(orderId string) ([]*orders.OrderComment, error)
+	InsertOrderComment(*orders.OrderComment) error
 }
 
 type orderRepository struct {
@@ -45,21 +61,59 @@ func (r *orderRepository) FindOneOrder(orderId string) (*orders.Order, error) {
 					SELECT
 						spo.id,
 						spo.qty,
-						spo.product
+						spo.canceled_qty,
+						spo.product,
+						spo.variant_id,
+						spo.variant,
+						spo.tax
 					FROM products_orders spo
 					WHERE spo.order_id = o.id
 				) AS pt
 			) AS products,
 			o.address,
 			o.contact,
+			o.note,
+			o.address_id,
+			o.shipping_address,
+			o.coupon_code,
+			o.discount,
+			o.tax_total,
 			(
 				SELECT
-					SUM(COALESCE((po.product ->> 'price')::FLOAT*(po.qty)::FLOAT, 0))
+					SUM(COALESCE((po.tax->>'gross')::FLOAT, (po.product->>'price')::FLOAT*(po.qty)::FLOAT, 0))
+					-- Lines with a discount share are already discounted, older orders take the discount off the total
+					- o.discount + COALESCE(SUM((po.tax->>'discount')::FLOAT), 0)
 				FROM products_orders po
 				WHERE po.order_id = o.id
 			) AS total_paid,
+			(
+				SELECT
+					COALESCE(SUM(r.amount), 0)
+				FROM refunds r
+				WHERE r.order_id = o.id
+				AND r.status = 'succeeded'
+			) AS refunded_amount,
+			(
+				SELECT
+					COALESCE(json_agg(rt ORDER BY rt.created_at), '[]'::json)
+				FROM (
+					SELECT
+						r.id,
+						r.order_id,
+						COALESCE(r.payment_id::TEXT, '') AS payment_id,
+						r.amount,
+						r.reason,
+						r.status,
+						r.lines,
+						r.created_at,
+						r.updated_at
+					FROM refunds r
+					WHERE r.order_id = o.id
+				) AS rt
+			) AS refunds,
 			o.created_at,
-			o.updated_at
+			o.updated_at,
+			o.version
 		FROM orders o
 		WHERE o.id = $1
 	) AS t;`
@@ -85,6 +139,31 @@ func (r *orderRepository) FindOrder(req *orders.OrderFilter) ([]*orders.Order, i
 	return engineer.FindOrder(), engineer.CountOrder()
 }
 
+// FindOrderCursor gives the page in display order and whether more rows follow in the direction it was read
+func (r *orderRepository) FindOrderCursor(req *orders.OrderFilter) ([]*orders.Order, bool) {
+	builder := orderPatterns.FindOrderBuilder(r.db, req)
+	result := orderPatterns.FindOrderEngineer(builder).FindOrderCursor()
+
+	hasMore := len(result) > req.Limit
+	if hasMore {
+		result = result[:req.Limit]
+	}
+	if req.After != nil && req.After.Backward {
+		slices.Reverse(result)
+	}
+	return result, hasMore
+}
+
+func (r *orderRepository) CountOrder(req *orders.OrderFilter) int {
+	builder := orderPatterns.FindOrderBuilder(r.db, req)
+	return orderPatterns.FindOrderEngineer(builder).CountOrder()
+}
+
+func (r *orderRepository) ExportOrder(req *orders.OrderFilter, fn func(*orders.OrderExportRow) error) error {
+	builder := orderPatterns.FindOrderBuilder(r.db, req)
+	return orderPatterns.FindOrderEngineer(builder).ExportOrder(fn)
+}
+
 func (r *orderRepository) InsertOrder(req *orders.Order) (string, error) {
 	builder := orderPatterns.InsertOrderBuilder(r.db, req)
 	orderId, err := orderPatterns.InsertOrderEngineer(builder).InsertOrder()
@@ -94,7 +173,9 @@ func (r *orderRepository) InsertOrder(req *orders.Order) (string, error) {
 	return orderId, nil
 }
 
-func (r *orderRepository) UpdateOrder(req *orders.Order) error {
+// UpdateOrder updates the status of the order, only if the order is still in one of fromStatus when fromStatus is not empty.
+// A non empty UserId only updates an order of that user
+func (r *orderRepository) UpdateOrder(req *orders.Order, fromStatus []string) error {
 	query := `
 	UPDATE "orders" SET`
 
@@ -134,8 +215,574 @@ func (r *orderRepository) UpdateOrder(req *orders.Order) error {
 	}
 	query += queryClose
 
-	if _, err := r.db.ExecContext(context.Background(), query, values...); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// The order is locked for the update and compared with the version the client read, 0 skips the compare
+	var version int
+	var status, userId string
+	if err := tx.QueryRowxContext(ctx, `SELECT "version", "status"::TEXT, "user_id" FROM "orders" WHERE "id" = $1 FOR UPDATE;`, req.Id).Scan(&version, &status, &userId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return orders.ErrOrderNotFound
+		}
+		return fmt.Errorf("get order version failed: %v", err)
+	}
+	if req.UserId != "" && req.UserId != userId {
+		tx.Rollback()
+		return orders.ErrOrderNotFound
+	}
+	if req.Version != 0 && req.Version != version {
+		tx.Rollback()
+		return entities.ErrVersionConflict
+	}
+	if len(fromStatus) != 0 && !slices.Contains(fromStatus, status) {
+		tx.Rollback()
+		return fmt.Errorf("%w: order is %s", orders.ErrStatusNotAllowed, status)
+	}
+	if len(queryWhereStack) == 0 {
+		tx.Rollback()
+		return nil
+	}
+
+	if req.Status == "canceled" {
+		if err := releaseOrder(ctx, tx, req.Id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, query, values...); err != nil {
+		tx.Rollback()
 		return fmt.Errorf("update order failed: %v", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// releaseOrder gives back what a canceled order holds, once: stock, coupon usage and pending payments
+func releaseOrder(ctx context.Context, tx *sqlx.Tx, orderId string) error {
+	var status string
+	if err := tx.GetContext(ctx, &status, `SELECT "status"::TEXT FROM "orders" WHERE "id" = $1 FOR UPDATE;`, orderId); err != nil {
+		return fmt.Errorf("get order failed: %v", err)
+	}
+	if status == "canceled" {
+		return nil
+	}
+
+	query := `
+	UPDATE "products" p SET
+		"stock" = p."stock" + po."qty"
+	FROM (
+		SELECT
+			"product"->>'id' AS "product_id",
+			SUM("qty") AS "qty"
+		FROM "products_orders"
+		WHERE "order_id" = $1
+		AND "variant" IS NULL
+		GROUP BY "product"->>'id'
+	) AS po
+	WHERE p."id" = po."product_id"
+	AND p."stock" IS NOT NULL;`
+
+	if _, err := tx.ExecContext(ctx, query, orderId); err != nil {
+		return fmt.Errorf("restock order failed: %v", err)
+	}
+
+	// A line is a variant line by its snapshot, variant_id is nulled once the variant is deleted
+	// and the units of a deleted variant are not given back to anything
+	query = `
+	UPDATE "product_variants" v SET
+		"stock" = v."stock" + po."qty"
+	FROM (
+		SELECT
+			"variant"->>'id' AS "variant_id",
+			SUM("qty") AS "qty"
+		FROM "products_orders"
+		WHERE "order_id" = $1
+		AND "variant" IS NOT NULL
+		GROUP BY "variant"->>'id'
+	) AS po
+	WHERE v."id"::TEXT = po."variant_id"
+	AND v."stock" IS NOT NULL;`
+
+	if _, err := tx.ExecContext(ctx, query, orderId); err != nil {
+		return fmt.Errorf("restock order variants failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "coupon_redemptions" WHERE "order_id" = $1;`, orderId); err != nil {
+		return fmt.Errorf("release coupon failed: %v", err)
+	}
+
+	query = `
+	UPDATE "payments" SET
+		"status" = 'failed'
+	WHERE "order_id" = $1
+	AND "status" = 'pending';`
+
+	if _, err := tx.ExecContext(ctx, query, orderId); err != nil {
+		return fmt.Errorf("release payments failed: %v", err)
+	}
+	return nil
+}
+
+// UpdateTransferSlip replaces the slip and moves the order status, only if the order is still in one of fromStatus
+func (r *orderRepository) UpdateTransferSlip(orderId string, slip *orders.TransferSlip, fromStatus []string, toStatus string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	slipBytes, err := json.Marshal(slip)
+	if err != nil {
+		return fmt.Errorf("marshal transfer slip failed: %v", err)
+	}
+
+	query := `
+	UPDATE "orders" SET
+		"transfer_slip" = $1,
+		"status" = $2
+	WHERE "id" = $3
+	AND "status"::TEXT = ANY($4);`
+
+	res, err := r.db.ExecContext(ctx, query, string(slipBytes), toStatus, orderId, fromStatus)
+	if err != nil {
+		return fmt.Errorf("update transfer slip failed: %v", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return fmt.Errorf("order status has been changed, please try again")
+	}
+	return nil
+}
+
+// InsertRefund cancels the refund lines, recomputes the order totals and records the refund in one transaction
+func (r *orderRepository) InsertRefund(refund *orders.Refund, restock bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var status string
+	if err := tx.GetContext(ctx, &status, `SELECT "status"::TEXT FROM "orders" WHERE "id" = $1 FOR UPDATE;`, refund.OrderId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("get order failed: %v", err)
+	}
+	if status == "canceled" {
+		tx.Rollback()
+		return fmt.Errorf("order has been canceled")
+	}
+
+	// The refund was priced on the lines read before the lock, a line another refund changed since then is priced again by the caller
+	lineQty := make(map[string]int)
+	rows, err := tx.QueryxContext(ctx, `SELECT "id", "qty" FROM "products_orders" WHERE "order_id" = $1 FOR UPDATE;`, refund.OrderId)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("lock products_orders failed: %v", err)
+	}
+	for rows.Next() {
+		var id string
+		var qty int
+		if err := rows.Scan(&id, &qty); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return fmt.Errorf("scan products_orders failed: %v", err)
+		}
+		lineQty[id] = qty
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("lock products_orders failed: %v", err)
+	}
+	for _, line := range refund.Lines {
+		qty, ok := lineQty[line.ProductsOrderId]
+		if !ok || qty != line.LineQty || qty < line.Qty {
+			tx.Rollback()
+			return fmt.Errorf("order line %s has been changed, please try again", line.ProductsOrderId)
+		}
+	}
+
+	// The amount was bounded by the refunds read before the lock, one made since then bounds it again
+	var priorRefunds int
+	if err := tx.GetContext(ctx, &priorRefunds, `SELECT COUNT(*) FROM "refunds" WHERE "order_id" = $1;`, refund.OrderId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("count refunds failed: %v", err)
+	}
+	if priorRefunds != refund.PriorRefunds {
+		tx.Rollback()
+		return fmt.Errorf("order has been refunded meanwhile, please try again")
+	}
+
+	// Lines without a tax snapshot share the part of the order discount the snapshots do not hold, it shrinks with their amount
+	var legacySubtotal, lineDiscount float64
+	query := `
+	SELECT
+		COALESCE(SUM(("product"->>'price')::FLOAT*"qty") FILTER (WHERE "tax" IS NULL), 0),
+		COALESCE(SUM(("tax"->>'discount')::FLOAT), 0)
+	FROM "products_orders"
+	WHERE "order_id" = $1;`
+	if err := tx.QueryRowxContext(ctx, query, refund.OrderId).Scan(&legacySubtotal, &lineDiscount); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("get order discount failed: %v", err)
+	}
+
+	for _, line := range refund.Lines {
+		// A nil tax stays NULL, not a JSON null, so the line keeps counting as one without a snapshot
+		var taxArg any
+		if line.Tax != nil {
+			taxBytes, err := json.Marshal(line.Tax)
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("marshal line tax failed: %v", err)
+			}
+			taxArg = string(taxBytes)
+		}
+
+		query := `
+		UPDATE "products_orders" SET
+			"qty" = "qty" - $1,
+			"canceled_qty" = "canceled_qty" + $1,
+			"tax" = $2
+		WHERE "id" = $3
+		AND "order_id" = $4
+		AND "qty" >= $1;`
+
+		result, err := tx.ExecContext(ctx, query, line.Qty, taxArg, line.ProductsOrderId, refund.OrderId)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("update products_orders failed: %v", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			tx.Rollback()
+			return fmt.Errorf("order line %s has been changed, please try again", line.ProductsOrderId)
+		}
+
+		if restock {
+			query = `
+			UPDATE "products" SET
+				"stock" = "stock" + $1
+			WHERE "id" = $2
+			AND "stock" IS NOT NULL;`
+			id := line.ProductId
+			// A variant line holds the stock of its variant, a deleted variant matches no row and nothing is restocked
+			if line.VariantId != "" {
+				query = `
+				UPDATE "product_variants" SET
+					"stock" = "stock" + $1
+				WHERE "id" = $2
+				AND "stock" IS NOT NULL;`
+				id = line.VariantId
+			}
+
+			if _, err := tx.ExecContext(ctx, query, line.Qty, id); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("restock product failed: %v", err)
+			}
+		}
+	}
+
+	// Lines with a discount share carry the order discount, lines without a snapshot keep the rest of it by what is left of their amount.
+	// An order without lines is canceled
+	query = `
+	UPDATE "orders" o SET
+		"tax_total" = t."tax_total",
+		"discount" = ROUND((t."line_discount" + GREATEST(o."discount" - $2::FLOAT, 0) * COALESCE(t."legacy_subtotal" / NULLIF($3::FLOAT, 0), 0))::NUMERIC, 2)::FLOAT,
+		"status" = CASE WHEN t."qty" = 0 THEN 'canceled'::order_status ELSE o."status" END
+	FROM (
+		SELECT
+			COALESCE(SUM((po."tax"->>'tax')::FLOAT), 0) AS "tax_total",
+			COALESCE(SUM((po."product"->>'price')::FLOAT*po."qty") FILTER (WHERE po."tax" IS NULL), 0) AS "legacy_subtotal",
+			COALESCE(SUM((po."tax"->>'discount')::FLOAT), 0) AS "line_discount",
+			COALESCE(SUM(po."qty"), 0) AS "qty"
+		FROM "products_orders" po
+		WHERE po."order_id" = $1
+	) AS t
+	WHERE o."id" = $1;`
+
+	if _, err := tx.ExecContext(ctx, query, refund.OrderId, lineDiscount, legacySubtotal); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update order totals failed: %v", err)
+	}
+
+	linesBytes, err := json.Marshal(refund.Lines)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("marshal refund lines failed: %v", err)
+	}
+
+	query = `
+	INSERT INTO "refunds" (
+		"order_id",
+		"amount",
+		"reason",
+		"status",
+		"lines"
+	)
+	VALUES
+	($1, $2, $3, $4, $5)
+		RETURNING "id";`
+
+	if err := tx.QueryRowxContext(
+		ctx,
+		query,
+		refund.OrderId,
+		refund.Amount,
+		refund.Reason,
+		refund.Status,
+		string(linesBytes),
+	).Scan(&refund.Id); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert refund failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+func (r *orderRepository) UpdateRefund(req *orders.Refund) error {
+	query := `
+	UPDATE "refunds" SET
+		"status" = $1,
+		"payment_id" = COALESCE(NULLIF($2, '')::uuid, "payment_id")
+	WHERE "id" = $3
+	AND "order_id" = $4;`
+
+	result, err := r.db.ExecContext(context.Background(), query, req.Status, req.PaymentId, req.Id, req.OrderId)
+	if err != nil {
+		return fmt.Errorf("update refund failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("refund not found")
+	}
+	return nil
+}
+
+func (r *orderRepository) FindOneRefund(orderId, refundId string) (*orders.Refund, error) {
+	query := `
+	SELECT
+		to_jsonb(t)
+	FROM (
+		SELECT
+			r.id,
+			r.order_id,
+			COALESCE(r.payment_id::TEXT, '') AS payment_id,
+			r.amount,
+			r.reason,
+			r.status,
+			r.lines,
+			r.created_at,
+			r.updated_at
+		FROM refunds r
+		WHERE r.order_id = $1
+		AND r.id = $2
+	) AS t;`
+
+	raw := make([]byte, 0)
+	if err := r.db.Get(&raw, query, orderId, refundId); err != nil {
+		return nil, fmt.Errorf("get refund failed: %v", err)
+	}
+
+	refund := new(orders.Refund)
+	if err := json.Unmarshal(raw, refund); err != nil {
+		return nil, fmt.Errorf("unmarshal refund failed: %v", err)
+	}
+	return refund, nil
+}
+
+// CancelExpiredOrder cancels waiting orders created before olderThan ago and returns their ids
+func (r *orderRepository) CancelExpiredOrder(ctx context.Context, olderThan time.Duration, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Orders being paid or updated right now are skipped and picked up next run
+	query := `
+	SELECT
+		"id"
+	FROM "orders"
+	WHERE "status" = 'waiting'
+	AND "created_at" < now() - make_interval(secs => $1)
+	ORDER BY "created_at"
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED;`
+
+	orderIds := make([]string, 0)
+	if err := tx.SelectContext(ctx, &orderIds, query, olderThan.Seconds(), limit); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("get expired orders failed: %v", err)
+	}
+
+	for _, orderId := range orderIds {
+		if err := releaseOrder(ctx, tx, orderId); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if len(orderIds) > 0 {
+		query = `
+		UPDATE "orders" SET
+			"status" = 'canceled'
+		WHERE "id" = ANY($1);`
+
+		if _, err := tx.ExecContext(ctx, query, orderIds); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("cancel expired orders failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return orderIds, nil
+}
+
+func (r *orderRepository) findInvoice(orderId string) (*orders.Invoice, error) {
+	query := `
+	SELECT
+		"id",
+		"order_id",
+		"number",
+		"issued_at"::TEXT AS "issued_at"
+	FROM "invoices"
+	WHERE "order_id" = $1;`
+
+	invoice := new(orders.Invoice)
+	if err := r.db.Get(invoice, query, orderId); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// FindOrInsertInvoice returns the invoice of an order, the first call on a paid order allocates the next invoice number
+func (r *orderRepository) FindOrInsertInvoice(orderId string) (*orders.Invoice, error) {
+	invoice, err := r.findInvoice(orderId)
+	if err == nil {
+		return invoice, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("get invoice failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The order keeps its status until the invoice is in, a number is only taken for a paid order
+	var paid bool
+	if err := tx.GetContext(ctx, &paid, fmt.Sprintf(`SELECT %s FROM "orders" o WHERE o."id" = $1 FOR SHARE;`, orders.PaidOrderStatus), orderId); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("get order failed: %v", err)
+	}
+	if !paid {
+		tx.Rollback()
+		return nil, fmt.Errorf("order has not been paid")
+	}
+
+	// The upsert locks the counter row until commit, a rollback gives the number back
+	var year, number int
+	query := `
+	INSERT INTO "invoice_counters" (
+		"year",
+		"last_number"
+	)
+	VALUES
+	(EXTRACT(YEAR FROM now())::INT, 1)
+	ON CONFLICT ("year") DO UPDATE SET
+		"last_number" = "invoice_counters"."last_number" + 1
+	RETURNING "year", "last_number";`
+
+	if err := tx.QueryRowxContext(ctx, query).Scan(&year, &number); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("allocate invoice number failed: %v", err)
+	}
+
+	query = `
+	INSERT INTO "invoices" (
+		"order_id",
+		"number"
+	)
+	VALUES
+	($1, $2)
+	ON CONFLICT ("order_id") DO NOTHING;`
+
+	result, err := tx.ExecContext(ctx, query, orderId, fmt.Sprintf("INV-%d-%06d", year, number))
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("insert invoice failed: %v", err)
+	}
+
+	// Another request issued the invoice first, drop this number
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+	} else if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	invoice, err = r.findInvoice(orderId)
+	if err != nil {
+		return nil, fmt.Errorf("get invoice failed: %v", err)
+	}
+	return invoice, nil
+}
+
+func (r *orderRepository) FindOrderComment(orderId string) ([]*orders.OrderComment, error) {
+	query := `
+	SELECT
+		c."id",
+		c."order_id",
+		c."user_id",
+		COALESCE(u."username", '') AS "username",
+		c."body",
+		c."created_at"::TEXT AS "created_at"
+	FROM "order_comments" c
+	LEFT JOIN "users" u ON u."id" = c."user_id"
+	WHERE c."order_id" = $1
+	ORDER BY c."created_at";`
+
+	result := make([]*orders.OrderComment, 0)
+	if err := r.db.Select(&result, query, orderId); err != nil {
+		return nil, fmt.Errorf("get order comments failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *orderRepository) InsertOrderComment(req *orders.OrderComment) error {
+	query := `
+	INSERT INTO "order_comments" (
+		"order_id",
+		"user_id",
+		"body"
+	)
+	VALUES
+	($1, $2, $3)
+		RETURNING "id";`
+
+	if err := r.db.QueryRowxContext(context.Background(), query, req.OrderId, req.UserId, req.Body).Scan(&req.Id); err != nil {
+		return fmt.Errorf("insert order comment failed: %v", err)
+	}
 	return nil
 }
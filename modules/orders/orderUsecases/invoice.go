@@ -0,0 +1,149 @@
+package orderUsecases
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/modules/orders"
+	"go_learn_project_rest_api/pkgs/fonts"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+var paymentStatusMap = map[string]string{
+	"paid":      "PAID",
+	"shipping":  "PAID",
+	"completed": "PAID",
+}
+
+func (u *orderUsecases) InvoicePdf(userId, orderId, shopName string, w io.Writer) (*orders.Invoice, error) {
+	order, err := u.orderRepository.FindOneOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userId {
+		return nil, fmt.Errorf("order not found")
+	}
+	// An invoice number is never given back, so it is only taken for an order that has been paid
+	if !slices.Contains(orders.PaidStatus, order.Status) {
+		return nil, fmt.Errorf("order status %s has no invoice, it is issued once the order is paid", order.Status)
+	}
+
+	invoice, err := u.orderRepository.FindOrInsertInvoice(order.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := renderInvoice(w, shopName, invoice, order); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func renderInvoice(w io.Writer, shopName string, invoice *orders.Invoice, order *orders.Order) error {
+	// Names and addresses are UTF-8, the core fonts only print cp1252
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fonts.Family, "", fonts.Regular)
+	pdf.SetTitle(invoice.Number, true)
+	pdf.AddPage()
+
+	// Header
+	setInvoiceFont(pdf, "B", 18)
+	pdf.CellFormat(120, 10, shopName, "", 0, "L", false, 0, "")
+	pdf.CellFormat(70, 10, "INVOICE", "", 1, "R", false, 0, "")
+
+	setInvoiceFont(pdf, "", 10)
+	issuedAt := invoice.IssuedAt
+	if len(issuedAt) > 19 {
+		issuedAt = issuedAt[:19]
+	}
+	for _, row := range [][2]string{
+		{"Invoice no.", invoice.Number},
+		{"Issued at", issuedAt},
+		{"Order", order.Id},
+		{"Payment status", paymentStatusMap[order.Status]},
+	} {
+		pdf.CellFormat(130, 6, "", "", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 6, row[1], "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	setInvoiceFont(pdf, "B", 10)
+	pdf.CellFormat(0, 6, "Bill to", "", 1, "L", false, 0, "")
+	setInvoiceFont(pdf, "", 10)
+	pdf.MultiCell(0, 5, strings.TrimSpace(order.Contact+"\n"+order.Address), "", "L", false)
+	pdf.Ln(4)
+
+	// Lines, priced from the snapshot taken when the order was placed
+	widths := []float64{80, 25, 20, 30, 35}
+	setInvoiceFont(pdf, "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	for i, title := range []string{"Item", "Price", "Qty", "Tax", "Amount"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, title, "B", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	setInvoiceFont(pdf, "", 10)
+	invoiceTotals := order.InvoiceTotals()
+	for _, line := range invoiceTotals.Lines {
+		taxText := "-"
+		if line.Tax != nil {
+			taxText = fmt.Sprintf("%.2f (%g%%)", line.Tax.Tax, line.Tax.Rate)
+		}
+		pdf.CellFormat(widths[0], 7, line.Title, "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 7, fmt.Sprintf("%.2f", line.Price), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[2], 7, fmt.Sprintf("%d", line.Qty), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 7, taxText, "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 7, fmt.Sprintf("%.2f", line.Amount), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+
+	// Totals
+	totals := [][2]string{
+		{"Subtotal", fmt.Sprintf("%.2f", invoiceTotals.Subtotal)},
+	}
+	if invoiceTotals.Discount > 0 {
+		totals = append(totals, [2]string{fmt.Sprintf("Discount %s", order.CouponCode), fmt.Sprintf("-%.2f", invoiceTotals.Discount)})
+	}
+	// Refunded lines are already left out of the lines and the total, so the refund is not taken off again
+	totalTitle := "Total"
+	if order.RefundedAmount > 0 {
+		totalTitle = "Total after refunds"
+	}
+	totals = append(totals,
+		[2]string{"Tax", fmt.Sprintf("%.2f", invoiceTotals.TaxTotal)},
+		[2]string{totalTitle, fmt.Sprintf("%.2f", invoiceTotals.Total)},
+	)
+	for i, row := range totals {
+		style := ""
+		if i == len(totals)-1 {
+			style = "B"
+		}
+		setInvoiceFont(pdf, style, 10)
+		border := ""
+		if i == 0 {
+			border = "T"
+		}
+		pdf.CellFormat(115, 7, "", "", 0, "L", false, 0, "")
+		pdf.CellFormat(40, 7, row[0], border, 0, "L", false, 0, "")
+		pdf.CellFormat(35, 7, row[1], border, 1, "R", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+// setInvoiceFont sets the style of the text that follows, the font has no bold face so bold text is filled and stroked
+func setInvoiceFont(pdf *fpdf.Fpdf, style string, size float64) {
+	pdf.SetFont(fonts.Family, "", size)
+	if style == "B" {
+		pdf.SetTextRenderingMode(2)
+	} else {
+		pdf.SetTextRenderingMode(0)
+	}
+}
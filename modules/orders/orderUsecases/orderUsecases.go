@@ -1,31 +1,73 @@
 package orderUsecases
 
 import (
+	"context"
+	"encoding/csv"
 	"fmt"
+	"go_learn_project_rest_api/modules/addresses/addressRepositories"
 	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/files"
+	"go_learn_project_rest_api/modules/files/fileUsecases"
+	"go_learn_project_rest_api/modules/notifications"
+	"go_learn_project_rest_api/modules/notifications/notificationRepositories"
 	"go_learn_project_rest_api/modules/orders"
 	"go_learn_project_rest_api/modules/orders/orderRepositories"
+	"go_learn_project_rest_api/modules/payments"
+	"go_learn_project_rest_api/modules/payments/paymentRepositories"
+	"go_learn_project_rest_api/modules/payments/paymentUsecases"
 	"go_learn_project_rest_api/modules/products/productRepositories"
+	"go_learn_project_rest_api/modules/taxes"
+	"go_learn_project_rest_api/modules/taxes/taxRepositories"
 	"go_learn_project_rest_api/pkgs/utils"
+	"io"
+	"log"
 	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
 )
 
 type IOrderUsecases interface {
 	FindOneOrder(string) (*orders.Order, error)
 	FindOrder(*orders.OrderFilter) *entities.PaginateRes
+	FindOrderCursor(*orders.OrderFilter) *entities.CursorPaginateRes
 	InsertOrder(*orders.Order) (*orders.Order, error)
-	UpdateOrder(*orders.Order) (*orders.Order, error)
+	UpdateOrder(req *orders.Order, fromStatus []string) (*orders.Order, error)
+	ExportOrder(*orders.OrderFilter, string, io.Writer) error
+	UploadTransferSlip(userId, orderId string, req *files.FileReq) (*orders.Order, error)
+	VerifyTransferSlip(*orders.VerifySlipReq) (*orders.Order, error)
+	InsertRefund(*orders.RefundReq) (*orders.Order, error)
+	UpdateRefund(*orders.Refund) (*orders.Order, error)
+	CancelExpiredOrder(ctx context.Context, olderThan time.Duration) ([]string, error)
+	InvoicePdf(userId, orderId, shopName string, w io.Writer) (*orders.Invoice, error)
+	Reorder(userId, orderId string) (*orders.ReorderRes, error)
+	FindOrderComment(orderId string) ([]*orders.OrderComment, error)
+	InsertOrderComment(*orders.OrderComment) ([]*orders.OrderComment, error)
 }
 
 type orderUsecases struct {
-	orderRepository   orderRepositories.IOrderRepository
-	productRepository productRepositories.IProductRepository
+	orderRepository        orderRepositories.IOrderRepository
+	productRepository      productRepositories.IProductRepository
+	addressRepository      addressRepositories.IAddressRepository
+	taxRepository          taxRepositories.ITaxRepository
+	notificationRepository notificationRepositories.INotificationRepository
+	fileUsecases           fileUsecases.IFileUsecases
+	paymentRepository      paymentRepositories.IPaymentRepository
+	paymentUsecases        paymentUsecases.IPaymentUsecases
 }
 
-func OrderUsecases(orderRepository orderRepositories.IOrderRepository, productRepository productRepositories.IProductRepository) IOrderUsecases {
+func OrderUsecases(orderRepository orderRepositories.IOrderRepository, productRepository productRepositories.IProductRepository, addressRepository addressRepositories.IAddressRepository, taxRepository taxRepositories.ITaxRepository, notificationRepository notificationRepositories.INotificationRepository, fileUsecases fileUsecases.IFileUsecases, paymentRepository paymentRepositories.IPaymentRepository, paymentUsecases paymentUsecases.IPaymentUsecases) IOrderUsecases {
 	return &orderUsecases{
-		orderRepository:   orderRepository,
-		productRepository: productRepository,
+		orderRepository:        orderRepository,
+		productRepository:      productRepository,
+		addressRepository:      addressRepository,
+		taxRepository:          taxRepository,
+		notificationRepository: notificationRepository,
+		fileUsecases:           fileUsecases,
+		paymentRepository:      paymentRepository,
+		paymentUsecases:        paymentUsecases,
 	}
 }
 
@@ -44,23 +86,88 @@ func (u *orderUsecases) FindOrder(req *orders.OrderFilter) *entities.PaginateRes
 	}
 }
 
+func (u *orderUsecases) FindOrderCursor(req *orders.OrderFilter) *entities.CursorPaginateRes {
+	ordersData, hasMore := u.orderRepository.FindOrderCursor(req)
+	next, prev := entities.CursorPage(req.CursorReq, req.SortReq, len(ordersData), hasMore, func(i int) (string, string) {
+		return ordersData[i].CursorValue(req.OrderBy), ordersData[i].Id
+	})
+
+	res := &entities.CursorPaginateRes{
+		Data:       ordersData,
+		Limit:      req.Limit,
+		NextCursor: next,
+		PrevCursor: prev,
+	}
+	if !req.SkipCount {
+		count := u.orderRepository.CountOrder(req)
+		res.TotalItem = &count
+	}
+	return res
+}
+
 func (u *orderUsecases) InsertOrder(req *orders.Order) (*orders.Order, error) {
+	// Snapshot the address book entry, so later edits do not change the order
+	if req.AddressId != "" {
+		address, err := u.addressRepository.FindOneAddress(req.UserId, req.AddressId)
+		if err != nil {
+			return nil, err
+		}
+		req.ShippingAddress = address
+		req.Address = address.Format()
+		req.Contact = address.Contact()
+	} else {
+		req.ShippingAddress = nil
+	}
+
+	// Tax rates can be bound to the shipping province or country
+	regions := make([]string, 0)
+	if req.ShippingAddress != nil {
+		regions = append(regions, req.ShippingAddress.Province, req.ShippingAddress.Country)
+	}
+
 	// Check if products is exists
+	req.TaxTotal = 0
 	for i := range req.Products {
 		if req.Products[i].Product == nil {
 			return nil, fmt.Errorf("product is nil")
 		}
+		if req.Products[i].Qty < 1 {
+			return nil, fmt.Errorf("qty must be at least 1")
+		}
 
 		prod, err := u.productRepository.FindOneProduct(req.Products[i].Product.Id)
 		if err != nil {
 			return nil, err
 		}
 		utils.Debug(prod)
+		if !prod.Visible {
+			return nil, fmt.Errorf("product %s is not available", prod.Title)
+		}
 
-		// Set price
-		req.TotalPaid += req.Products[i].Product.Price * float64(req.Products[i].Qty)
+		// A product with variants is ordered by one of them, the snapshot carries the variant price
+		req.Products[i].Variant = nil
+		if len(prod.Variants) > 0 || req.Products[i].VariantId != "" {
+			variant := prod.FindVariant(req.Products[i].VariantId)
+			if variant == nil {
+				return nil, fmt.Errorf("variant of %s is invalid", prod.Title)
+			}
+			prod.Price = variant.PriceOf(prod)
+			req.Products[i].Variant = variant
+		}
+		prod.Options, prod.Variants = nil, nil
+
+		// Set price and tax from the catalog, never from the request
+		rate, err := u.taxRepository.ResolveTaxRate(prod.Id, regions)
+		if err != nil {
+			return nil, err
+		}
 		req.Products[i].Product = prod
+		req.Products[i].Tax = taxes.Calculate(rate, prod.Price, req.Products[i].Qty, prod.TaxMode)
+
+		req.TaxTotal += req.Products[i].Tax.Tax
+		req.TotalPaid += req.Products[i].Tax.Gross
 	}
+	req.TaxTotal = math.Round(req.TaxTotal*100) / 100
 
 	orderId, err := u.orderRepository.InsertOrder(req)
 	if err != nil {
@@ -75,8 +182,110 @@ func (u *orderUsecases) InsertOrder(req *orders.Order) (*orders.Order, error) {
 	return order, nil
 }
 
-func (u *orderUsecases) UpdateOrder(req *orders.Order) (*orders.Order, error) {
-	if err := u.orderRepository.UpdateOrder(req); err != nil {
+// Reorder places a new order with the lines of an old one, priced from the current catalog
+func (u *orderUsecases) Reorder(userId, orderId string) (*orders.ReorderRes, error) {
+	old, err := u.orderRepository.FindOneOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if old.UserId != userId {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	req := &orders.Order{
+		UserId:   old.UserId,
+		Address:  old.Address,
+		Contact:  old.Contact,
+		Status:   "waiting",
+		Products: make([]*orders.ProductsOrder, 0),
+	}
+	// The address book entry may be gone, the old snapshot is used then
+	if old.AddressId != "" {
+		if _, err := u.addressRepository.FindOneAddress(userId, old.AddressId); err == nil {
+			req.AddressId = old.AddressId
+		}
+	}
+
+	skipped := make([]*orders.ReorderSkip, 0)
+	for _, line := range old.Products {
+		if line.Product == nil || line.Qty == 0 {
+			continue
+		}
+		skip := &orders.ReorderSkip{
+			ProductId: line.Product.Id,
+			Title:     line.Product.Title,
+			Qty:       line.Qty,
+		}
+
+		prod, err := u.productRepository.FindOneProduct(line.Product.Id)
+		if err != nil {
+			skip.Reason = "product no longer exists"
+			skipped = append(skipped, skip)
+			continue
+		}
+		if !prod.Visible {
+			skip.Reason = "product is no longer available"
+			skipped = append(skipped, skip)
+			continue
+		}
+		stock := prod.Stock
+		variantId := line.OrderedVariantId()
+		if variantId == "" && len(prod.Variants) > 0 {
+			skip.Reason = "product now has variants, one must be chosen"
+			skipped = append(skipped, skip)
+			continue
+		}
+		if variantId != "" {
+			variant := prod.FindVariant(variantId)
+			if variant == nil {
+				skip.Reason = "variant no longer exists"
+				skipped = append(skipped, skip)
+				continue
+			}
+			stock = variant.Stock
+		}
+		if stock != nil && *stock < line.Qty {
+			skip.Reason = "product is out of stock"
+			skipped = append(skipped, skip)
+			continue
+		}
+
+		req.Products = append(req.Products, &orders.ProductsOrder{
+			Qty:       line.Qty,
+			Product:   prod,
+			VariantId: variantId,
+		})
+	}
+	if len(req.Products) == 0 {
+		return nil, fmt.Errorf("no product of order %s can be ordered again", old.Id)
+	}
+
+	order, err := u.InsertOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	return &orders.ReorderRes{
+		Order:   order,
+		Skipped: skipped,
+	}, nil
+}
+
+func (u *orderUsecases) FindOrderComment(orderId string) ([]*orders.OrderComment, error) {
+	return u.orderRepository.FindOrderComment(orderId)
+}
+
+func (u *orderUsecases) InsertOrderComment(req *orders.OrderComment) ([]*orders.OrderComment, error) {
+	if _, err := u.orderRepository.FindOneOrder(req.OrderId); err != nil {
+		return nil, err
+	}
+	if err := u.orderRepository.InsertOrderComment(req); err != nil {
+		return nil, err
+	}
+	return u.orderRepository.FindOrderComment(req.OrderId)
+}
+
+func (u *orderUsecases) UpdateOrder(req *orders.Order, fromStatus []string) (*orders.Order, error) {
+	if err := u.orderRepository.UpdateOrder(req, fromStatus); err != nil {
 		return nil, err
 	}
 
@@ -86,3 +295,321 @@ func (u *orderUsecases) UpdateOrder(req *orders.Order) (*orders.Order, error) {
 	}
 	return order, nil
 }
+
+func (u *orderUsecases) UploadTransferSlip(userId, orderId string, req *files.FileReq) (*orders.Order, error) {
+	order, err := u.orderRepository.FindOneOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userId {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	// A rejected slip sends the order back to waiting, so the customer can upload again
+	fromStatus := []string{"waiting", "pending_verification"}
+	if !slices.Contains(fromStatus, order.Status) {
+		return nil, fmt.Errorf("order status %s can not upload transfer slip", order.Status)
+	}
+
+	res, err := u.fileUsecases.UploadToGCP([]*files.FileReq{req})
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := slipTime()
+	if err != nil {
+		return nil, err
+	}
+	slip := &orders.TransferSlip{
+		Id:        uuid.NewString(),
+		FileName:  res[0].FileName,
+		Url:       res[0].Url,
+		Status:    orders.SlipPending,
+		CreatedAt: now,
+	}
+	if err := u.orderRepository.UpdateTransferSlip(orderId, slip, fromStatus, "pending_verification"); err != nil {
+		return nil, err
+	}
+	return u.orderRepository.FindOneOrder(orderId)
+}
+
+func (u *orderUsecases) VerifyTransferSlip(req *orders.VerifySlipReq) (*orders.Order, error) {
+	order, err := u.orderRepository.FindOneOrder(req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != "pending_verification" || order.TransferSlip == nil {
+		return nil, fmt.Errorf("order has no transfer slip waiting for verification")
+	}
+	if !req.Approve && req.Reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+
+	now, err := slipTime()
+	if err != nil {
+		return nil, err
+	}
+	slip := order.TransferSlip
+	slip.Reason = req.Reason
+	slip.VerifiedAt = now
+
+	notification := &notifications.Notification{
+		UserId:  order.UserId,
+		OrderId: order.Id,
+	}
+	toStatus := "paid"
+	if req.Approve {
+		slip.Status = orders.SlipApproved
+		notification.Type = notifications.SlipApproved
+		notification.Message = fmt.Sprintf("Your transfer slip for order %s has been approved", order.Id)
+	} else {
+		toStatus = "waiting"
+		slip.Status = orders.SlipRejected
+		notification.Type = notifications.SlipRejected
+		notification.Message = fmt.Sprintf("Your transfer slip for order %s has been rejected: %s. Please upload a new slip", order.Id, req.Reason)
+	}
+
+	if err := u.orderRepository.UpdateTransferSlip(order.Id, slip, []string{"pending_verification"}, toStatus); err != nil {
+		return nil, err
+	}
+	if err := u.notificationRepository.InsertNotification(notification); err != nil {
+		return nil, err
+	}
+	return u.orderRepository.FindOneOrder(order.Id)
+}
+
+func (u *orderUsecases) InsertRefund(req *orders.RefundReq) (*orders.Order, error) {
+	order, err := u.orderRepository.FindOneOrder(req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == "canceled" {
+		return nil, fmt.Errorf("order has been canceled")
+	}
+	if len(req.Lines) == 0 {
+		return nil, fmt.Errorf("lines are empty")
+	}
+
+	lineMap := make(map[string]*orders.ProductsOrder)
+	for _, line := range order.Products {
+		lineMap[line.Id] = line
+	}
+
+	refund := &orders.Refund{
+		OrderId: order.Id,
+		Reason:  req.Reason,
+		Lines:   make([]*orders.RefundLine, 0, len(req.Lines)),
+	}
+	seen := make(map[string]bool)
+	total := 0.0
+	legacyRate := order.LegacyDiscountRate()
+	for _, l := range req.Lines {
+		line := lineMap[l.ProductsOrderId]
+		if line == nil || line.Product == nil {
+			return nil, fmt.Errorf("order line %s not found", l.ProductsOrderId)
+		}
+		if seen[line.Id] {
+			return nil, fmt.Errorf("order line %s is duplicated", line.Id)
+		}
+		seen[line.Id] = true
+		if l.Qty < 1 || l.Qty > line.Qty {
+			return nil, fmt.Errorf("qty of order line %s must be between 1 and %d", line.Id, line.Qty)
+		}
+
+		tax, amount := orders.RefundLineTax(line, l.Qty)
+		if line.Tax == nil {
+			// A line from before tax snapshots stays without one, the order keeps its share of the discount
+			tax = nil
+			amount = math.Round(amount*(1-legacyRate)*100) / 100
+		}
+
+		refund.Lines = append(refund.Lines, &orders.RefundLine{
+			ProductsOrderId: line.Id,
+			ProductId:       line.Product.Id,
+			VariantId:       line.OrderedVariantId(),
+			Qty:             l.Qty,
+			Amount:          amount,
+			Tax:             tax,
+			LineQty:         line.Qty,
+		})
+		total += amount
+	}
+
+	// Nothing was paid yet, so there is nothing to give back
+	if !slices.Contains(orders.PaidStatus, order.Status) {
+		refund.Amount = 0
+		refund.Status = orders.RefundSucceeded
+	} else {
+		refund.Amount = total
+		if req.Amount != nil {
+			refund.Amount = *req.Amount
+		}
+		// An order paid by bank transfer has no provider payment to cap the refund, so it is bounded by what is left of the order
+		refundable := order.RefundableAmount()
+		if refund.Amount < 0 || refund.Amount > refundable+0.009 {
+			return nil, fmt.Errorf("refund amount must be between 0 and %.2f", refundable)
+		}
+		refund.PriorRefunds = len(order.Refunds)
+		refund.Amount = math.Round(refund.Amount*100) / 100
+		refund.Status = orders.RefundPending
+	}
+
+	restock := req.Restock == nil || *req.Restock
+	if err := u.orderRepository.InsertRefund(refund, restock); err != nil {
+		return nil, err
+	}
+
+	if refund.Status == orders.RefundPending && refund.Amount > 0 {
+		if err := u.refundPayment(refund); err != nil {
+			return nil, err
+		}
+	}
+	return u.orderRepository.FindOneOrder(order.Id)
+}
+
+// refundPayment returns the money through the payment the order was paid with,
+// orders paid outside a provider keep the refund pending until an admin settles it
+func (u *orderUsecases) refundPayment(refund *orders.Refund) error {
+	paymentList, err := u.paymentRepository.FindPaymentByOrder(refund.OrderId)
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range paymentList {
+		if payment.Status != payments.Succeeded || payment.Amount-payment.RefundedAmount < refund.Amount-0.009 {
+			continue
+		}
+
+		refund.PaymentId = payment.Id
+		refund.Status = orders.RefundSucceeded
+		_, refundErr := u.paymentUsecases.Refund(&payments.RefundReq{
+			PaymentId: payment.Id,
+			Amount:    refund.Amount,
+		})
+		if refundErr != nil {
+			// The lines stay canceled, the failed refund is left for an admin to settle
+			log.Printf("refund payment %s failed: %v\n", payment.Id, refundErr)
+			refund.Status = orders.RefundFailed
+		}
+		return u.orderRepository.UpdateRefund(refund)
+	}
+	return nil
+}
+
+func (u *orderUsecases) UpdateRefund(req *orders.Refund) (*orders.Order, error) {
+	refund, err := u.orderRepository.FindOneRefund(req.OrderId, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status == orders.RefundSucceeded {
+		return nil, fmt.Errorf("refund has been succeeded")
+	}
+
+	refund.Status = req.Status
+	if err := u.orderRepository.UpdateRefund(refund); err != nil {
+		return nil, err
+	}
+	return u.orderRepository.FindOneOrder(req.OrderId)
+}
+
+// CancelExpiredOrder cancels unpaid orders in batches until none is left or ctx is done
+func (u *orderUsecases) CancelExpiredOrder(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	const batch = 100
+
+	canceled := make([]string, 0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return canceled, err
+		}
+		orderIds, err := u.orderRepository.CancelExpiredOrder(ctx, olderThan, batch)
+		if err != nil {
+			return canceled, err
+		}
+		for _, orderId := range orderIds {
+			log.Printf("order %s canceled, not paid within %v\n", orderId, olderThan)
+		}
+		canceled = append(canceled, orderIds...)
+
+		if len(orderIds) < batch {
+			return canceled, nil
+		}
+	}
+}
+
+// slipTime returns the current time in shop local time, YYYY-MM-DD HH:MM:SS
+func slipTime() (string, error) {
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		return "", err
+	}
+	return time.Now().In(loc).Format("2006-01-02 15:04:05"), nil
+}
+
+func (u *orderUsecases) ExportOrder(req *orders.OrderFilter, format string, w io.Writer) error {
+	switch format {
+	case "csv":
+		return u.exportOrderCsv(req, w)
+	case "xlsx":
+		return u.exportOrderXlsx(req, w)
+	default:
+		return fmt.Errorf("export format %s is not supported", format)
+	}
+}
+
+func (u *orderUsecases) exportOrderCsv(req *orders.OrderFilter, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(orders.OrderExportHeader); err != nil {
+		return fmt.Errorf("write csv header failed: %v", err)
+	}
+
+	if err := u.orderRepository.ExportOrder(req, func(row *orders.OrderExportRow) error {
+		return writer.Write(row.CsvRecord())
+	}); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (u *orderUsecases) exportOrderXlsx(req *orders.OrderFilter, w io.Writer) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	// Stream writer spills rows to a temp file instead of keeping the whole sheet in memory
+	sheet := file.GetSheetName(0)
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return fmt.Errorf("create xlsx stream failed: %v", err)
+	}
+
+	header := make([]any, 0, len(orders.OrderExportHeader))
+	for _, h := range orders.OrderExportHeader {
+		header = append(header, h)
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return fmt.Errorf("write xlsx header failed: %v", err)
+	}
+
+	rowIndex := 2
+	if err := u.orderRepository.ExportOrder(req, func(row *orders.OrderExportRow) error {
+		cell, err := excelize.CoordinatesToCellName(1, rowIndex)
+		if err != nil {
+			return err
+		}
+		rowIndex++
+
+		return stream.SetRow(cell, row.XlsxRow())
+	}); err != nil {
+		return err
+	}
+
+	if err := stream.Flush(); err != nil {
+		return fmt.Errorf("flush xlsx stream failed: %v", err)
+	}
+	// WriteTo zips the sheets straight into w, the handler spools it to a temp file
+	if _, err := file.WriteTo(w); err != nil {
+		return fmt.Errorf("write xlsx failed: %v", err)
+	}
+	return nil
+}
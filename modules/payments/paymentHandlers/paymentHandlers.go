@@ -0,0 +1,107 @@
+package paymentHandlers
+
+import (
+	"errors"
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/payments"
+	"go_learn_project_rest_api/modules/payments/paymentUsecases"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type paymentHandlersErrCode string
+
+const (
+	createIntentErr       paymentHandlersErrCode = "payments-001"
+	webhookErr            paymentHandlersErrCode = "payments-002"
+	refundErr             paymentHandlersErrCode = "payments-003"
+	findPaymentByOrderErr paymentHandlersErrCode = "payments-004"
+)
+
+type IPaymentHandlers interface {
+	CreateIntent(fiber.Ctx) error
+	Webhook(fiber.Ctx) error
+	Refund(fiber.Ctx) error
+	FindPaymentByOrder(fiber.Ctx) error
+}
+
+type paymentHandlers struct {
+	cfg             config.IConfig
+	paymentUsecases paymentUsecases.IPaymentUsecases
+}
+
+func PaymentHandlers(cfg config.IConfig, paymentUsecases paymentUsecases.IPaymentUsecases) IPaymentHandlers {
+	return &paymentHandlers{
+		cfg:             cfg,
+		paymentUsecases: paymentUsecases,
+	}
+}
+
+func (h *paymentHandlers) CreateIntent(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	intent, err := h.paymentUsecases.CreateIntent(userId, orderId)
+	if err != nil {
+		code := fiber.ErrBadRequest.Code
+		if errors.Is(err, payments.ErrPendingPayment) {
+			code = fiber.ErrConflict.Code
+		}
+		return entities.NewResponse(c).Error(
+			code,
+			string(createIntentErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, intent).Res()
+}
+
+func (h *paymentHandlers) Webhook(c fiber.Ctx) error {
+	// The signature is computed over the raw body, so it must not be re-encoded
+	if err := h.paymentUsecases.HandleWebhook(c.Body(), c.Get("X-Signature")); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(webhookErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, nil).Res()
+}
+
+func (h *paymentHandlers) Refund(c fiber.Ctx) error {
+	req := new(payments.RefundReq)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(refundErr),
+			err.Error(),
+		).Res()
+	}
+	req.PaymentId = strings.Trim(c.Params("payment_id"), " ")
+
+	payment, err := h.paymentUsecases.Refund(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(refundErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, payment).Res()
+}
+
+func (h *paymentHandlers) FindPaymentByOrder(c fiber.Ctx) error {
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	result, err := h.paymentUsecases.FindPaymentByOrder(orderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findPaymentByOrderErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
@@ -0,0 +1,43 @@
+package paymentProviders
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/payments"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// bankTransfer is the manual flow, the back office confirms the transfer by calling the webhook
+type bankTransfer struct {
+	cfg config.IPaymentConfig
+}
+
+func newBankTransfer(cfg config.IPaymentConfig) IPaymentProvider {
+	return &bankTransfer{
+		cfg: cfg,
+	}
+}
+
+func (p *bankTransfer) Name() string { return string(BankTransfer) }
+
+func (p *bankTransfer) CreateIntent(payment *payments.Payment) (*payments.PaymentIntent, error) {
+	payment.ProviderRef = "BT" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:12])
+	return &payments.PaymentIntent{
+		Payment:      payment,
+		Instructions: fmt.Sprintf("transfer %.2f and put %s in the transfer note", payment.Amount, payment.ProviderRef),
+	}, nil
+}
+
+func (p *bankTransfer) VerifyWebhook(body []byte, signature string) (*payments.WebhookEvent, error) {
+	if err := verifySignature(p.cfg.WebhookSecret(), body, signature); err != nil {
+		return nil, err
+	}
+	return parseEvent(body)
+}
+
+// Refund of a bank transfer is paid back by staff, there is nothing to call
+func (p *bankTransfer) Refund(payment *payments.Payment, amount float64) error {
+	return nil
+}
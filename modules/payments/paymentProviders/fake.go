@@ -0,0 +1,55 @@
+package paymentProviders
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/modules/payments"
+	"sync"
+)
+
+// FakeProvider keeps everything in memory, it is meant for tests
+type FakeProvider struct {
+	mu         sync.Mutex
+	secret     []byte
+	seq        int
+	Refunds    map[string]float64
+	FailRefund bool
+}
+
+func NewFake(secret []byte) *FakeProvider {
+	return &FakeProvider{
+		secret:  secret,
+		Refunds: make(map[string]float64),
+	}
+}
+
+func (p *FakeProvider) Name() string { return string(Fake) }
+
+func (p *FakeProvider) CreateIntent(payment *payments.Payment) (*payments.PaymentIntent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seq++
+	payment.ProviderRef = fmt.Sprintf("FAKE%06d", p.seq)
+	return &payments.PaymentIntent{
+		Payment:     payment,
+		RedirectUrl: "https://fake-payment.local/pay/" + payment.ProviderRef,
+	}, nil
+}
+
+func (p *FakeProvider) VerifyWebhook(body []byte, signature string) (*payments.WebhookEvent, error) {
+	if err := verifySignature(p.secret, body, signature); err != nil {
+		return nil, err
+	}
+	return parseEvent(body)
+}
+
+func (p *FakeProvider) Refund(payment *payments.Payment, amount float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.FailRefund {
+		return fmt.Errorf("refund %s failed", payment.ProviderRef)
+	}
+	p.Refunds[payment.ProviderRef] += amount
+	return nil
+}
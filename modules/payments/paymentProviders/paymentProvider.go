@@ -0,0 +1,73 @@
+package paymentProviders
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/payments"
+)
+
+type ProviderType string
+
+const (
+	BankTransfer ProviderType = "bank_transfer"
+	Fake         ProviderType = "fake"
+)
+
+type IPaymentProvider interface {
+	Name() string
+	CreateIntent(*payments.Payment) (*payments.PaymentIntent, error)
+	VerifyWebhook(body []byte, signature string) (*payments.WebhookEvent, error)
+	Refund(payment *payments.Payment, amount float64) error
+}
+
+func PaymentProvider(providerType ProviderType, cfg config.IPaymentConfig) (IPaymentProvider, error) {
+	switch providerType {
+	case BankTransfer, "":
+		return newBankTransfer(cfg), nil
+	case Fake:
+		return NewFake(cfg.WebhookSecret()), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider")
+	}
+}
+
+// Sign is HMAC-SHA256 of the raw body in hex, providers send it in the X-Signature header
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifySignature(secret, body []byte, signature string) error {
+	if len(secret) == 0 {
+		return fmt.Errorf("webhook secret is not configured")
+	}
+	expected, err := hex.DecodeString(Sign(secret, body))
+	if err != nil {
+		return err
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, got) {
+		return fmt.Errorf("webhook signature is invalid")
+	}
+	return nil
+}
+
+func parseEvent(body []byte) (*payments.WebhookEvent, error) {
+	event := new(payments.WebhookEvent)
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, fmt.Errorf("unmarshal webhook failed: %v", err)
+	}
+	if event.ProviderRef == "" {
+		return nil, fmt.Errorf("provider_ref is empty")
+	}
+	if event.Status != payments.Succeeded && event.Status != payments.Failed {
+		return nil, fmt.Errorf("webhook status is invalid")
+	}
+	event.Payload = body
+	return event, nil
+}
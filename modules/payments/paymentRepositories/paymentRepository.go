@@ -0,0 +1,221 @@
+package paymentRepositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go_learn_project_rest_api/modules/payments"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const paymentColumns = `
+		"id",
+		"order_id",
+		"provider",
+		"provider_ref",
+		"amount",
+		"refunded_amount",
+		"status"::TEXT AS "status",
+		"review_reason",
+		"created_at"::TEXT AS "created_at",
+		"updated_at"::TEXT AS "updated_at"`
+
+type IPaymentRepository interface {
+	FindOnePayment(string) (*payments.Payment, error)
+	FindPaymentByRef(provider, providerRef string) (*payments.Payment, error)
+	FindPaymentByOrder(string) ([]*payments.Payment, error)
+	InsertPayment(*payments.Payment) (string, error)
+	ApplyWebhook(*payments.Payment, *payments.WebhookEvent) error
+	UpdateRefund(paymentId string, amount float64) error
+	ReleaseRefund(paymentId string, amount float64) error
+}
+
+type paymentRepository struct {
+	db *sqlx.DB
+}
+
+func PaymentRepository(db *sqlx.DB) IPaymentRepository {
+	return &paymentRepository{
+		db: db,
+	}
+}
+
+func (r *paymentRepository) FindOnePayment(paymentId string) (*payments.Payment, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM "payments"
+	WHERE "id" = $1;`, paymentColumns)
+
+	payment := new(payments.Payment)
+	if err := r.db.Get(payment, query, paymentId); err != nil {
+		return nil, fmt.Errorf("get payment failed: %v", err)
+	}
+	return payment, nil
+}
+
+func (r *paymentRepository) FindPaymentByRef(provider, providerRef string) (*payments.Payment, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM "payments"
+	WHERE "provider" = $1
+	AND "provider_ref" = $2;`, paymentColumns)
+
+	payment := new(payments.Payment)
+	if err := r.db.Get(payment, query, provider, providerRef); err != nil {
+		return nil, fmt.Errorf("get payment failed: %v", err)
+	}
+	return payment, nil
+}
+
+func (r *paymentRepository) FindPaymentByOrder(orderId string) ([]*payments.Payment, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM "payments"
+	WHERE "order_id" = $1
+	ORDER BY "created_at" DESC;`, paymentColumns)
+
+	result := make([]*payments.Payment, 0)
+	if err := r.db.Select(&result, query, orderId); err != nil {
+		return nil, fmt.Errorf("get payments failed: %v", err)
+	}
+	return result, nil
+}
+
+// InsertPayment adds a pending payment, an order that already has one gets ErrPendingPayment
+func (r *paymentRepository) InsertPayment(req *payments.Payment) (string, error) {
+	query := `
+	INSERT INTO "payments" (
+		"order_id",
+		"provider",
+		"provider_ref",
+		"amount"
+	)
+	VALUES
+	($1, $2, $3, $4)
+	ON CONFLICT ("order_id") WHERE "status" = 'pending' DO NOTHING
+		RETURNING "id";`
+
+	if err := r.db.QueryRowxContext(
+		context.Background(),
+		query,
+		req.OrderId,
+		req.Provider,
+		req.ProviderRef,
+		req.Amount,
+	).Scan(&req.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", payments.ErrPendingPayment
+		}
+		return "", fmt.Errorf("insert payment failed: %v", err)
+	}
+	return req.Id, nil
+}
+
+func (r *paymentRepository) ApplyWebhook(payment *payments.Payment, event *payments.WebhookEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// Only a pending payment moves, a replayed webhook changes nothing
+	query := `
+	UPDATE "payments" SET
+		"status" = $1::payment_status,
+		"payload" = $2
+	WHERE "id" = $3
+	AND "status" = 'pending';`
+
+	result, err := tx.ExecContext(ctx, query, event.Status, string(event.Payload), payment.Id)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update payment failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return nil
+	}
+
+	if event.Status == payments.Succeeded {
+		// A slip may be waiting for staff when the provider payment comes in, the payment settles the order anyway
+		query = `
+		UPDATE "orders" SET
+			"status" = 'paid'
+		WHERE "id" = $1
+		AND "status" IN ('waiting', 'pending_verification');`
+
+		result, err := tx.ExecContext(ctx, query, payment.OrderId)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("update order status failed: %v", err)
+		}
+
+		// The money is taken for an order that is canceled or already paid, it is kept for staff to refund
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			query = `
+			UPDATE "payments" p SET
+				"review_reason" = 'order was ' || o."status"::TEXT || ' when the payment succeeded'
+			FROM "orders" o
+			WHERE p."id" = $1
+			AND o."id" = p."order_id"
+			RETURNING p."review_reason";`
+
+			var reason string
+			if err := tx.GetContext(ctx, &reason, query, payment.Id); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("update payment review failed: %v", err)
+			}
+			log.Printf("payment %s needs review: %s\n", payment.Id, reason)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// UpdateRefund adds the amount to the refunded amount, only while the payment is succeeded and the total stays within the amount paid
+func (r *paymentRepository) UpdateRefund(paymentId string, amount float64) error {
+	query := `
+	UPDATE "payments" SET
+		"refunded_amount" = "refunded_amount" + $1,
+		"status" = CASE
+			WHEN "refunded_amount" + $1 >= "amount" - 0.009 THEN 'refunded'::payment_status
+			ELSE "status"
+		END
+	WHERE "id" = $2
+	AND "status" = 'succeeded'
+	AND "refunded_amount" + $1 <= "amount" + 0.009;`
+
+	result, err := r.db.ExecContext(context.Background(), query, amount, paymentId)
+	if err != nil {
+		return fmt.Errorf("update payment refund failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("payment has been changed, please try again")
+	}
+	return nil
+}
+
+// ReleaseRefund takes back a refund the provider did not make
+func (r *paymentRepository) ReleaseRefund(paymentId string, amount float64) error {
+	query := `
+	UPDATE "payments" SET
+		"refunded_amount" = GREATEST("refunded_amount" - $1, 0),
+		"status" = 'succeeded'
+	WHERE "id" = $2
+	AND "status" IN ('succeeded', 'refunded');`
+
+	if _, err := r.db.ExecContext(context.Background(), query, amount, paymentId); err != nil {
+		return fmt.Errorf("release payment refund failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package paymentUsecases
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/modules/orders/orderRepositories"
+	"go_learn_project_rest_api/modules/payments"
+	"go_learn_project_rest_api/modules/payments/paymentProviders"
+	"go_learn_project_rest_api/modules/payments/paymentRepositories"
+	"math"
+)
+
+type IPaymentUsecases interface {
+	CreateIntent(userId, orderId string) (*payments.PaymentIntent, error)
+	HandleWebhook(body []byte, signature string) error
+	Refund(*payments.RefundReq) (*payments.Payment, error)
+	FindPaymentByOrder(string) ([]*payments.Payment, error)
+}
+
+type paymentUsecases struct {
+	paymentRepository paymentRepositories.IPaymentRepository
+	orderRepository   orderRepositories.IOrderRepository
+	provider          paymentProviders.IPaymentProvider
+}
+
+func PaymentUsecases(paymentRepository paymentRepositories.IPaymentRepository, orderRepository orderRepositories.IOrderRepository, provider paymentProviders.IPaymentProvider) IPaymentUsecases {
+	return &paymentUsecases{
+		paymentRepository: paymentRepository,
+		orderRepository:   orderRepository,
+		provider:          provider,
+	}
+}
+
+func (u *paymentUsecases) CreateIntent(userId, orderId string) (*payments.PaymentIntent, error) {
+	order, err := u.orderRepository.FindOneOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userId {
+		return nil, fmt.Errorf("order not found")
+	}
+	if order.Status != "waiting" {
+		return nil, fmt.Errorf("order status %s can not be paid", order.Status)
+	}
+
+	// Checked before the provider is called, the insert still refuses a second one made at the same time
+	existing, err := u.paymentRepository.FindPaymentByOrder(order.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range existing {
+		if p.Status == payments.Pending {
+			return nil, payments.ErrPendingPayment
+		}
+	}
+
+	payment := &payments.Payment{
+		OrderId:  order.Id,
+		Provider: u.provider.Name(),
+		Amount:   math.Round(order.TotalPaid*100) / 100,
+		Status:   payments.Pending,
+	}
+	intent, err := u.provider.CreateIntent(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := u.paymentRepository.InsertPayment(payment); err != nil {
+		return nil, err
+	}
+
+	intent.Payment, err = u.paymentRepository.FindOnePayment(payment.Id)
+	if err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
+
+func (u *paymentUsecases) HandleWebhook(body []byte, signature string) error {
+	event, err := u.provider.VerifyWebhook(body, signature)
+	if err != nil {
+		return err
+	}
+
+	payment, err := u.paymentRepository.FindPaymentByRef(u.provider.Name(), event.ProviderRef)
+	if err != nil {
+		return err
+	}
+
+	status, ok := payments.WebhookStatus(payment, event)
+	if !ok {
+		return nil
+	}
+	event.Status = status
+	return u.paymentRepository.ApplyWebhook(payment, event)
+}
+
+// Refund takes the amount off the payment before calling the provider, so two refunds at once can not
+// give back more than was paid, and gives it back when the provider fails
+func (u *paymentUsecases) Refund(req *payments.RefundReq) (*payments.Payment, error) {
+	payment, err := u.paymentRepository.FindOnePayment(req.PaymentId)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := payments.RefundAmount(payment, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.paymentRepository.UpdateRefund(payment.Id, amount); err != nil {
+		return nil, err
+	}
+	if err := u.provider.Refund(payment, amount); err != nil {
+		if releaseErr := u.paymentRepository.ReleaseRefund(payment.Id, amount); releaseErr != nil {
+			return nil, fmt.Errorf("%v, release refund failed: %v", err, releaseErr)
+		}
+		return nil, err
+	}
+	req.Amount = amount
+	return u.paymentRepository.FindOnePayment(payment.Id)
+}
+
+func (u *paymentUsecases) FindPaymentByOrder(orderId string) ([]*payments.Payment, error) {
+	return u.paymentRepository.FindPaymentByOrder(orderId)
+}
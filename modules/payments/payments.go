@@ -0,0 +1,77 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	Pending   = "pending"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+	Refunded  = "refunded"
+)
+
+// ErrPendingPayment is returned when the order already has a payment waiting for the provider
+var ErrPendingPayment = errors.New("order already has a pending payment")
+
+type Payment struct {
+	Id             string  `db:"id" json:"id"`
+	OrderId        string  `db:"order_id" json:"order_id"`
+	Provider       string  `db:"provider" json:"provider"`
+	ProviderRef    string  `db:"provider_ref" json:"provider_ref"`
+	Amount         float64 `db:"amount" json:"amount"`
+	RefundedAmount float64 `db:"refunded_amount" json:"refunded_amount"`
+	Status         string  `db:"status" json:"status"`
+	ReviewReason   *string `db:"review_reason" json:"review_reason"` // why staff must look at a succeeded payment, nil when nothing is wrong
+	CreatedAt      string  `db:"created_at" json:"created_at"`
+	UpdatedAt      string  `db:"updated_at" json:"updated_at"`
+}
+
+// PaymentIntent is what the customer needs to pay a payment
+type PaymentIntent struct {
+	Payment      *Payment `json:"payment"`
+	Instructions string   `json:"instructions"`
+	RedirectUrl  string   `json:"redirect_url"`
+}
+
+// WebhookEvent is the provider notification after the signature is verified
+type WebhookEvent struct {
+	ProviderRef string  `json:"provider_ref"`
+	Status      string  `json:"status"`
+	Amount      float64 `json:"amount"`
+	Payload     []byte  `json:"-"`
+}
+
+type RefundReq struct {
+	PaymentId string  `json:"payment_id"`
+	Amount    float64 `json:"amount"`
+}
+
+// WebhookStatus gives the status a webhook moves the payment to, false when it changes nothing.
+// Only a pending payment moves, so a replayed webhook is ignored, and a success with the wrong amount is not a payment of this order
+func WebhookStatus(payment *Payment, event *WebhookEvent) (string, bool) {
+	if payment.Status != Pending {
+		return payment.Status, false
+	}
+	if event.Status == Succeeded && math.Abs(event.Amount-payment.Amount) > 0.009 {
+		return Failed, true
+	}
+	return event.Status, true
+}
+
+// RefundAmount checks the amount against what is left to refund, 0 refunds all of it
+func RefundAmount(payment *Payment, amount float64) (float64, error) {
+	if payment.Status != Succeeded {
+		return 0, fmt.Errorf("payment status %s can not be refunded", payment.Status)
+	}
+	remaining := math.Round((payment.Amount-payment.RefundedAmount)*100) / 100
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining+0.009 {
+		return 0, fmt.Errorf("refund amount must be between 0 and %.2f", remaining)
+	}
+	return math.Round(amount*100) / 100, nil
+}
@@ -1,24 +1,377 @@
 package products
 
 import (
+	"fmt"
 	"go_learn_project_rest_api/modules/appInfo"
 	"go_learn_project_rest_api/modules/entities"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
+const (
+	SearchFullText = "fulltext"
+	SearchContains = "contains"
+)
+
+const (
+	ProductDraft     = "draft"
+	ProductPublished = "published"
+	ProductArchived  = "archived"
+)
+
+func IsProductStatus(status string) bool {
+	return status == ProductDraft || status == ProductPublished || status == ProductArchived
+}
+
 type Product struct {
-	Id          string            `json:"id"`
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	Category    *appInfo.Category `json:"category"`
-	CreatedAt   string            `json:"created_at"`
-	UpdatedAt   string            `json:"updated_at"`
-	Price       float64           `json:"price"`
-	Images      []*entities.Image `json:"images"`
+	Id           string              `json:"id"`
+	ExternalSku  string              `json:"external_sku"`
+	Title        string              `json:"title"`
+	Description  string              `json:"description"`
+	Category     *appInfo.Category   `json:"category"`   // first of Categories by sort order
+	Categories   []*appInfo.Category `json:"categories"` // nil keeps the categories on update
+	CreatedAt    string              `json:"created_at"`
+	UpdatedAt    string              `json:"updated_at"`
+	Version      int                 `json:"version"`       // the ETag, an update has to send it as If-Match
+	Price        float64             `json:"price"`         // sells for now, a running scheduled price or the regular one. Writes set the regular price
+	RegularPrice float64             `json:"regular_price"` // the price without schedules
+	TaxMode      string              `json:"tax_mode"`
+	Stock        *int                `json:"stock"`
+	Status       string              `json:"status"`
+	PublishAt    *string             `json:"publish_at"` // nil keeps it on update, an empty string clears it
+	Visible      bool                `json:"visible"`    // published and past publish_at
+	Rating       float64             `json:"rating"`     // average of the visible reviews, 0 without reviews
+	ReviewCount  int                 `json:"review_count"`
+	Images       []*entities.Image   `json:"images"`
+	Options      []*Option           `json:"options"`           // nil keeps the options on update
+	Variants     []*Variant          `json:"variants"`          // nil keeps the variants on update
+	Rank         float64             `json:"rank,omitempty"`    // search relevance
+	Snippet      string              `json:"snippet,omitempty"` // search match, highlighted with <mark>
+}
+
+// Option is an option type of a product, e.g. Size with the values S, M and L
+type Option struct {
+	Id     string   `json:"id"`
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// Variant is a sellable combination of option values, Options maps an option name to one of its values
+type Variant struct {
+	Id        string            `json:"id"`
+	Sku       string            `json:"sku"`
+	Options   map[string]string `json:"options"`
+	Price     *float64          `json:"price"` // nil uses the product price
+	Stock     *int              `json:"stock"` // nil is not stock tracked
+	Images    []*entities.Image `json:"images"`
+	CreatedAt string            `json:"created_at,omitempty"`
+	UpdatedAt string            `json:"updated_at,omitempty"`
+}
+
+// PriceOf gives the price the variant is sold at
+func (v *Variant) PriceOf(p *Product) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return p.Price
+}
+
+// Label names the variant by its option values, e.g. Color: Red, Size: M
+func (v *Variant) Label() string {
+	names := make([]string, 0, len(v.Options))
+	for name := range v.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	labels := make([]string, 0, len(names))
+	for _, name := range names {
+		labels = append(labels, name+": "+v.Options[name])
+	}
+	return strings.Join(labels, ", ")
+}
+
+// FindVariant gives the variant with the id, nil if the product has none
+func (p *Product) FindVariant(variantId string) *Variant {
+	for _, v := range p.Variants {
+		if v.Id == variantId {
+			return v
+		}
+	}
+	return nil
+}
+
+// CategoryIds joins Category and Categories without duplicates, an empty result keeps the categories on update
+func (p *Product) CategoryIds() []int {
+	ids := make([]int, 0)
+	seen := make(map[int]bool)
+	add := func(c *appInfo.Category) {
+		if c == nil || c.Id <= 0 || seen[c.Id] {
+			return
+		}
+		seen[c.Id] = true
+		ids = append(ids, c.Id)
+	}
+
+	add(p.Category)
+	for _, c := range p.Categories {
+		add(c)
+	}
+	return ids
+}
+
+// ValidateVariants checks that every variant picks one value of every option, with no two variants alike
+func ValidateVariants(options []*Option, variants []*Variant) error {
+	values := make(map[string]map[string]bool)
+	for _, o := range options {
+		o.Name = strings.TrimSpace(o.Name)
+		if o.Name == "" {
+			return fmt.Errorf("option name is required")
+		}
+		if values[o.Name] != nil {
+			return fmt.Errorf("option %s is duplicated", o.Name)
+		}
+		if len(o.Values) == 0 {
+			return fmt.Errorf("option %s has no values", o.Name)
+		}
+		values[o.Name] = make(map[string]bool)
+		for _, value := range o.Values {
+			values[o.Name][value] = true
+		}
+	}
+
+	skus := make(map[string]bool)
+	combinations := make(map[string]bool)
+	for _, v := range variants {
+		v.Sku = strings.TrimSpace(v.Sku)
+		if v.Sku == "" {
+			return fmt.Errorf("variant sku is required")
+		}
+		if skus[v.Sku] {
+			return fmt.Errorf("sku %s is duplicated", v.Sku)
+		}
+		skus[v.Sku] = true
+
+		if v.Price != nil && *v.Price < 0 {
+			return fmt.Errorf("price of %s must not be negative", v.Sku)
+		}
+		if v.Stock != nil && *v.Stock < 0 {
+			return fmt.Errorf("stock of %s must not be negative", v.Sku)
+		}
+
+		if len(v.Options) != len(values) {
+			return fmt.Errorf("variant %s must pick one value of every option", v.Sku)
+		}
+		for name, value := range v.Options {
+			if !values[name][value] {
+				return fmt.Errorf("variant %s has an invalid %s", v.Sku, name)
+			}
+		}
+		if combinations[v.Label()] {
+			return fmt.Errorf("variant %s duplicates %s", v.Sku, v.Label())
+		}
+		combinations[v.Label()] = true
+	}
+	return nil
 }
 
 type ProductFilter struct {
-	Id     string `query:"id"`
-	Search string `query:"search"`
+	Id          string  `query:"id"`
+	Search      string  `query:"search"`
+	SearchMode  string  `query:"search_mode"`  // fulltext, contains
+	CategoryIds string  `query:"category_ids"` // comma separated, 1,2,3
+	MinPrice    float64 `query:"min_price"`
+	MaxPrice    float64 `query:"max_price"`
+	StartDate   string  `query:"start_date"` // created_at, YYYY-MM-DD
+	EndDate     string  `query:"end_date"`
+	InStock     bool    `query:"in_stock"`
+	Facets      bool    `query:"facets"` // counts the categories and prices too
+	Status      string  `query:"status"` // admin listing only
+	Categories  []int   `query:"-"`      // parsed from CategoryIds
+	Admin       bool    `query:"-"`      // lists every status, otherwise only visible products
 	*entities.PaginationReq
 	*entities.SortReq
+	*entities.CursorReq
+}
+
+// CursorValue is the value of the sort key a cursor continues from, it must compare equal once cast back in SQL
+func (p *Product) CursorValue(orderBy string) string {
+	switch orderBy {
+	case "id":
+		return p.Id
+	case "price":
+		return strconv.FormatFloat(p.Price, 'g', -1, 64)
+	case "created_at":
+		return p.CreatedAt
+	case "rating":
+		return strconv.FormatFloat(p.Rating, 'g', -1, 64)
+	case "relevance":
+		// ts_rank_cd and similarity give REAL
+		return strconv.FormatFloat(p.Rank, 'g', -1, 32)
+	default:
+		return p.Title
+	}
+}
+
+// PriceBuckets are the lower bounds of the price facets, the last bucket has no upper bound
+var PriceBuckets = []float64{0, 100, 500, 1000, 5000}
+
+type CategoryFacet struct {
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+	Count int    `json:"count"`
+}
+
+type PriceFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int      `json:"count"`
+}
+
+// ProductFacets are counted over the filtered products, a facet ignores its own filter so the other options stay visible
+type ProductFacets struct {
+	Categories []*CategoryFacet `json:"categories"`
+	Prices     []*PriceFacet    `json:"prices"`
+}
+
+type ProductPaginateRes struct {
+	*entities.PaginateRes
+	Facets *ProductFacets `json:"facets,omitempty"` // only with facets
+}
+
+type ProductCursorRes struct {
+	*entities.CursorPaginateRes
+	Facets *ProductFacets `json:"facets,omitempty"` // only with facets, skipped with skip_count
+}
+
+// ResolveSearchMode defaults to full text search, Thai can not be tokenized so it falls back to contains
+func (f *ProductFilter) ResolveSearchMode() {
+	f.Search = strings.TrimSpace(f.Search)
+	if f.SearchMode != SearchContains {
+		f.SearchMode = SearchFullText
+	}
+	if f.SearchMode == SearchFullText && strings.IndexFunc(f.Search, func(r rune) bool { return unicode.Is(unicode.Thai, r) }) >= 0 {
+		f.SearchMode = SearchContains
+	}
+}
+
+const (
+	ImportTransactional = "transactional" // nothing is written if a row fails
+	ImportPartial       = "partial"       // valid rows are written, failed rows are reported
+)
+
+// ProductCsvHeader is shared by import and export, so an export can be imported back
+var ProductCsvHeader = []string{"external_sku", "title", "description", "price", "category", "image_urls", "status", "id"}
+
+// ImportRow is a product row of a CSV import, Categories are category ids or slugs and replace the categories of the product.
+// A row with an Id updates that product, so products without an external sku can be exported and imported back,
+// otherwise the row is matched by its external sku. An empty Status publishes a new product and keeps the status of an existing one
+type ImportRow struct {
+	Line        int
+	Id          string
+	ExternalSku string
+	Title       string
+	Description string
+	Price       float64
+	Categories  []string
+	ImageUrls   []string
+	Status      string
+}
+
+type ImportRowError struct {
+	Line        int    `json:"line"`
+	ExternalSku string `json:"external_sku"`
+	Error       string `json:"error"`
+}
+
+type ImportReq struct {
+	Mode   string
+	Rows   []*ImportRow
+	Errors []*ImportRowError // rows that failed to parse, they are never written
+}
+
+type ImportRes struct {
+	Mode      string            `json:"mode"`
+	Committed bool              `json:"committed"`
+	Total     int               `json:"total"`
+	Inserted  int               `json:"inserted"`
+	Updated   int               `json:"updated"`
+	Failed    int               `json:"failed"`
+	Errors    []*ImportRowError `json:"errors"`
+}
+
+// ProductExportRow is a product in ProductCsvHeader order, Categories are slugs and Categories and ImageUrls are separated by |
+type ProductExportRow struct {
+	ExternalSku string  `db:"external_sku"`
+	Title       string  `db:"title"`
+	Description string  `db:"description"`
+	Price       float64 `db:"price"`
+	Categories  string  `db:"categories"`
+	ImageUrls   string  `db:"image_urls"`
+	Status      string  `db:"status"`
+	Id          string  `db:"id"`
+}
+
+const (
+	ScheduleUpcoming = "upcoming"
+	ScheduleActive   = "active"
+	ScheduleEnded    = "ended"
+)
+
+// PriceChange is a change of the regular price, PreviousPrice is nil for the first price
+type PriceChange struct {
+	Id            string   `db:"id" json:"id"`
+	Price         float64  `db:"price" json:"price"`
+	PreviousPrice *float64 `db:"previous_price" json:"previous_price"`
+	ChangedAt     string   `db:"created_at" json:"changed_at"`
+}
+
+// ScheduledPrice replaces the regular price from StartsAt until EndsAt, a nil EndsAt runs until it is removed.
+// Times are written in RFC 3339, e.g. 2025-01-31T09:00:00+07:00
+type ScheduledPrice struct {
+	Id        string  `db:"id" json:"id"`
+	ProductId string  `db:"product_id" json:"product_id"`
+	Price     float64 `db:"price" json:"price"`
+	StartsAt  string  `db:"starts_at" json:"starts_at"`
+	EndsAt    *string `db:"ends_at" json:"ends_at"`
+	Status    string  `db:"status" json:"status"` // upcoming, active, ended
+	CreatedAt string  `db:"created_at" json:"created_at"`
+}
+
+func (s *ScheduledPrice) Validate() error {
+	if s.Price < 0 {
+		return fmt.Errorf("price must not be negative")
+	}
+	startsAt, err := time.Parse(time.RFC3339, s.StartsAt)
+	if err != nil {
+		return fmt.Errorf("starts_at is invalid")
+	}
+	if s.EndsAt != nil && *s.EndsAt == "" {
+		s.EndsAt = nil
+	}
+	if s.EndsAt != nil {
+		endsAt, err := time.Parse(time.RFC3339, *s.EndsAt)
+		if err != nil {
+			return fmt.Errorf("ends_at is invalid")
+		}
+		if !endsAt.After(startsAt) {
+			return fmt.Errorf("ends_at must be after starts_at")
+		}
+		if !endsAt.After(time.Now()) {
+			return fmt.Errorf("ends_at must be in the future")
+		}
+	}
+	return nil
+}
+
+// PriceTimeline is the price history of a product with its scheduled prices, the newest first
+type PriceTimeline struct {
+	ProductId    string            `json:"product_id"`
+	Price        float64           `json:"price"`
+	RegularPrice float64           `json:"regular_price"`
+	History      []*PriceChange    `json:"history"`
+	Schedules    []*ScheduledPrice `json:"schedules"`
 }
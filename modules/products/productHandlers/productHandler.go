@@ -1,6 +1,8 @@
 package productHandlers
 
 import (
+	"errors"
+	"fmt"
 	"go_learn_project_rest_api/config"
 	"go_learn_project_rest_api/modules/appInfo"
 	"go_learn_project_rest_api/modules/entities"
@@ -8,7 +10,12 @@ import (
 	"go_learn_project_rest_api/modules/files/fileUsecases"
 	"go_learn_project_rest_api/modules/products"
 	"go_learn_project_rest_api/modules/products/productUsecases"
+	"go_learn_project_rest_api/modules/taxes"
+	"go_learn_project_rest_api/pkgs/utils"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -21,14 +28,25 @@ const (
 	insertProductErr  productsHandlersErrCode = "products-003"
 	updateProductErr  productsHandlersErrCode = "products-004"
 	deleteProductErr  productsHandlersErrCode = "products-005"
+	importProductErr  productsHandlersErrCode = "products-006"
+	findPriceErr      productsHandlersErrCode = "products-007"
+	schedulePriceErr  productsHandlersErrCode = "products-008"
+	exportProductErr  productsHandlersErrCode = "products-009"
 )
 
 type IProductHandler interface {
 	FindOneProduct(fiber.Ctx) error
+	FindOneProductAdmin(fiber.Ctx) error
 	FindProduct(fiber.Ctx) error
+	FindProductAdmin(fiber.Ctx) error
 	AddProduct(fiber.Ctx) error
 	UpdateProduct(fiber.Ctx) error
 	DeleteProduct(fiber.Ctx) error
+	ImportProduct(fiber.Ctx) error
+	ExportProduct(fiber.Ctx) error
+	FindPriceTimeline(fiber.Ctx) error
+	InsertScheduledPrice(fiber.Ctx) error
+	DeleteScheduledPrice(fiber.Ctx) error
 }
 
 type productHandler struct {
@@ -45,7 +63,16 @@ func ProductHandler(productUsecase productUsecases.IProductUsecases, cfg config.
 	}
 }
 
+// FindOneProduct only gives a visible product, drafts, archived and scheduled products are not found
 func (h *productHandler) FindOneProduct(c fiber.Ctx) error {
+	return h.findOneProduct(c, false)
+}
+
+func (h *productHandler) FindOneProductAdmin(c fiber.Ctx) error {
+	return h.findOneProduct(c, true)
+}
+
+func (h *productHandler) findOneProduct(c fiber.Ctx, admin bool) error {
 	productId := strings.Trim(c.Params("product_id"), " ")
 
 	product, err := h.productUsecase.FindOneProduct(productId)
@@ -56,13 +83,32 @@ func (h *productHandler) FindOneProduct(c fiber.Ctx) error {
 			err.Error(),
 		).Res()
 	}
+	if !admin && !product.Visible {
+		return entities.NewResponse(c).Error(
+			fiber.StatusNotFound,
+			string(findOneProductErr),
+			"product not found",
+		).Res()
+	}
+	c.Set(fiber.HeaderETag, entities.ETag(product.Version))
 	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, product).Res()
 }
 
+// FindProduct only lists visible products
 func (h *productHandler) FindProduct(c fiber.Ctx) error {
+	return h.findProduct(c, false)
+}
+
+// FindProductAdmin lists products of every status, status narrows them down
+func (h *productHandler) FindProductAdmin(c fiber.Ctx) error {
+	return h.findProduct(c, true)
+}
+
+func (h *productHandler) findProduct(c fiber.Ctx, admin bool) error {
 	req := &products.ProductFilter{
 		PaginationReq: &entities.PaginationReq{},
 		SortReq:       &entities.SortReq{},
+		CursorReq:     &entities.CursorReq{},
 	}
 
 	if err := c.Bind().Query(req); err != nil {
@@ -72,6 +118,17 @@ func (h *productHandler) FindProduct(c fiber.Ctx) error {
 			err.Error(),
 		).Res()
 	}
+	req.Admin = admin
+	if !admin {
+		req.Status = ""
+	}
+	if req.Status != "" && !products.IsProductStatus(req.Status) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findProductErr),
+			"status is invalid",
+		).Res()
+	}
 	if req.Page < 1 {
 		req.Page = 1
 	}
@@ -79,11 +136,79 @@ func (h *productHandler) FindProduct(c fiber.Ctx) error {
 		req.Limit = 5
 	}
 
+	req.ResolveSearchMode()
+
+	// Filters
+	for _, id := range strings.Split(req.CategoryIds, ",") {
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+		categoryId, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil || categoryId < 1 {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findProductErr),
+				"category_ids is invalid",
+			).Res()
+		}
+		req.Categories = append(req.Categories, categoryId)
+	}
+	if req.MinPrice < 0 || req.MaxPrice < 0 || (req.MaxPrice > 0 && req.MinPrice > req.MaxPrice) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findProductErr),
+			"price range is invalid",
+		).Res()
+	}
+	// Date	YYYY-MM-DD
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findProductErr),
+				"date is invalid",
+			).Res()
+		}
+	}
+
+	// A search ranks by relevance unless the client asks otherwise
 	if req.OrderBy == "" {
 		req.OrderBy = "title"
+		if req.Search != "" {
+			req.OrderBy = "relevance"
+		}
+	}
+	orderByMap := map[string]bool{
+		"id":         true,
+		"title":      true,
+		"price":      true,
+		"created_at": true,
+		"rating":     true,
+		"relevance":  req.Search != "",
 	}
-	if req.Sort == "" {
+	if !orderByMap[req.OrderBy] {
+		req.OrderBy = "title"
+	}
+	req.Sort = strings.ToUpper(req.Sort)
+	if req.Sort != "ASC" && req.Sort != "DESC" {
 		req.Sort = "ASC"
+		if req.OrderBy == "relevance" || req.OrderBy == "rating" {
+			req.Sort = "DESC"
+		}
+	}
+
+	if req.IsCursor() {
+		if err := req.Decode(req.SortReq); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(findProductErr),
+				err.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, h.productUsecase.FindProductCursor(req)).Res()
 	}
 
 	products := h.productUsecase.FindProduct(req)
@@ -102,13 +227,34 @@ func (h *productHandler) AddProduct(c fiber.Ctx) error {
 			err.Error(),
 		).Res()
 	}
-	if req.Category.Id <= 0 {
+	if len(req.CategoryIds()) == 0 {
 		return entities.NewResponse(c).Error(
 			fiber.StatusBadRequest,
 			string(insertProductErr),
 			"category id is invalid",
 		).Res()
 	}
+	if req.TaxMode != "" && !taxes.IsMode(req.TaxMode) {
+		return entities.NewResponse(c).Error(
+			fiber.StatusBadRequest,
+			string(insertProductErr),
+			"tax mode is invalid",
+		).Res()
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.StatusBadRequest,
+			string(insertProductErr),
+			"stock must not be negative",
+		).Res()
+	}
+	if err := validateStatus(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.StatusBadRequest,
+			string(insertProductErr),
+			err.Error(),
+		).Res()
+	}
 
 	product, err := h.productUsecase.AddProduct(req)
 	if err != nil {
@@ -136,17 +282,60 @@ func (h *productHandler) UpdateProduct(c fiber.Ctx) error {
 	}
 	req.Id = productId
 
-	product, err := h.productUsecase.UpdateProduct(req)
+	if req.TaxMode != "" && !taxes.IsMode(req.TaxMode) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			"tax mode is invalid",
+		).Res()
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			"stock must not be negative",
+		).Res()
+	}
+	if err := validateStatus(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	// The update only goes through on the version the client read
+	version, err := entities.ParseIfMatch(c.Get(fiber.HeaderIfMatch))
 	if err != nil {
+		code := fiber.StatusBadRequest
+		if errors.Is(err, entities.ErrIfMatchRequired) {
+			code = fiber.StatusPreconditionRequired
+		}
 		return entities.NewResponse(c).Error(
-			fiber.ErrInternalServerError.Code,
+			code,
 			string(updateProductErr),
 			err.Error(),
 		).Res()
 	}
+	req.Version = version
+
+	product, err := h.productUsecase.UpdateProduct(req)
+	if err != nil {
+		code, msg := fiber.StatusInternalServerError, err.Error()
+		if errors.Is(err, entities.ErrVersionConflict) {
+			code, msg = fiber.StatusPreconditionFailed, "product "+msg
+		}
+		return entities.NewResponse(c).Error(
+			code,
+			string(updateProductErr),
+			msg,
+		).Res()
+	}
+	c.Set(fiber.HeaderETag, entities.ETag(product.Version))
 	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, product).Res()
 }
 
+// DeleteProduct archives a product that was ordered, its images are kept for the archived product
 func (h *productHandler) DeleteProduct(c fiber.Ctx) error {
 	req := strings.Trim(c.Params("product_id"), "")
 
@@ -159,6 +348,20 @@ func (h *productHandler) DeleteProduct(c fiber.Ctx) error {
 		).Res()
 	}
 
+	archived, err := h.productUsecase.DeleteProduct(product.Id)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(deleteProductErr),
+			err.Error(),
+		).Res()
+	}
+	if archived {
+		product.Status = products.ProductArchived
+		product.Visible = false
+		return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, product).Res()
+	}
+
 	deleteFileReq := make([]*files.DeleteFileReq, 0)
 	for _, image := range product.Images {
 		deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
@@ -166,6 +369,14 @@ func (h *productHandler) DeleteProduct(c fiber.Ctx) error {
 			FileName:    image.FileName,
 		})
 	}
+	for _, variant := range product.Variants {
+		for _, image := range variant.Images {
+			deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
+				Destination: "images/test/",
+				FileName:    image.FileName,
+			})
+		}
+	}
 
 	if err := h.fileUsecases.DeleteFileOnGCP(deleteFileReq); err != nil {
 		return entities.NewResponse(c).Error(
@@ -174,13 +385,140 @@ func (h *productHandler) DeleteProduct(c fiber.Ctx) error {
 			err.Error(),
 		).Res()
 	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusNoContent, nil).Res()
+}
 
-	if err := h.productUsecase.DeleteProduct(product.Id); err != nil {
+// validateStatus checks the status and the publish time, publish_at takes RFC 3339, e.g. 2025-01-31T09:00:00+07:00
+func validateStatus(req *products.Product) error {
+	if req.Status != "" && !products.IsProductStatus(req.Status) {
+		return fmt.Errorf("status must be draft, published or archived")
+	}
+	if req.PublishAt != nil && *req.PublishAt != "" {
+		if _, err := time.Parse(time.RFC3339, *req.PublishAt); err != nil {
+			return fmt.Errorf("publish_at is invalid")
+		}
+	}
+	return nil
+}
+
+func (h *productHandler) ImportProduct(c fiber.Ctx) error {
+	modeMap := map[string]string{
+		products.ImportTransactional: products.ImportTransactional,
+		products.ImportPartial:       products.ImportPartial,
+	}
+	mode := modeMap[strings.ToLower(c.Query("mode", products.ImportTransactional))]
+	if mode == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importProductErr),
+			"mode is invalid",
+		).Res()
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importProductErr),
+			err.Error(),
+		).Res()
+	}
+	if !strings.EqualFold(filepath.Ext(file.Filename), ".csv") {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importProductErr),
+			"file must be a csv",
+		).Res()
+	}
+
+	f, err := file.Open()
+	if err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
-			string(deleteProductErr),
+			string(importProductErr),
 			err.Error(),
 		).Res()
 	}
-	return entities.NewResponse(c).SuccessResponse(fiber.StatusNoContent, nil).Res()
+	defer f.Close()
+
+	res, err := h.productUsecase.ImportProductCsv(f, mode)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(importProductErr),
+			err.Error(),
+		).Res()
+	}
+	// The report tells which rows failed, nothing was written when it is not committed
+	if !res.Committed {
+		return entities.NewResponse(c).SuccessResponse(fiber.StatusUnprocessableEntity, res).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, res).Res()
+}
+
+func (h *productHandler) ExportProduct(c fiber.Ctx) error {
+	// The file is written out first, a failed export is answered with an error instead of a cut file
+	f, size, err := utils.SpoolFile(h.productUsecase.ExportProductCsv)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(exportProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	c.Attachment(fmt.Sprintf("products_%s.csv", time.Now().Format("20060102150405")))
+	return c.SendStream(f, int(size))
+}
+
+// FindPriceTimeline gives the regular price changes and the scheduled prices of a product
+func (h *productHandler) FindPriceTimeline(c fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	result, err := h.productUsecase.FindPriceTimeline(productId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findPriceErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *productHandler) InsertScheduledPrice(c fiber.Ctx) error {
+	req := new(products.ScheduledPrice)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(schedulePriceErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+
+	result, err := h.productUsecase.InsertScheduledPrice(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(schedulePriceErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, result).Res()
+}
+
+func (h *productHandler) DeleteScheduledPrice(c fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+	scheduleId := strings.Trim(c.Params("schedule_id"), " ")
+
+	result, err := h.productUsecase.DeleteScheduledPrice(productId, scheduleId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(schedulePriceErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
 }
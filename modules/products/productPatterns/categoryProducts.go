@@ -0,0 +1,33 @@
+package productPatterns
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ReplaceCategories writes the categories of a product, the ones left out are removed
+func ReplaceCategories(ctx context.Context, tx *sqlx.Tx, productId string, categoryIds []int) error {
+	query := `
+	DELETE FROM "products_categories"
+	WHERE "product_id" = $1
+	AND NOT ("category_id" = ANY($2));`
+	if _, err := tx.ExecContext(ctx, query, productId, categoryIds); err != nil {
+		return fmt.Errorf("delete products_categories failed: %v", err)
+	}
+
+	query = `
+	INSERT INTO "products_categories" (
+		"product_id",
+		"category_id"
+	)
+	SELECT
+		$1,
+		UNNEST($2::INT[])
+	ON CONFLICT ("product_id", "category_id") DO NOTHING;`
+	if _, err := tx.ExecContext(ctx, query, productId, categoryIds); err != nil {
+		return fmt.Errorf("insert products_categories failed: %v", err)
+	}
+	return nil
+}
@@ -21,10 +21,17 @@ type IFindProductBuilder interface {
 	whereQuery()
 	sort()
 	paginate()
+	seek()
+	seekLimit()
 	closeJsonQuery()
+	categoryFacetQuery()
+	closeCategoryFacetQuery()
+	priceFacetQuery()
 	resetQuery()
 	Result() []*products.Product
 	Count() int
+	CategoryFacets() []*products.CategoryFacet
+	PriceFacets() []*products.PriceFacet
 	PrintQuery()
 }
 
@@ -34,8 +41,15 @@ type findProductBuilder struct {
 	query          string
 	lastStackIndex int
 	values         []any
+	facet          string // the facet being counted, its own filter is left out
+	rankExpr       string // relevance of the search, set by searchSelect
 }
 
+const (
+	facetCategory = "category"
+	facetPrice    = "price"
+)
+
 func FindProductBuilder(db *sqlx.DB, req *products.ProductFilter) IFindProductBuilder {
 	return &findProductBuilder{
 		db:  db,
@@ -54,22 +68,64 @@ func (b *findProductBuilder) initQuery() {
 	b.query += `
             SELECT
                 p.id,
+                p.external_sku,
                 p.title,
                 p.description,
-                p.price,
+                product_current_price(p.id, p.price) AS price,
+                p.price AS regular_price,
+                p.tax_mode,
+                p.stock,
+                p.status,
+                p.publish_at,
+                (p.status = 'published' AND COALESCE(p.publish_at <= now(), TRUE)) AS visible,
+                (
+                    SELECT
+                        COALESCE(ROUND(AVG(r.rating), 2), 0)::FLOAT
+                    FROM reviews r
+                    WHERE r.product_id = p.id
+                    AND NOT r.is_hidden
+                ) AS rating,
+                (
+                    SELECT
+                        COUNT(*)
+                    FROM reviews r
+                    WHERE r.product_id = p.id
+                    AND NOT r.is_hidden
+                ) AS review_count,
                 (
                     SELECT
                         to_jsonb(ct)
                     FROM (
                         SELECT 
                             c.id,
-                            c.title
-                        FROM categories c LEFT JOIN products_categories pc ON pc.category_id = c.id
+                            c.title,
+                            c.slug,
+                            c.parent_id,
+                            c.sort_order
+                        FROM categories c JOIN products_categories pc ON pc.category_id = c.id
                         WHERE pc.product_id = p.id
+                        ORDER BY c.sort_order, c.id
+                        LIMIT 1
+                    ) AS ct
+                ) AS category,
+                (
+                    SELECT
+                        COALESCE(array_to_json(array_agg(ct)), '[]'::json)
+                    FROM (
+                        SELECT 
+                            c.id,
+                            c.title,
+                            c.slug,
+                            c.parent_id,
+                            c.sort_order
+                        FROM categories c JOIN products_categories pc ON pc.category_id = c.id
+                        WHERE pc.product_id = p.id
+                        ORDER BY c.sort_order, c.id
                     ) AS ct
                 ) AS categories,
                 p.created_at,
                 p.updated_at,
+                p.version,
                 (
                     SELECT 
                         COALESCE(array_to_json(array_agg(it)), '[]'::json)
@@ -80,13 +136,92 @@ func (b *findProductBuilder) initQuery() {
                             i.url
                         FROM images i
                         WHERE i.product_id = p.id
+                        AND i.variant_id IS NULL
                     ) AS it
-                ) AS images
+                ) AS images,
+                (
+                    SELECT
+                        COALESCE(array_to_json(array_agg(ot)), '[]'::json)
+                    FROM (
+                        SELECT
+                            o.id,
+                            o.name,
+                            o."values"
+                        FROM product_options o
+                        WHERE o.product_id = p.id
+                        ORDER BY o.position
+                    ) AS ot
+                ) AS options,
+                (
+                    SELECT
+                        COALESCE(array_to_json(array_agg(vt)), '[]'::json)
+                    FROM (
+                        SELECT
+                            v.id,
+                            v.sku,
+                            v.options,
+                            v.price,
+                            v.stock,
+                            (
+                                SELECT
+                                    COALESCE(array_to_json(array_agg(vit)), '[]'::json)
+                                FROM (
+                                    SELECT
+                                        vi.id,
+                                        vi.filename,
+                                        vi.url
+                                    FROM images vi
+                                    WHERE vi.variant_id = v.id
+                                ) AS vit
+                            ) AS images,
+                            v.created_at,
+                            v.updated_at
+                        FROM product_variants v
+                        WHERE v.product_id = p.id
+                        ORDER BY v.created_at, v.sku
+                    ) AS vt
+                ) AS variants`
+	b.searchSelect()
+	b.query += `
             FROM products p
             WHERE 1 = 1
     `
 }
 
+// searchSelect adds the relevance and the highlighted snippet of a search
+func (b *findProductBuilder) searchSelect() {
+	if b.req.Search == "" {
+		return
+	}
+
+	b.values = append(b.values, b.req.Search)
+	b.lastStackIndex = len(b.values)
+
+	switch b.req.SearchMode {
+	case products.SearchContains:
+		// The term is matched case insensitive as ILIKE does, its regex characters escaped, the text keeps its own case
+		b.rankExpr = fmt.Sprintf(`GREATEST(similarity(p.title, $%[1]d), word_similarity($%[1]d, p.title || ' ' || p.description))`, b.lastStackIndex)
+		b.query += fmt.Sprintf(`,
+                %[2]s AS rank,
+                regexp_replace(
+                    substring(p.title || ' ' || p.description FROM GREATEST(strpos(LOWER(p.title || ' ' || p.description), LOWER($%[1]d)) - 40, 1) FOR 160),
+                    regexp_replace($%[1]d, '([.*+?^${}()|\[\]\\])', '\\\1', 'g'),
+                    '<mark>\&</mark>',
+                    'gi'
+                ) AS snippet`, b.lastStackIndex, b.rankExpr)
+	default:
+		b.rankExpr = fmt.Sprintf(`ts_rank_cd(p.search_vector, websearch_to_tsquery('english', $%d))`, b.lastStackIndex)
+		b.query += fmt.Sprintf(`,
+                %[2]s AS rank,
+                ts_headline(
+                    'english',
+                    p.title || ' ' || p.description,
+                    websearch_to_tsquery('english', $%[1]d),
+                    'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=25, MinWords=8'
+                ) AS snippet`, b.lastStackIndex, b.rankExpr)
+	}
+}
+
 func (b *findProductBuilder) countQuery() {
 	b.query += `
         SELECT 
@@ -97,7 +232,6 @@ func (b *findProductBuilder) countQuery() {
 }
 
 func (b *findProductBuilder) whereQuery() {
-	var queryWhere string
 	queryWhereStack := make([]string, 0)
 
 	// Id check
@@ -110,57 +244,200 @@ func (b *findProductBuilder) whereQuery() {
 
 	// Search check
 	if b.req.Search != "" {
-		b.values = append(
-			b.values,
-			"%"+strings.ToLower(b.req.Search)+"%",
-			"%"+strings.ToLower(b.req.Search)+"%",
-		)
+		switch b.req.SearchMode {
+		case products.SearchContains:
+			pattern := "%" + escapeLike(b.req.Search) + "%"
+			b.values = append(b.values, pattern, pattern)
+
+			queryWhereStack = append(queryWhereStack, `
+		AND (p.title ILIKE ? OR p.description ILIKE ?)`)
+		default:
+			b.values = append(b.values, b.req.Search)
+
+			queryWhereStack = append(queryWhereStack, `
+		AND p.search_vector @@ websearch_to_tsquery('english', ?)`)
+		}
+	}
 
+	// Status check, the public listing only has the visible products
+	if !b.req.Admin {
 		queryWhereStack = append(queryWhereStack, `
-		AND (LOWER(p.title) LIKE ? OR LOWER(p.description) LIKE ?)`)
+		AND p.status = 'published'
+		AND COALESCE(p.publish_at <= now(), TRUE)`)
+	} else if b.req.Status != "" {
+		b.values = append(b.values, b.req.Status)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND p.status = ?::product_status`)
 	}
 
-	for i := range queryWhereStack {
-		if i != len(queryWhereStack)-1 {
-			queryWhere += strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(i+1), 1)
-		} else {
-			queryWhere += strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(i+1), 1)
-			queryWhere = strings.Replace(queryWhere, "?", "$"+strconv.Itoa(i+2), 1)
+	// Category check, a category also matches the products of its descendants
+	if len(b.req.Categories) > 0 && b.facet != facetCategory {
+		b.values = append(b.values, b.req.Categories)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND EXISTS (
+			SELECT 1
+			FROM products_categories fpc
+			WHERE fpc.product_id = p.id
+			AND fpc.category_id = ANY(ARRAY(
+				WITH RECURSIVE tree AS (
+					SELECT
+						id
+					FROM categories
+					WHERE id = ANY(?)
+					UNION
+					SELECT
+						c.id
+					FROM categories c
+					JOIN tree t ON c.parent_id = t.id
+				)
+				SELECT id FROM tree
+			))
+		)`)
+	}
+
+	// Price check
+	if b.facet != facetPrice {
+		if b.req.MinPrice > 0 {
+			b.values = append(b.values, b.req.MinPrice)
+
+			queryWhereStack = append(queryWhereStack, `
+		AND product_current_price(p.id, p.price) >= ?`)
+		}
+		if b.req.MaxPrice > 0 {
+			b.values = append(b.values, b.req.MaxPrice)
+
+			queryWhereStack = append(queryWhereStack, `
+		AND product_current_price(p.id, p.price) <= ?`)
+		}
+	}
+
+	// Created date check
+	if b.req.StartDate != "" {
+		b.values = append(b.values, b.req.StartDate)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND p.created_at >= DATE(?)`)
+	}
+	if b.req.EndDate != "" {
+		b.values = append(b.values, b.req.EndDate)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND p.created_at < (?)::DATE + 1`)
+	}
+
+	// Availability check, products without stock tracking are always available.
+	// A product with variants is sold through them, it is in stock while any variant is
+	if b.req.InStock {
+		queryWhereStack = append(queryWhereStack, `
+		AND (
+			CASE
+				WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+				THEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND (v.stock IS NULL OR v.stock > 0))
+				ELSE (p.stock IS NULL OR p.stock > 0)
+			END
+		)`)
+	}
+
+	// Every ? takes the next value in order
+	for _, queryWhere := range queryWhereStack {
+		for strings.Contains(queryWhere, "?") {
+			queryWhere = strings.Replace(queryWhere, "?", "$"+strconv.Itoa(b.lastStackIndex+1), 1)
+			b.lastStackIndex++
 		}
+		b.query += queryWhere
 	}
 	// Last stack record
 	b.lastStackIndex = len(b.values)
+}
 
-	// Summary query
-	b.query += queryWhere
+// escapeLike keeps user input from acting as LIKE wildcards
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
 }
 
-func (b *findProductBuilder) sort() {
-	orderByMap := map[string]string{
-		"id":    "p.id",
-		"title": "p.title",
-		"price": "p.price",
+// sortKey gives the expression sorted on and the cast a cursor value needs to compare with it
+func (b *findProductBuilder) sortKey() (string, string) {
+	switch b.req.OrderBy {
+	case "id":
+		return "p.id", ""
+	case "price":
+		return "product_current_price(p.id, p.price)", "::FLOAT"
+	case "created_at":
+		return "p.created_at", "::TIMESTAMP"
+	case "rating":
+		// Same as the rating column, the cursor value has to compare equal to it
+		return `(
+			SELECT
+				COALESCE(ROUND(AVG(r.rating), 2), 0)::FLOAT
+			FROM reviews r
+			WHERE r.product_id = p.id
+			AND NOT r.is_hidden
+		)`, "::FLOAT"
+	case "relevance":
+		// Relevance only exists while searching
+		if b.rankExpr != "" {
+			return b.rankExpr, "::REAL"
+		}
 	}
+	return "p.title", ""
+}
 
-	if orderByMap[b.req.OrderBy] == "" {
-		b.req.OrderBy = orderByMap[b.req.OrderBy]
-	} else {
-		b.req.OrderBy = orderByMap[b.req.OrderBy]
+// direction is the sort the rows are read in, a backward cursor reads the other way
+func (b *findProductBuilder) direction() string {
+	sort := "ASC"
+	if strings.ToUpper(b.req.Sort) == "DESC" {
+		sort = "DESC"
+	}
+	if b.req.CursorReq != nil && b.req.After != nil && b.req.After.Backward {
+		if sort == "ASC" {
+			return "DESC"
+		}
+		return "ASC"
+	}
+	return sort
+}
+
+func (b *findProductBuilder) sort() {
+	orderBy, _ := b.sortKey()
+	sort := b.direction()
+
+	// Columns can not be bound as parameters, only whitelisted names reach the query
+	b.query += fmt.Sprintf(`
+		ORDER BY %s %s, p.id %s`, orderBy, sort, sort)
+}
+
+// seek continues after the cursor row instead of skipping rows with OFFSET
+func (b *findProductBuilder) seek() {
+	if b.req.CursorReq == nil || b.req.After == nil {
+		return
 	}
 
-	sortMap := map[string]string{
-		"DESC": "DESC",
-		"ASC":  "ASC",
+	op := ">"
+	if b.direction() == "DESC" {
+		op = "<"
 	}
-	if sortMap[strings.ToUpper(b.req.Sort)] == "" {
-		b.req.Sort = sortMap["ASC"]
+
+	orderBy, cast := b.sortKey()
+	if orderBy == "p.id" {
+		b.values = append(b.values, b.req.After.Id)
+		b.query += fmt.Sprintf(`
+		AND p.id %s $%d`, op, len(b.values))
 	} else {
-		b.req.Sort = sortMap[strings.ToUpper(b.req.Sort)]
+		b.values = append(b.values, b.req.After.Value, b.req.After.Id)
+		b.query += fmt.Sprintf(`
+		AND (%s, p.id) %s ($%d%s, $%d)`, orderBy, op, len(b.values)-1, cast, len(b.values))
 	}
+	b.lastStackIndex = len(b.values)
+}
+
+// seekLimit reads one row more than the page, it tells whether another page follows
+func (b *findProductBuilder) seekLimit() {
+	b.values = append(b.values, b.req.Limit+1)
 
-	b.values = append(b.values, b.req.OrderBy)
 	b.query += fmt.Sprintf(`
-		ORDER BY $%d %s`, b.lastStackIndex+1, b.req.Sort)
+		LIMIT $%d`, b.lastStackIndex+1)
 	b.lastStackIndex = len(b.values)
 }
 
@@ -178,15 +455,65 @@ func (b *findProductBuilder) closeJsonQuery() {
     `
 }
 
+func (b *findProductBuilder) categoryFacetQuery() {
+	b.facet = facetCategory
+	b.query += `
+            SELECT
+                c.id,
+                c.title,
+                COUNT(DISTINCT pc.product_id) AS count
+            FROM categories c
+            JOIN products_categories pc ON pc.category_id = c.id
+            WHERE pc.product_id IN (
+                SELECT
+                    p.id
+                FROM products p
+                WHERE 1 = 1
+    `
+}
+
+func (b *findProductBuilder) closeCategoryFacetQuery() {
+	b.query += `
+            )
+            GROUP BY c.id, c.title
+            ORDER BY c.title`
+}
+
+func (b *findProductBuilder) priceFacetQuery() {
+	b.facet = facetPrice
+
+	// Bounds come from products.PriceBuckets, never from the request
+	counts := make([]string, 0, len(products.PriceBuckets))
+	for i, min := range products.PriceBuckets {
+		if i == len(products.PriceBuckets)-1 {
+			counts = append(counts, fmt.Sprintf(`
+                COUNT(*) FILTER (WHERE product_current_price(p.id, p.price) >= %v)`, min))
+			continue
+		}
+		counts = append(counts, fmt.Sprintf(`
+                COUNT(*) FILTER (WHERE product_current_price(p.id, p.price) >= %v AND product_current_price(p.id, p.price) < %v)`, min, products.PriceBuckets[i+1]))
+	}
+
+	b.query += fmt.Sprintf(`
+        SELECT
+            json_build_array(%s
+            )
+        FROM products p
+        WHERE 1 = 1
+    `, strings.Join(counts, ","))
+}
+
 func (b *findProductBuilder) resetQuery() {
 	b.query = ``
 	b.values = make([]any, 0)
 	b.lastStackIndex = 0
+	b.facet = ""
 }
 
 func (b *findProductBuilder) Result() []*products.Product {
 	_, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
+	defer b.resetQuery()
 
 	bytes := make([]byte, 0)
 	productsData := make([]*products.Product, 0)
@@ -200,24 +527,75 @@ func (b *findProductBuilder) Result() []*products.Product {
 		log.Printf("unmarshal products failed: %v\n", err)
 		return make([]*products.Product, 0)
 	}
-
-	b.resetQuery()
 	return productsData
 }
 
 func (b *findProductBuilder) Count() int {
 	_, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
+	defer b.resetQuery()
 
 	var count int
 	if err := b.db.Get(&count, b.query, b.values...); err != nil {
 		log.Printf("count products failed: %v\n", err)
 		return 0
 	}
-	b.resetQuery()
 	return count
 }
 
+func (b *findProductBuilder) CategoryFacets() []*products.CategoryFacet {
+	_, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+	defer b.resetQuery()
+
+	bytes := make([]byte, 0)
+	facets := make([]*products.CategoryFacet, 0)
+
+	if err := b.db.Get(&bytes, b.query, b.values...); err != nil {
+		log.Printf("count category facets failed: %v\n", err)
+		return facets
+	}
+	// json_agg gives null when nothing matched
+	if bytes == nil {
+		return facets
+	}
+
+	if err := json.Unmarshal(bytes, &facets); err != nil {
+		log.Printf("unmarshal category facets failed: %v\n", err)
+		return make([]*products.CategoryFacet, 0)
+	}
+	return facets
+}
+
+func (b *findProductBuilder) PriceFacets() []*products.PriceFacet {
+	_, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+	defer b.resetQuery()
+
+	bytes := make([]byte, 0)
+	counts := make([]int, 0)
+
+	if err := b.db.Get(&bytes, b.query, b.values...); err != nil {
+		log.Printf("count price facets failed: %v\n", err)
+	} else if err := json.Unmarshal(bytes, &counts); err != nil {
+		log.Printf("unmarshal price facets failed: %v\n", err)
+	}
+
+	facets := make([]*products.PriceFacet, 0, len(products.PriceBuckets))
+	for i, min := range products.PriceBuckets {
+		facet := &products.PriceFacet{Min: min}
+		if i < len(products.PriceBuckets)-1 {
+			max := products.PriceBuckets[i+1]
+			facet.Max = &max
+		}
+		if i < len(counts) {
+			facet.Count = counts[i]
+		}
+		facets = append(facets, facet)
+	}
+	return facets
+}
+
 func (b *findProductBuilder) PrintQuery() {
 	utils.Debug(b.values)
 	fmt.Println(b.query)
@@ -248,3 +626,29 @@ func (en *findProductEngineer) CountProduct() IFindProductBuilder {
 	en.builder.whereQuery()
 	return en.builder
 }
+
+func (en *findProductEngineer) CategoryFacet() IFindProductBuilder {
+	en.builder.openJsonQuery()
+	en.builder.categoryFacetQuery()
+	en.builder.whereQuery()
+	en.builder.closeCategoryFacetQuery()
+	en.builder.closeJsonQuery()
+	return en.builder
+}
+
+func (en *findProductEngineer) PriceFacet() IFindProductBuilder {
+	en.builder.priceFacetQuery()
+	en.builder.whereQuery()
+	return en.builder
+}
+
+func (en *findProductEngineer) FindProductCursor() IFindProductBuilder {
+	en.builder.openJsonQuery()
+	en.builder.initQuery()
+	en.builder.whereQuery()
+	en.builder.seek()
+	en.builder.sort()
+	en.builder.seekLimit()
+	en.builder.closeJsonQuery()
+	return en.builder
+}
@@ -14,6 +14,7 @@ type IInsertProductBuilder interface {
 	insertProduct() error
 	insertCategory() error
 	insertAttachment() error
+	insertVariants() error
 	commit() error
 	getProductId() string
 }
@@ -47,11 +48,16 @@ func (b *insertProductBuilder) insertProduct() error {
         INSERT INTO products (
             title,
             description,
-            price
-        ) VALUES ($1, $2, $3) RETURNING id;
+            price,
+            tax_mode,
+            stock,
+            external_sku,
+            status,
+            publish_at
+        ) VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'inclusive')::tax_mode, $5, NULLIF($6, ''), COALESCE(NULLIF($7, ''), 'draft')::product_status, NULLIF($8, '')::TIMESTAMPTZ) RETURNING id;
     `
 
-	if err := b.tx.QueryRowxContext(ctx, query, b.req.Title, b.req.Description, b.req.Price).Scan(&b.req.Id); err != nil {
+	if err := b.tx.QueryRowxContext(ctx, query, b.req.Title, b.req.Description, b.req.Price, b.req.TaxMode, b.req.Stock, b.req.ExternalSku, b.req.Status, b.req.PublishAt).Scan(&b.req.Id); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert product failed: %v", err)
 	}
@@ -62,15 +68,9 @@ func (b *insertProductBuilder) insertCategory() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
 
-	query := `
-        INSERT INTO products_categories (
-            product_id,
-            category_id
-        ) VALUES ($1, $2);
-    `
-	if _, err := b.tx.ExecContext(ctx, query, b.req.Id, b.req.Category.Id); err != nil {
+	if err := ReplaceCategories(ctx, b.tx, b.req.Id, b.req.CategoryIds()); err != nil {
 		b.tx.Rollback()
-		return fmt.Errorf("insert products_categories failed: %v", err)
+		return err
 	}
 
 	return nil
@@ -116,6 +116,20 @@ func (b *insertProductBuilder) insertAttachment() error {
 	}
 	return nil
 }
+func (b *insertProductBuilder) insertVariants() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	if err := replaceOptions(ctx, b.tx, b.req.Id, b.req.Options); err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	if _, err := upsertVariants(ctx, b.tx, b.req.Id, b.req.Variants); err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	return nil
+}
 func (b *insertProductBuilder) commit() error {
 	if err := b.tx.Commit(); err != nil {
 		return err
@@ -153,6 +167,9 @@ func (en *insertProductEngineer) InsertProduct() (string, error) {
 	if err := en.builder.insertAttachment(); err != nil {
 		return "", err
 	}
+	if err := en.builder.insertVariants(); err != nil {
+		return "", err
+	}
 	if err := en.builder.commit(); err != nil {
 		return "", err
 	}
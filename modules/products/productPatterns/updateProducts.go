@@ -2,25 +2,38 @@ package productPatterns
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"go_learn_project_rest_api/modules/entities"
 	"go_learn_project_rest_api/modules/files"
 	"go_learn_project_rest_api/modules/files/fileUsecases"
 	"go_learn_project_rest_api/modules/products"
+	"log"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type IUpdateProductBuilder interface {
 	initTransaction() error
+	checkVersion() error
 	initQuery()
 	updateTitleQuery()
 	updateDescriptionQuery()
 	updatePriceQuery()
+	updateTaxModeQuery()
+	updateStockQuery()
+	updateExternalSkuQuery()
+	updateStatusQuery()
+	updatePublishAtQuery()
+	touchQuery()
 	updateCategory() error
+	updateOptions() error
+	updateVariants() error
 	insertImages() error
 	getOldImages() []*entities.Image
 	deleteOldImages() error
+	deleteDroppedFiles()
 	closeQuery()
 	updateProduct() error
 	getQueryFields() []string
@@ -36,6 +49,7 @@ type updateProductBuilder struct {
 	tx             *sqlx.Tx
 	req            *products.Product
 	filesUsecases  fileUsecases.IFileUsecases
+	droppedImages  []*entities.Image // their files go once the transaction commits
 	query          string
 	queryFields    []string
 	lastStackIndex int
@@ -64,6 +78,23 @@ func (b *updateProductBuilder) initTransaction() error {
 	b.tx = tx
 	return nil
 }
+
+// checkVersion locks the product for the update and compares it with the version the client read, 0 skips the compare
+func (b *updateProductBuilder) checkVersion() error {
+	var version int
+	if err := b.tx.GetContext(context.Background(), &version, `SELECT "version" FROM "products" WHERE "id" = $1 FOR UPDATE;`, b.req.Id); err != nil {
+		b.tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("product %s not found", b.req.Id)
+		}
+		return fmt.Errorf("get product version failed: %v", err)
+	}
+	if b.req.Version != 0 && b.req.Version != version {
+		b.tx.Rollback()
+		return entities.ErrVersionConflict
+	}
+	return nil
+}
 func (b *updateProductBuilder) initQuery() {
 	b.query += `
 	UPDATE "products" SET`
@@ -95,27 +126,68 @@ func (b *updateProductBuilder) updatePriceQuery() {
 		"price" = $%d`, b.lastStackIndex))
 	}
 }
-func (b *updateProductBuilder) updateCategory() error {
-	if b.req.Category == nil {
-		return nil
+func (b *updateProductBuilder) updateTaxModeQuery() {
+	if b.req.TaxMode != "" {
+		b.values = append(b.values, b.req.TaxMode)
+		b.lastStackIndex = len(b.values)
+
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		"tax_mode" = $%d::tax_mode`, b.lastStackIndex))
 	}
-	if b.req.Category.Id == 0 {
-		return nil
+}
+func (b *updateProductBuilder) updateStockQuery() {
+	if b.req.Stock != nil {
+		b.values = append(b.values, *b.req.Stock)
+		b.lastStackIndex = len(b.values)
+
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		"stock" = $%d`, b.lastStackIndex))
 	}
+}
+func (b *updateProductBuilder) updateExternalSkuQuery() {
+	if b.req.ExternalSku != "" {
+		b.values = append(b.values, b.req.ExternalSku)
+		b.lastStackIndex = len(b.values)
 
-	query := `
-	UPDATE "products_categories" SET
-		"category_id" = $1
-	WHERE "product_id" = $2;`
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		"external_sku" = $%d`, b.lastStackIndex))
+	}
+}
+func (b *updateProductBuilder) updateStatusQuery() {
+	if b.req.Status != "" {
+		b.values = append(b.values, b.req.Status)
+		b.lastStackIndex = len(b.values)
 
-	if _, err := b.tx.ExecContext(
-		context.Background(),
-		query,
-		b.req.Category.Id,
-		b.req.Id,
-	); err != nil {
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		"status" = $%d::product_status`, b.lastStackIndex))
+	}
+}
+func (b *updateProductBuilder) updatePublishAtQuery() {
+	if b.req.PublishAt != nil {
+		b.values = append(b.values, *b.req.PublishAt)
+		b.lastStackIndex = len(b.values)
+
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		"publish_at" = NULLIF($%d, '')::TIMESTAMPTZ`, b.lastStackIndex))
+	}
+}
+
+// touchQuery bumps the version on every admin update, also when only the stock or the categories, options, variants or images change.
+// The trigger leaves stock changes alone, those come from orders too
+func (b *updateProductBuilder) touchQuery() {
+	b.queryFields = append(b.queryFields, `
+		"version" = "version" + 1`)
+}
+func (b *updateProductBuilder) updateCategory() error {
+	// No category keeps the categories
+	categoryIds := b.req.CategoryIds()
+	if len(categoryIds) == 0 {
+		return nil
+	}
+
+	if err := ReplaceCategories(context.Background(), b.tx, b.req.Id, categoryIds); err != nil {
 		b.tx.Rollback()
-		return fmt.Errorf("update products_categories failed: %v", err)
+		return err
 	}
 	return nil
 }
@@ -164,7 +236,8 @@ func (b *updateProductBuilder) getOldImages() []*entities.Image {
 		"filename",
 		"url"
 	FROM "images"
-	WHERE "product_id" = $1;`
+	WHERE "product_id" = $1
+	AND "variant_id" IS NULL;`
 
 	images := make([]*entities.Image, 0)
 	if err := b.db.Select(
@@ -179,19 +252,10 @@ func (b *updateProductBuilder) getOldImages() []*entities.Image {
 func (b *updateProductBuilder) deleteOldImages() error {
 	query := `
 	DELETE FROM "images"
-	WHERE "product_id" = $1;`
+	WHERE "product_id" = $1
+	AND "variant_id" IS NULL;`
 
 	images := b.getOldImages()
-	if len(images) > 0 {
-		deleteFileReq := make([]*files.DeleteFileReq, 0)
-		for _, img := range images {
-			deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
-				Destination: "images/test/",
-				FileName:    img.FileName,
-			})
-		}
-		b.filesUsecases.DeleteFileOnGCP(deleteFileReq)
-	}
 
 	if _, err := b.tx.ExecContext(
 		context.Background(),
@@ -201,8 +265,50 @@ func (b *updateProductBuilder) deleteOldImages() error {
 		b.tx.Rollback()
 		return fmt.Errorf("delete images failed: %v", err)
 	}
+	b.droppedImages = append(b.droppedImages, images...)
+	return nil
+}
+func (b *updateProductBuilder) updateOptions() error {
+	// nil keeps the options
+	if b.req.Options == nil {
+		return nil
+	}
+	if err := replaceOptions(context.Background(), b.tx, b.req.Id, b.req.Options); err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	return nil
+}
+func (b *updateProductBuilder) updateVariants() error {
+	// nil keeps the variants
+	if b.req.Variants == nil {
+		return nil
+	}
+	dropped, err := upsertVariants(context.Background(), b.tx, b.req.Id, b.req.Variants)
+	if err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	b.droppedImages = append(b.droppedImages, dropped...)
 	return nil
 }
+
+// deleteDroppedFiles removes the files of the replaced images, the update is already committed so a failure is only logged
+func (b *updateProductBuilder) deleteDroppedFiles() {
+	if len(b.droppedImages) == 0 {
+		return
+	}
+	deleteFileReq := make([]*files.DeleteFileReq, 0)
+	for _, img := range b.droppedImages {
+		deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
+			Destination: "images/test/",
+			FileName:    img.FileName,
+		})
+	}
+	if err := b.filesUsecases.DeleteFileOnGCP(deleteFileReq); err != nil {
+		log.Printf("delete replaced images failed: %v\n", err)
+	}
+}
 func (b *updateProductBuilder) closeQuery() {
 	b.values = append(b.values, b.req.Id)
 	b.lastStackIndex = len(b.values)
@@ -237,6 +343,12 @@ func (en *updateProductEngineer) sumQueryFields() {
 	en.builder.updateTitleQuery()
 	en.builder.updateDescriptionQuery()
 	en.builder.updatePriceQuery()
+	en.builder.updateTaxModeQuery()
+	en.builder.updateStockQuery()
+	en.builder.updateExternalSkuQuery()
+	en.builder.updateStatusQuery()
+	en.builder.updatePublishAtQuery()
+	en.builder.touchQuery()
 
 	fields := en.builder.getQueryFields()
 
@@ -251,7 +363,12 @@ func (en *updateProductEngineer) sumQueryFields() {
 }
 
 func (en *updateProductEngineer) UpdateProduct() error {
-	en.builder.initTransaction()
+	if err := en.builder.initTransaction(); err != nil {
+		return err
+	}
+	if err := en.builder.checkVersion(); err != nil {
+		return err
+	}
 
 	en.builder.initQuery()
 	en.sumQueryFields()
@@ -259,7 +376,7 @@ func (en *updateProductEngineer) UpdateProduct() error {
 
 	fmt.Println(en.builder.getQuery())
 
-	// Update product
+	// Update product, always runs so the version moves with any change
 	if err := en.builder.updateProduct(); err != nil {
 		return err
 	}
@@ -269,6 +386,14 @@ func (en *updateProductEngineer) UpdateProduct() error {
 		return err
 	}
 
+	// Update options and variants
+	if err := en.builder.updateOptions(); err != nil {
+		return err
+	}
+	if err := en.builder.updateVariants(); err != nil {
+		return err
+	}
+
 	if en.builder.getImagesLen() > 0 {
 		if err := en.builder.deleteOldImages(); err != nil {
 			return err
@@ -282,5 +407,8 @@ func (en *updateProductEngineer) UpdateProduct() error {
 	if err := en.builder.commit(); err != nil {
 		return err
 	}
+
+	// Files of replaced images go only once the rows are gone for good
+	en.builder.deleteDroppedFiles()
 	return nil
 }
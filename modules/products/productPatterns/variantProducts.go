@@ -0,0 +1,144 @@
+package productPatterns
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/products"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// replaceOptions writes the option types of a product, the request order is kept as position
+func replaceOptions(ctx context.Context, tx *sqlx.Tx, productId string, options []*products.Option) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "product_options" WHERE "product_id" = $1;`, productId); err != nil {
+		return fmt.Errorf("delete product_options failed: %v", err)
+	}
+
+	query := `
+	INSERT INTO "product_options" (
+		"product_id",
+		"name",
+		"values",
+		"position"
+	)
+	VALUES ($1, $2, $3, $4)
+	RETURNING "id";`
+
+	for i, o := range options {
+		valuesBytes, err := json.Marshal(o.Values)
+		if err != nil {
+			return fmt.Errorf("marshal option values failed: %v", err)
+		}
+		if err := tx.QueryRowxContext(ctx, query, productId, o.Name, string(valuesBytes), i).Scan(&o.Id); err != nil {
+			return fmt.Errorf("insert product_options failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// upsertVariants writes the variants of a product by sku, so a kept sku keeps its id and the order lines pointing at it.
+// Variants left out are removed, the images dropped on the way are returned so their files can be deleted
+func upsertVariants(ctx context.Context, tx *sqlx.Tx, productId string, variants []*products.Variant) ([]*entities.Image, error) {
+	dropped := make([]*entities.Image, 0)
+
+	query := `
+	INSERT INTO "product_variants" (
+		"product_id",
+		"sku",
+		"options",
+		"price",
+		"stock"
+	)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT ("sku") DO UPDATE SET
+		"options" = EXCLUDED."options",
+		"price" = EXCLUDED."price",
+		"stock" = EXCLUDED."stock"
+	WHERE "product_variants"."product_id" = EXCLUDED."product_id"
+	RETURNING "id";`
+
+	skus := make([]string, 0, len(variants))
+	for _, v := range variants {
+		if v.Options == nil {
+			v.Options = make(map[string]string)
+		}
+		optionsBytes, err := json.Marshal(v.Options)
+		if err != nil {
+			return nil, fmt.Errorf("marshal variant options failed: %v", err)
+		}
+
+		if err := tx.QueryRowxContext(ctx, query, productId, v.Sku, string(optionsBytes), v.Price, v.Stock).Scan(&v.Id); err != nil {
+			// The conflict update is skipped when the sku belongs to another product
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("sku %s is used by another product", v.Sku)
+			}
+			return nil, fmt.Errorf("upsert product_variants failed: %v", err)
+		}
+		skus = append(skus, v.Sku)
+
+		// nil keeps the images of the variant
+		if v.Images == nil {
+			continue
+		}
+		images, err := replaceVariantImages(ctx, tx, productId, v)
+		if err != nil {
+			return nil, err
+		}
+		dropped = append(dropped, images...)
+	}
+
+	images := make([]*entities.Image, 0)
+	query = `
+	DELETE FROM "images"
+	WHERE "variant_id" IN (
+		SELECT
+			"id"
+		FROM "product_variants"
+		WHERE "product_id" = $1
+		AND NOT ("sku" = ANY($2))
+	)
+	RETURNING "id", "filename", "url";`
+	if err := tx.SelectContext(ctx, &images, query, productId, skus); err != nil {
+		return nil, fmt.Errorf("delete variant images failed: %v", err)
+	}
+	dropped = append(dropped, images...)
+
+	query = `
+	DELETE FROM "product_variants"
+	WHERE "product_id" = $1
+	AND NOT ("sku" = ANY($2));`
+	if _, err := tx.ExecContext(ctx, query, productId, skus); err != nil {
+		return nil, fmt.Errorf("delete product_variants failed: %v", err)
+	}
+	return dropped, nil
+}
+
+func replaceVariantImages(ctx context.Context, tx *sqlx.Tx, productId string, v *products.Variant) ([]*entities.Image, error) {
+	dropped := make([]*entities.Image, 0)
+	query := `
+	DELETE FROM "images"
+	WHERE "variant_id" = $1
+	RETURNING "id", "filename", "url";`
+	if err := tx.SelectContext(ctx, &dropped, query, v.Id); err != nil {
+		return nil, fmt.Errorf("delete variant images failed: %v", err)
+	}
+
+	query = `
+	INSERT INTO "images" (
+		"filename",
+		"url",
+		"product_id",
+		"variant_id"
+	)
+	VALUES ($1, $2, $3, $4);`
+	for _, img := range v.Images {
+		if _, err := tx.ExecContext(ctx, query, img.FileName, img.Url, productId, v.Id); err != nil {
+			return nil, fmt.Errorf("insert variant images failed: %v", err)
+		}
+	}
+	return dropped, nil
+}
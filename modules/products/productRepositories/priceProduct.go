@@ -0,0 +1,173 @@
+package productRepositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go_learn_project_rest_api/modules/products"
+	"time"
+)
+
+func (r *productRepository) FindPriceTimeline(productId string) (*products.PriceTimeline, error) {
+	timeline := &products.PriceTimeline{
+		ProductId: productId,
+		History:   make([]*products.PriceChange, 0),
+		Schedules: make([]*products.ScheduledPrice, 0),
+	}
+
+	query := `
+	SELECT
+		product_current_price("id", "price") AS "price",
+		"price" AS "regular_price"
+	FROM "products"
+	WHERE "id" = $1;`
+	if err := r.db.QueryRowx(query, productId).Scan(&timeline.Price, &timeline.RegularPrice); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("product %s not found", productId)
+		}
+		return nil, fmt.Errorf("get product price failed: %v", err)
+	}
+
+	query = `
+	SELECT
+		"id",
+		"price",
+		"previous_price",
+		"created_at"::TEXT AS "created_at"
+	FROM "product_price_history"
+	WHERE "product_id" = $1
+	ORDER BY "created_at" DESC, "id";`
+	if err := r.db.Select(&timeline.History, query, productId); err != nil {
+		return nil, fmt.Errorf("get price history failed: %v", err)
+	}
+
+	query = `
+	SELECT
+		"id",
+		"product_id",
+		"price",
+		"starts_at"::TEXT AS "starts_at",
+		"ends_at"::TEXT AS "ends_at",
+		CASE
+			WHEN "starts_at" > now() THEN 'upcoming'
+			WHEN "ends_at" IS NOT NULL AND "ends_at" <= now() THEN 'ended'
+			ELSE 'active'
+		END AS "status",
+		"created_at"::TEXT AS "created_at"
+	FROM "product_scheduled_prices"
+	WHERE "product_id" = $1
+	ORDER BY "starts_at" DESC, "id";`
+	if err := r.db.Select(&timeline.Schedules, query, productId); err != nil {
+		return nil, fmt.Errorf("get scheduled prices failed: %v", err)
+	}
+	return timeline, nil
+}
+
+// InsertScheduledPrice adds a price for a period, periods of a product must not overlap
+func (r *productRepository) InsertScheduledPrice(req *products.ScheduledPrice) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	// Validate has checked the times, the offset of the request is kept by passing them as time
+	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+	if err != nil {
+		return "", fmt.Errorf("starts_at is invalid")
+	}
+	var endsAt *time.Time
+	if req.EndsAt != nil {
+		t, err := time.Parse(time.RFC3339, *req.EndsAt)
+		if err != nil {
+			return "", fmt.Errorf("ends_at is invalid")
+		}
+		endsAt = &t
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// The product row lock keeps two schedules of the product from passing the overlap check together
+	var productId string
+	if err := tx.GetContext(ctx, &productId, `SELECT "id" FROM "products" WHERE "id" = $1 FOR UPDATE;`, req.ProductId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("product %s not found", req.ProductId)
+		}
+		return "", fmt.Errorf("lock product failed: %v", err)
+	}
+
+	query := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM "product_scheduled_prices"
+		WHERE "product_id" = $1
+		AND "starts_at" < COALESCE($3::TIMESTAMPTZ::TIMESTAMP, 'infinity')
+		AND COALESCE("ends_at", 'infinity') > $2::TIMESTAMPTZ::TIMESTAMP
+	);`
+	var isOverlap bool
+	if err := tx.GetContext(ctx, &isOverlap, query, req.ProductId, startsAt, endsAt); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("check scheduled prices failed: %v", err)
+	}
+	if isOverlap {
+		tx.Rollback()
+		return "", fmt.Errorf("scheduled price overlaps another one of the product")
+	}
+
+	query = `
+	INSERT INTO "product_scheduled_prices" (
+		"product_id",
+		"price",
+		"starts_at",
+		"ends_at"
+	)
+	VALUES ($1, $2, $3::TIMESTAMPTZ, $4::TIMESTAMPTZ)
+	RETURNING "id";`
+	var scheduleId string
+	if err := tx.QueryRowxContext(ctx, query, req.ProductId, req.Price, startsAt, endsAt).Scan(&scheduleId); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("insert scheduled price failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	return scheduleId, nil
+}
+
+// DeleteScheduledPrice removes an upcoming price, a running one is ended now so the timeline keeps it
+func (r *productRepository) DeleteScheduledPrice(productId, scheduleId string) error {
+	query := `
+	UPDATE "product_scheduled_prices" SET
+		"ends_at" = now()
+	WHERE "id" = $1
+	AND "product_id" = $2
+	AND "starts_at" <= now()
+	AND ("ends_at" IS NULL OR "ends_at" > now());`
+
+	result, err := r.db.Exec(query, scheduleId, productId)
+	if err != nil {
+		return fmt.Errorf("end scheduled price failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		return nil
+	}
+
+	query = `
+	DELETE FROM "product_scheduled_prices"
+	WHERE "id" = $1
+	AND "product_id" = $2
+	AND "starts_at" > now();`
+
+	result, err = r.db.Exec(query, scheduleId, productId)
+	if err != nil {
+		return fmt.Errorf("delete scheduled price failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("scheduled price not found or already ended")
+	}
+	return nil
+}
@@ -2,23 +2,41 @@ package productRepositories
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go_learn_project_rest_api/config"
 	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/files"
 	"go_learn_project_rest_api/modules/files/fileUsecases"
 	"go_learn_project_rest_api/modules/products"
 	"go_learn_project_rest_api/modules/products/productPatterns"
+	"log"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type IProductRepository interface {
 	FindOneProduct(string) (*products.Product, error)
+	FindProductByIds([]string) ([]*products.Product, error)
 	FindProduct(*products.ProductFilter) ([]*products.Product, int)
+	FindProductFacet(*products.ProductFilter) *products.ProductFacets
+	FindProductCursor(*products.ProductFilter) ([]*products.Product, bool)
+	CountProduct(*products.ProductFilter) int
+	ImportProduct(*products.ImportReq) (*products.ImportRes, error)
+	ExportProduct(fn func(*products.ProductExportRow) error) error
 	InsertProduct(*products.Product) (*products.Product, error)
 	UpdateProduct(*products.Product) (*products.Product, error)
-	DeleteProduct(string) error
+	DeleteProduct(string) (bool, error)
+	FindPriceTimeline(productId string) (*products.PriceTimeline, error)
+	InsertScheduledPrice(*products.ScheduledPrice) (string, error)
+	DeleteScheduledPrice(productId, scheduleId string) error
 }
 
 type productRepository struct {
@@ -35,29 +53,68 @@ func ProductRepository(db *sqlx.DB, cfg config.IConfig, fileUsecases fileUsecase
 	}
 }
 
-func (r *productRepository) FindOneProduct(productId string) (*products.Product, error) {
-	query := `
-        SELECT
-            to_jsonb(t)
-        FROM (
+// productSelect is the product as the api returns it, the caller adds the where
+const productSelect = `
             SELECT
                 p.id,
+                p.external_sku,
                 p.title,
                 p.description,
-                p.price,
+                product_current_price(p.id, p.price) AS price,
+                p.price AS regular_price,
+                p.tax_mode,
+                p.stock,
+                p.status,
+                p.publish_at,
+                (p.status = 'published' AND COALESCE(p.publish_at <= now(), TRUE)) AS visible,
+                (
+                    SELECT
+                        COALESCE(ROUND(AVG(r.rating), 2), 0)::FLOAT
+                    FROM reviews r
+                    WHERE r.product_id = p.id
+                    AND NOT r.is_hidden
+                ) AS rating,
+                (
+                    SELECT
+                        COUNT(*)
+                    FROM reviews r
+                    WHERE r.product_id = p.id
+                    AND NOT r.is_hidden
+                ) AS review_count,
                 (
                     SELECT
                         to_jsonb(ct)
                     FROM (
                         SELECT 
                             c.id,
-                            c.title
-                        FROM categories c LEFT JOIN products_categories pc ON pc.category_id = c.id
+                            c.title,
+                            c.slug,
+                            c.parent_id,
+                            c.sort_order
+                        FROM categories c JOIN products_categories pc ON pc.category_id = c.id
+                        WHERE pc.product_id = p.id
+                        ORDER BY c.sort_order, c.id
+                        LIMIT 1
+                    ) AS ct
+                ) AS category,
+                (
+                    SELECT
+                        COALESCE(array_to_json(array_agg(ct)), '[]'::json)
+                    FROM (
+                        SELECT 
+                            c.id,
+                            c.title,
+                            c.slug,
+                            c.parent_id,
+                            c.sort_order
+                        FROM categories c JOIN products_categories pc ON pc.category_id = c.id
                         WHERE pc.product_id = p.id
+                        ORDER BY c.sort_order, c.id
                     ) AS ct
                 ) AS categories,
                 p.created_at,
                 p.updated_at,
+                p.version,
                 (
                     SELECT 
                         COALESCE(array_to_json(array_agg(it)), '[]'::json)
@@ -68,9 +125,58 @@ func (r *productRepository) FindOneProduct(productId string) (*products.Product,
                             i.url
                         FROM images i
                         WHERE i.product_id = p.id
+                        AND i.variant_id IS NULL
                     ) AS it
-                ) AS images
-            FROM products p
+                ) AS images,
+                (
+                    SELECT
+                        COALESCE(array_to_json(array_agg(ot)), '[]'::json)
+                    FROM (
+                        SELECT
+                            o.id,
+                            o.name,
+                            o."values"
+                        FROM product_options o
+                        WHERE o.product_id = p.id
+                        ORDER BY o.position
+                    ) AS ot
+                ) AS options,
+                (
+                    SELECT
+                        COALESCE(array_to_json(array_agg(vt)), '[]'::json)
+                    FROM (
+                        SELECT
+                            v.id,
+                            v.sku,
+                            v.options,
+                            v.price,
+                            v.stock,
+                            (
+                                SELECT
+                                    COALESCE(array_to_json(array_agg(vit)), '[]'::json)
+                                FROM (
+                                    SELECT
+                                        vi.id,
+                                        vi.filename,
+                                        vi.url
+                                    FROM images vi
+                                    WHERE vi.variant_id = v.id
+                                ) AS vit
+                            ) AS images,
+                            v.created_at,
+                            v.updated_at
+                        FROM product_variants v
+                        WHERE v.product_id = p.id
+                        ORDER BY v.created_at, v.sku
+                    ) AS vt
+                ) AS variants
+            FROM products p`
+
+func (r *productRepository) FindOneProduct(productId string) (*products.Product, error) {
+	query := `
+        SELECT
+            to_jsonb(t)
+        FROM (` + productSelect + `
             WHERE p.id = $1
             LIMIT 1
         ) AS t;
@@ -91,6 +197,29 @@ func (r *productRepository) FindOneProduct(productId string) (*products.Product,
 	return result, nil
 }
 
+// FindProductByIds gives the products with the ids in one query, ids not found are left out
+func (r *productRepository) FindProductByIds(productIds []string) ([]*products.Product, error) {
+	query := `
+        SELECT
+            COALESCE(jsonb_agg(t), '[]'::jsonb)
+        FROM (` + productSelect + `
+            WHERE p.id = ANY($1)
+        ) AS t;
+    `
+	bytesResult := make([]byte, 0)
+	result := make([]*products.Product, 0)
+
+	if err := r.db.Get(&bytesResult, query, productIds); err != nil {
+		return nil, fmt.Errorf("get products failed: %v", err)
+	}
+
+	if err := json.Unmarshal(bytesResult, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal products failed: %v", err)
+	}
+
+	return result, nil
+}
+
 func (r *productRepository) FindProduct(req *products.ProductFilter) ([]*products.Product, int) {
 	builder := productPatterns.FindProductBuilder(r.db, req)
 	engineer := productPatterns.FindProductEngineer(builder)
@@ -100,6 +229,37 @@ func (r *productRepository) FindProduct(req *products.ProductFilter) ([]*product
 	return result, count
 }
 
+// FindProductCursor gives the page in display order and whether more rows follow in the direction it was read
+func (r *productRepository) FindProductCursor(req *products.ProductFilter) ([]*products.Product, bool) {
+	builder := productPatterns.FindProductBuilder(r.db, req)
+	engineer := productPatterns.FindProductEngineer(builder)
+
+	result := engineer.FindProductCursor().Result()
+	hasMore := len(result) > req.Limit
+	if hasMore {
+		result = result[:req.Limit]
+	}
+	if req.After != nil && req.After.Backward {
+		slices.Reverse(result)
+	}
+	return result, hasMore
+}
+
+func (r *productRepository) CountProduct(req *products.ProductFilter) int {
+	builder := productPatterns.FindProductBuilder(r.db, req)
+	return productPatterns.FindProductEngineer(builder).CountProduct().Count()
+}
+
+func (r *productRepository) FindProductFacet(req *products.ProductFilter) *products.ProductFacets {
+	builder := productPatterns.FindProductBuilder(r.db, req)
+	engineer := productPatterns.FindProductEngineer(builder)
+
+	return &products.ProductFacets{
+		Categories: engineer.CategoryFacet().CategoryFacets(),
+		Prices:     engineer.PriceFacet().PriceFacets(),
+	}
+}
+
 func (r *productRepository) InsertProduct(req *products.Product) (*products.Product, error) {
 	builder := productPatterns.InsertProductBuilder(r.db, req)
 	productId, err := productPatterns.InsertProductEngineer(builder).InsertProduct()
@@ -129,23 +289,306 @@ func (r *productRepository) UpdateProduct(req *products.Product) (*products.Prod
 	return product, nil
 }
 
-func (r *productRepository) DeleteProduct(productId string) error {
+// DeleteProduct removes a product, or archives it when an order has it, so the order lines can still be looked up.
+// It reports whether the product was archived
+func (r *productRepository) DeleteProduct(productId string) (bool, error) {
 	ctx := context.Background()
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return false, err
 	}
-	query := `DELETE FROM products WHERE id = $1;`
 
+	// The row lock holds off an order reserving the product while it is checked
+	var isOrdered bool
+	query := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM products_orders
+		WHERE product->>'id' = p.id
+	)
+	FROM products p
+	WHERE p.id = $1
+	FOR UPDATE;`
+	if err := tx.GetContext(ctx, &isOrdered, query, productId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("product %s not found", productId)
+		}
+		return false, fmt.Errorf("check product orders failed: %v", err)
+	}
+
+	query = `DELETE FROM products WHERE id = $1;`
+	if isOrdered {
+		query = `UPDATE products SET status = 'archived' WHERE id = $1;`
+	}
 	if _, err := tx.ExecContext(ctx, query, productId); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("delete product failed: %v", err)
+		return false, fmt.Errorf("delete product failed: %v", err)
 	}
 
 	if err := tx.Commit(); err != nil {
 		tx.Rollback()
-		return err
+		return false, err
+	}
+
+	return isOrdered, nil
+}
+
+// ImportProduct updates the rows with an id and upserts the others by external sku. Every row runs in a savepoint, so a failed row does not hide the errors of
+// the rows after it; in transactional mode the whole import is rolled back if any row failed
+func (r *productRepository) ImportProduct(req *products.ImportReq) (*products.ImportRes, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+
+	res := &products.ImportRes{
+		Mode:   req.Mode,
+		Total:  len(req.Rows) + len(req.Errors),
+		Errors: append(make([]*products.ImportRowError, 0), req.Errors...),
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dropped := make([]*entities.Image, 0)
+	for _, row := range req.Rows {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT "import_row";`); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("savepoint failed: %v", err)
+		}
+
+		inserted, images, err := importRow(ctx, tx, row)
+		if err != nil {
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT "import_row";`); err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("rollback to savepoint failed: %v", err)
+			}
+			res.Errors = append(res.Errors, &products.ImportRowError{
+				Line:        row.Line,
+				ExternalSku: row.ExternalSku,
+				Error:       err.Error(),
+			})
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT "import_row";`); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("release savepoint failed: %v", err)
+		}
+
+		if inserted {
+			res.Inserted++
+		} else {
+			res.Updated++
+		}
+		dropped = append(dropped, images...)
+	}
+	sort.Slice(res.Errors, func(i, j int) bool { return res.Errors[i].Line < res.Errors[j].Line })
+	res.Failed = len(res.Errors)
+
+	if req.Mode != products.ImportPartial && res.Failed > 0 {
+		tx.Rollback()
+		res.Inserted, res.Updated = 0, 0
+		return res, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	res.Committed = true
+
+	// Files of replaced images go only once the rows are gone for good
+	if len(dropped) > 0 {
+		deleteFileReq := make([]*files.DeleteFileReq, 0)
+		for _, img := range dropped {
+			deleteFileReq = append(deleteFileReq, &files.DeleteFileReq{
+				Destination: "images/test/",
+				FileName:    img.FileName,
+			})
+		}
+		if err := r.fileUsecases.DeleteFileOnGCP(deleteFileReq); err != nil {
+			log.Printf("delete replaced images failed: %v\n", err)
+		}
+	}
+	return res, nil
+}
+
+func importRow(ctx context.Context, tx *sqlx.Tx, row *products.ImportRow) (bool, []*entities.Image, error) {
+	categoryIds, err := importCategoryIds(ctx, tx, row.Categories)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// xmax is 0 only for a row this statement inserted
+	var productId string
+	var inserted bool
+	// Imported products are for sale unless the row says otherwise, an empty status keeps the one a product has
+	query := `
+	INSERT INTO "products" (
+		"external_sku",
+		"title",
+		"description",
+		"price",
+		"status"
+	)
+	VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'published')::product_status)
+	ON CONFLICT ("external_sku") DO UPDATE SET
+		"title" = EXCLUDED."title",
+		"description" = EXCLUDED."description",
+		"price" = EXCLUDED."price",
+		"status" = COALESCE(NULLIF($5, '')::product_status, "products"."status")
+	RETURNING "id", ("xmax" = 0) AS "inserted";`
+	if row.Id != "" {
+		// A row with an id updates that product, an empty external_sku keeps the one it has
+		query = `
+		UPDATE "products" SET
+			"external_sku" = COALESCE(NULLIF($1, ''), "external_sku"),
+			"title" = $2,
+			"description" = $3,
+			"price" = $4,
+			"status" = COALESCE(NULLIF($5, '')::product_status, "status")
+		WHERE "id" = $6
+		RETURNING "id", FALSE AS "inserted";`
+		if err := tx.QueryRowxContext(ctx, query, row.ExternalSku, row.Title, row.Description, row.Price, row.Status, row.Id).Scan(&productId, &inserted); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return false, nil, fmt.Errorf("product %s not found", row.Id)
+			}
+			return false, nil, fmt.Errorf("update product failed: %v", err)
+		}
+	} else if err := tx.QueryRowxContext(ctx, query, row.ExternalSku, row.Title, row.Description, row.Price, row.Status).Scan(&productId, &inserted); err != nil {
+		return false, nil, fmt.Errorf("upsert product failed: %v", err)
+	}
+
+	if err := productPatterns.ReplaceCategories(ctx, tx, productId, categoryIds); err != nil {
+		return false, nil, err
+	}
+
+	// An empty image_urls keeps the images of the product
+	dropped := make([]*entities.Image, 0)
+	if len(row.ImageUrls) == 0 {
+		return inserted, dropped, nil
+	}
+
+	// Images that are still listed keep their rows
+	query = `
+	DELETE FROM "images"
+	WHERE "product_id" = $1
+	AND "variant_id" IS NULL
+	AND NOT ("url" = ANY($2))
+	RETURNING "id", "filename", "url";`
+	if err := tx.SelectContext(ctx, &dropped, query, productId, row.ImageUrls); err != nil {
+		return false, nil, fmt.Errorf("delete images failed: %v", err)
+	}
+
+	query = `
+	INSERT INTO "images" (
+		"filename",
+		"url",
+		"product_id"
+	)
+	SELECT $1, $2, $3
+	WHERE NOT EXISTS (
+		SELECT 1
+		FROM "images"
+		WHERE "product_id" = $3
+		AND "variant_id" IS NULL
+		AND "url" = $2
+	);`
+	for _, url := range row.ImageUrls {
+		if _, err := tx.ExecContext(ctx, query, path.Base(url), url, productId); err != nil {
+			return false, nil, fmt.Errorf("insert images failed: %v", err)
+		}
+	}
+	return inserted, dropped, nil
+}
+
+// importCategoryIds resolves category ids or slugs, titles are not unique once categories are nested
+func importCategoryIds(ctx context.Context, tx *sqlx.Tx, categories []string) ([]int, error) {
+	query := `
+	SELECT
+		"id",
+		"slug"
+	FROM "categories"
+	WHERE "id"::TEXT = ANY($1)
+	OR "slug" = ANY($1);`
+	rows, err := tx.QueryxContext(ctx, query, categories)
+	if err != nil {
+		return nil, fmt.Errorf("get categories failed: %v", err)
 	}
+	defer rows.Close()
 
+	// A category is found by its id as well as by its slug
+	found := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return nil, fmt.Errorf("scan categories failed: %v", err)
+		}
+		found[strconv.Itoa(id)] = id
+		found[slug] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get categories failed: %v", err)
+	}
+
+	categoryIds := make([]int, 0, len(categories))
+	for _, category := range categories {
+		id, ok := found[category]
+		if !ok {
+			return nil, fmt.Errorf("category %s not found", category)
+		}
+		categoryIds = append(categoryIds, id)
+	}
+	return categoryIds, nil
+}
+
+func (r *productRepository) ExportProduct(fn func(*products.ProductExportRow) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+
+	query := `
+	SELECT
+		COALESCE(p."external_sku", '') AS "external_sku",
+		p."title",
+		p."description",
+		p."price",
+		COALESCE((
+			SELECT
+				string_agg(c."slug", '|' ORDER BY c."sort_order", c."id")
+			FROM "categories" c
+			JOIN "products_categories" pc ON pc."category_id" = c."id"
+			WHERE pc."product_id" = p."id"
+		), '') AS "categories",
+		COALESCE((
+			SELECT
+				string_agg(i."url", '|' ORDER BY i."created_at", i."id")
+			FROM "images" i
+			WHERE i."product_id" = p."id"
+			AND i."variant_id" IS NULL
+		), '') AS "image_urls",
+		p."status"::TEXT AS "status",
+		p."id"
+	FROM "products" p
+	ORDER BY p."id";`
+
+	rows, err := r.db.QueryxContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("export products failed: %v", err)
+	}
+	defer rows.Close()
+
+	// Rows are handed over one by one, so the whole catalog never sits in memory
+	for rows.Next() {
+		row := new(products.ProductExportRow)
+		if err := rows.StructScan(row); err != nil {
+			return fmt.Errorf("scan export product failed: %v", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("export products failed: %v", err)
+	}
 	return nil
 }
@@ -0,0 +1,190 @@
+package productUsecases
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go_learn_project_rest_api/modules/products"
+	"io"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maxImportRows = 5000
+
+// ImportProductCsv reads the whole file before anything is written, rows that can not be parsed are reported with the others
+func (u *productUsecases) ImportProductCsv(r io.Reader, mode string) (*products.ImportRes, error) {
+	rows, rowErrors, err := parseImportCsv(r)
+	if err != nil {
+		return nil, err
+	}
+	return u.productRepositories.ImportProduct(&products.ImportReq{
+		Mode:   mode,
+		Rows:   rows,
+		Errors: rowErrors,
+	})
+}
+
+func (u *productUsecases) ExportProductCsv(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(products.ProductCsvHeader); err != nil {
+		return fmt.Errorf("write csv header failed: %v", err)
+	}
+
+	if err := u.productRepositories.ExportProduct(func(row *products.ProductExportRow) error {
+		return writer.Write([]string{
+			row.ExternalSku,
+			row.Title,
+			row.Description,
+			strconv.FormatFloat(row.Price, 'f', -1, 64),
+			row.Categories,
+			row.ImageUrls,
+			row.Status,
+			row.Id,
+		})
+	}); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func parseImportCsv(r io.Reader) ([]*products.ImportRow, []*products.ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read csv header failed: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		// Spreadsheet apps may save a BOM in front of the first column
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"title", "price", "category"} {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("column %s is missing", name)
+		}
+	}
+	_, hasSku := columns["external_sku"]
+	_, hasId := columns["id"]
+	if !hasSku && !hasId {
+		return nil, nil, fmt.Errorf("column external_sku is missing")
+	}
+	get := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]*products.ImportRow, 0)
+	rowErrors := make([]*products.ImportRowError, 0)
+	skus := make(map[string]int)
+	ids := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(rows)+len(rowErrors) >= maxImportRows {
+			return nil, nil, fmt.Errorf("csv must not have more than %d rows", maxImportRows)
+		}
+
+		if err != nil {
+			// A malformed row is reported, the reader goes on with the next one
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("read csv failed: %v", err)
+			}
+			rowErrors = append(rowErrors, &products.ImportRowError{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row, err := parseImportRow(line, record, get)
+		if err == nil {
+			if first, ok := skus[row.ExternalSku]; ok && row.ExternalSku != "" {
+				err = fmt.Errorf("external_sku is already used on line %d", first)
+			} else if first, ok := ids[row.Id]; ok && row.Id != "" {
+				err = fmt.Errorf("id is already used on line %d", first)
+			}
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, &products.ImportRowError{
+				Line:        line,
+				ExternalSku: get(record, "external_sku"),
+				Error:       err.Error(),
+			})
+			continue
+		}
+		if row.ExternalSku != "" {
+			skus[row.ExternalSku] = line
+		}
+		if row.Id != "" {
+			ids[row.Id] = line
+		}
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+func parseImportRow(line int, record []string, get func([]string, string) string) (*products.ImportRow, error) {
+	row := &products.ImportRow{
+		Line:        line,
+		Id:          get(record, "id"),
+		ExternalSku: get(record, "external_sku"),
+		Title:       get(record, "title"),
+		Description: get(record, "description"),
+		Categories:  make([]string, 0),
+		ImageUrls:   make([]string, 0),
+		Status:      strings.ToLower(get(record, "status")),
+	}
+	if row.ExternalSku == "" && row.Id == "" {
+		return nil, fmt.Errorf("external_sku or id is required")
+	}
+	if row.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	for _, category := range strings.Split(get(record, "category"), "|") {
+		category = strings.TrimSpace(category)
+		if category != "" && !slices.Contains(row.Categories, category) {
+			row.Categories = append(row.Categories, category)
+		}
+	}
+	if len(row.Categories) == 0 {
+		return nil, fmt.Errorf("category is required")
+	}
+	if row.Status != "" && !products.IsProductStatus(row.Status) {
+		return nil, fmt.Errorf("status %s is invalid", row.Status)
+	}
+
+	price, err := strconv.ParseFloat(get(record, "price"), 64)
+	if err != nil || math.IsNaN(price) || math.IsInf(price, 0) || price < 0 {
+		return nil, fmt.Errorf("price is invalid")
+	}
+	row.Price = price
+
+	for _, raw := range strings.Split(get(record, "image_urls"), "|") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.ParseRequestURI(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("image url %s is invalid", raw)
+		}
+		row.ImageUrls = append(row.ImageUrls, raw)
+	}
+	return row, nil
+}
@@ -4,15 +4,22 @@ import (
 	"go_learn_project_rest_api/modules/entities"
 	"go_learn_project_rest_api/modules/products"
 	"go_learn_project_rest_api/modules/products/productRepositories"
+	"io"
 	"math"
 )
 
 type IProductUsecases interface {
 	FindOneProduct(string) (*products.Product, error)
-	FindProduct(*products.ProductFilter) *entities.PaginateRes
+	FindProduct(*products.ProductFilter) *products.ProductPaginateRes
+	FindProductCursor(*products.ProductFilter) *products.ProductCursorRes
+	ImportProductCsv(r io.Reader, mode string) (*products.ImportRes, error)
+	ExportProductCsv(w io.Writer) error
 	AddProduct(*products.Product) (*products.Product, error)
 	UpdateProduct(*products.Product) (*products.Product, error)
-	DeleteProduct(string) error
+	DeleteProduct(string) (bool, error)
+	FindPriceTimeline(productId string) (*products.PriceTimeline, error)
+	InsertScheduledPrice(*products.ScheduledPrice) (*products.PriceTimeline, error)
+	DeleteScheduledPrice(productId, scheduleId string) (*products.PriceTimeline, error)
 }
 
 type productUsecases struct {
@@ -34,26 +41,98 @@ func (u *productUsecases) FindOneProduct(productId string) (*products.Product, e
 	return product, nil
 }
 
-func (u *productUsecases) FindProduct(req *products.ProductFilter) *entities.PaginateRes {
-	products, count := u.productRepositories.FindProduct(req)
-	return &entities.PaginateRes{
-		Data:      products,
-		Page:      req.Page,
-		Limit:     req.Limit,
-		TotalPage: int(math.Ceil(float64(count) / float64(req.Limit))),
-		TotalItem: count,
+func (u *productUsecases) FindProduct(req *products.ProductFilter) *products.ProductPaginateRes {
+	productsData, count := u.productRepositories.FindProduct(req)
+	res := &products.ProductPaginateRes{
+		PaginateRes: &entities.PaginateRes{
+			Data:      productsData,
+			Page:      req.Page,
+			Limit:     req.Limit,
+			TotalPage: int(math.Ceil(float64(count) / float64(req.Limit))),
+			TotalItem: count,
+		},
 	}
+	// Facets are two more queries over the whole filter, only the pages showing them ask
+	if req.Facets {
+		res.Facets = u.productRepositories.FindProductFacet(req)
+	}
+	return res
+}
+
+func (u *productUsecases) FindProductCursor(req *products.ProductFilter) *products.ProductCursorRes {
+	productsData, hasMore := u.productRepositories.FindProductCursor(req)
+	next, prev := entities.CursorPage(req.CursorReq, req.SortReq, len(productsData), hasMore, func(i int) (string, string) {
+		return productsData[i].CursorValue(req.OrderBy), productsData[i].Id
+	})
 
+	res := &products.ProductCursorRes{
+		CursorPaginateRes: &entities.CursorPaginateRes{
+			Data:       productsData,
+			Limit:      req.Limit,
+			NextCursor: next,
+			PrevCursor: prev,
+		},
+	}
+	// Counting is what makes deep listings slow, skip_count leaves out the total and the facets
+	if !req.SkipCount {
+		count := u.productRepositories.CountProduct(req)
+		res.TotalItem = &count
+		if req.Facets {
+			res.Facets = u.productRepositories.FindProductFacet(req)
+		}
+	}
+	return res
 }
 
 func (u *productUsecases) AddProduct(req *products.Product) (*products.Product, error) {
+	if err := products.ValidateVariants(req.Options, req.Variants); err != nil {
+		return nil, err
+	}
 	return u.productRepositories.InsertProduct(req)
 }
 
 func (u *productUsecases) UpdateProduct(req *products.Product) (*products.Product, error) {
+	// Options and variants are checked together, the side left out of the request is the stored one
+	if req.Options != nil || req.Variants != nil {
+		old, err := u.productRepositories.FindOneProduct(req.Id)
+		if err != nil {
+			return nil, err
+		}
+		options, variants := req.Options, req.Variants
+		if options == nil {
+			options = old.Options
+		}
+		if variants == nil {
+			variants = old.Variants
+		}
+		if err := products.ValidateVariants(options, variants); err != nil {
+			return nil, err
+		}
+	}
 	return u.productRepositories.UpdateProduct(req)
 }
 
-func (u *productUsecases) DeleteProduct(productId string) error {
+func (u *productUsecases) DeleteProduct(productId string) (bool, error) {
 	return u.productRepositories.DeleteProduct(productId)
 }
+
+func (u *productUsecases) FindPriceTimeline(productId string) (*products.PriceTimeline, error) {
+	return u.productRepositories.FindPriceTimeline(productId)
+}
+
+func (u *productUsecases) InsertScheduledPrice(req *products.ScheduledPrice) (*products.PriceTimeline, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := u.productRepositories.InsertScheduledPrice(req); err != nil {
+		return nil, err
+	}
+	return u.productRepositories.FindPriceTimeline(req.ProductId)
+}
+
+func (u *productUsecases) DeleteScheduledPrice(productId, scheduleId string) (*products.PriceTimeline, error) {
+	if err := u.productRepositories.DeleteScheduledPrice(productId, scheduleId); err != nil {
+		return nil, err
+	}
+	return u.productRepositories.FindPriceTimeline(productId)
+}
@@ -0,0 +1,119 @@
+package promotionHandlers
+
+import (
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/promotions"
+	"go_learn_project_rest_api/modules/promotions/promotionUsecases"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type promotionHandlersErrCode string
+
+const (
+	findCouponErr       promotionHandlersErrCode = "promotions-001"
+	findOneCouponErr    promotionHandlersErrCode = "promotions-002"
+	insertCouponErr     promotionHandlersErrCode = "promotions-003"
+	deactivateCouponErr promotionHandlersErrCode = "promotions-004"
+)
+
+type IPromotionHandlers interface {
+	FindCoupon(fiber.Ctx) error
+	FindOneCoupon(fiber.Ctx) error
+	InsertCoupon(fiber.Ctx) error
+	DeactivateCoupon(fiber.Ctx) error
+}
+
+type promotionHandlers struct {
+	cfg               config.IConfig
+	promotionUsecases promotionUsecases.IPromotionUsecases
+}
+
+func PromotionHandlers(cfg config.IConfig, promotionUsecases promotionUsecases.IPromotionUsecases) IPromotionHandlers {
+	return &promotionHandlers{
+		cfg:               cfg,
+		promotionUsecases: promotionUsecases,
+	}
+}
+
+func (h *promotionHandlers) FindCoupon(c fiber.Ctx) error {
+	req := new(promotions.CouponFilter)
+	if err := c.Bind().Query(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findCouponErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.promotionUsecases.FindCoupon(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findCouponErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *promotionHandlers) FindOneCoupon(c fiber.Ctx) error {
+	couponId := strings.Trim(c.Params("coupon_id"), " ")
+
+	result, err := h.promotionUsecases.FindOneCoupon(couponId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findOneCouponErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *promotionHandlers) InsertCoupon(c fiber.Ctx) error {
+	req := &promotions.Coupon{
+		ProductIds:  make([]string, 0),
+		CategoryIds: make([]int, 0),
+	}
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertCouponErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertCouponErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.promotionUsecases.InsertCoupon(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(insertCouponErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, result).Res()
+}
+
+func (h *promotionHandlers) DeactivateCoupon(c fiber.Ctx) error {
+	couponId := strings.Trim(c.Params("coupon_id"), " ")
+
+	if err := h.promotionUsecases.DeactivateCoupon(couponId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(deactivateCouponErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusNoContent, nil).Res()
+}
@@ -0,0 +1,201 @@
+package promotionRepositories
+
+import (
+	"context"
+	"fmt"
+	"go_learn_project_rest_api/modules/promotions"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const couponColumns = `
+		c.id,
+		c.code,
+		c.type::TEXT AS type,
+		c.value,
+		c.min_spend,
+		c.max_discount,
+		c.usage_limit,
+		c.usage_limit_per_user,
+		(
+			SELECT
+				COUNT(*)
+			FROM coupon_redemptions cr
+			WHERE cr.coupon_id = c.id
+		) AS used_count,
+		c.started_at,
+		c.expired_at,
+		c.is_active,
+		c.created_at::TEXT AS created_at,
+		c.updated_at::TEXT AS updated_at`
+
+type IPromotionRepository interface {
+	FindCoupon(*promotions.CouponFilter) ([]*promotions.Coupon, error)
+	FindOneCoupon(string) (*promotions.Coupon, error)
+	InsertCoupon(*promotions.Coupon) (string, error)
+	DeactivateCoupon(string) error
+}
+
+type promotionRepository struct {
+	db *sqlx.DB
+}
+
+func PromotionRepository(db *sqlx.DB) IPromotionRepository {
+	return &promotionRepository{
+		db: db,
+	}
+}
+
+func (r *promotionRepository) findRestrictions(coupon *promotions.Coupon) error {
+	coupon.ProductIds = make([]string, 0)
+	if err := r.db.Select(
+		&coupon.ProductIds,
+		`SELECT "product_id" FROM "coupons_products" WHERE "coupon_id" = $1;`,
+		coupon.Id,
+	); err != nil {
+		return fmt.Errorf("get coupon products failed: %v", err)
+	}
+
+	coupon.CategoryIds = make([]int, 0)
+	if err := r.db.Select(
+		&coupon.CategoryIds,
+		`SELECT "category_id" FROM "coupons_categories" WHERE "coupon_id" = $1;`,
+		coupon.Id,
+	); err != nil {
+		return fmt.Errorf("get coupon categories failed: %v", err)
+	}
+	return nil
+}
+
+func (r *promotionRepository) FindCoupon(req *promotions.CouponFilter) ([]*promotions.Coupon, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM coupons c`, couponColumns)
+
+	values := make([]any, 0)
+	if req.Code != "" {
+		values = append(values, "%"+strings.ToUpper(req.Code)+"%")
+		query += `
+	WHERE c.code LIKE $1`
+	}
+	query += `
+	ORDER BY c.created_at DESC;`
+
+	coupons := make([]*promotions.Coupon, 0)
+	if err := r.db.Select(&coupons, query, values...); err != nil {
+		return nil, fmt.Errorf("get coupons failed: %v", err)
+	}
+	for _, coupon := range coupons {
+		if err := r.findRestrictions(coupon); err != nil {
+			return nil, err
+		}
+	}
+	return coupons, nil
+}
+
+func (r *promotionRepository) FindOneCoupon(couponId string) (*promotions.Coupon, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM coupons c
+	WHERE c.id = $1;`, couponColumns)
+
+	coupon := new(promotions.Coupon)
+	if err := r.db.Get(coupon, query, couponId); err != nil {
+		return nil, fmt.Errorf("get coupon failed: %v", err)
+	}
+	if err := r.findRestrictions(coupon); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+func (r *promotionRepository) InsertCoupon(req *promotions.Coupon) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	query := `
+	INSERT INTO "coupons" (
+		"code",
+		"type",
+		"value",
+		"min_spend",
+		"max_discount",
+		"usage_limit",
+		"usage_limit_per_user",
+		"started_at",
+		"expired_at"
+	)
+	VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING "id";`
+
+	if err := tx.QueryRowxContext(
+		ctx,
+		query,
+		req.Code,
+		req.Type,
+		req.Value,
+		req.MinSpend,
+		req.MaxDiscount,
+		req.UsageLimit,
+		req.UsageLimitPerUser,
+		req.StartedAt,
+		req.ExpiredAt,
+	).Scan(&req.Id); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("insert coupon failed: %v", err)
+	}
+
+	for _, productId := range req.ProductIds {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO "coupons_products" ("coupon_id", "product_id") VALUES ($1, $2);`,
+			req.Id,
+			productId,
+		); err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("insert coupons_products failed: %v", err)
+		}
+	}
+	for _, categoryId := range req.CategoryIds {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO "coupons_categories" ("coupon_id", "category_id") VALUES ($1, $2);`,
+			req.Id,
+			categoryId,
+		); err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("insert coupons_categories failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	return req.Id, nil
+}
+
+// DeactivateCoupon keeps the coupon row, redemptions of past orders still point to it
+func (r *promotionRepository) DeactivateCoupon(couponId string) error {
+	query := `
+	UPDATE "coupons" SET
+		"is_active" = FALSE
+	WHERE "id" = $1;`
+
+	result, err := r.db.ExecContext(context.Background(), query, couponId)
+	if err != nil {
+		return fmt.Errorf("deactivate coupon failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("coupon not found")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package promotionUsecases
+
+import (
+	"go_learn_project_rest_api/modules/promotions"
+	"go_learn_project_rest_api/modules/promotions/promotionRepositories"
+)
+
+type IPromotionUsecases interface {
+	FindCoupon(*promotions.CouponFilter) ([]*promotions.Coupon, error)
+	FindOneCoupon(string) (*promotions.Coupon, error)
+	InsertCoupon(*promotions.Coupon) (*promotions.Coupon, error)
+	DeactivateCoupon(string) error
+}
+
+type promotionUsecases struct {
+	promotionRepository promotionRepositories.IPromotionRepository
+}
+
+func PromotionUsecases(promotionRepository promotionRepositories.IPromotionRepository) IPromotionUsecases {
+	return &promotionUsecases{
+		promotionRepository: promotionRepository,
+	}
+}
+
+func (u *promotionUsecases) FindCoupon(req *promotions.CouponFilter) ([]*promotions.Coupon, error) {
+	return u.promotionRepository.FindCoupon(req)
+}
+
+func (u *promotionUsecases) FindOneCoupon(couponId string) (*promotions.Coupon, error) {
+	return u.promotionRepository.FindOneCoupon(couponId)
+}
+
+func (u *promotionUsecases) InsertCoupon(req *promotions.Coupon) (*promotions.Coupon, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	couponId, err := u.promotionRepository.InsertCoupon(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.promotionRepository.FindOneCoupon(couponId)
+}
+
+func (u *promotionUsecases) DeactivateCoupon(couponId string) error {
+	return u.promotionRepository.DeactivateCoupon(couponId)
+}
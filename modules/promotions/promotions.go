@@ -0,0 +1,192 @@
+package promotions
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	Percentage = "percentage"
+	Fixed      = "fixed"
+)
+
+type Coupon struct {
+	Id                string     `db:"id" json:"id"`
+	Code              string     `db:"code" json:"code"`
+	Type              string     `db:"type" json:"type"`
+	Value             float64    `db:"value" json:"value"`
+	MinSpend          float64    `db:"min_spend" json:"min_spend"`
+	MaxDiscount       float64    `db:"max_discount" json:"max_discount"`                 // 0 is no cap
+	UsageLimit        int        `db:"usage_limit" json:"usage_limit"`                   // 0 is unlimited
+	UsageLimitPerUser int        `db:"usage_limit_per_user" json:"usage_limit_per_user"` // 0 is unlimited
+	UsedCount         int        `db:"used_count" json:"used_count"`
+	StartedAt         *time.Time `db:"started_at" json:"started_at"`
+	ExpiredAt         *time.Time `db:"expired_at" json:"expired_at"`
+	IsActive          bool       `db:"is_active" json:"is_active"`
+	ProductIds        []string   `json:"product_ids"`
+	CategoryIds       []int      `json:"category_ids"`
+	CreatedAt         string     `db:"created_at" json:"created_at"`
+	UpdatedAt         string     `db:"updated_at" json:"updated_at"`
+}
+
+type CouponFilter struct {
+	Code string `query:"code"`
+}
+
+// CouponError is a coupon the order can not use, it is the customer's mistake and not a failure of the server
+type CouponError struct {
+	msg string
+}
+
+func (e *CouponError) Error() string { return e.msg }
+
+func CouponErrorf(format string, a ...any) error {
+	return &CouponError{msg: fmt.Sprintf(format, a...)}
+}
+
+// CouponLine is one order line the coupon is checked against
+type CouponLine struct {
+	ProductId   string
+	CategoryIds []int
+	Price       float64
+	Qty         int
+}
+
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (c *Coupon) Validate() error {
+	c.Code = NormalizeCode(c.Code)
+	if c.Code == "" {
+		return fmt.Errorf("code is required")
+	}
+
+	switch c.Type {
+	case Percentage:
+		if c.Value <= 0 || c.Value > 100 {
+			return fmt.Errorf("percentage value must be between 0 and 100")
+		}
+	case Fixed:
+		if c.Value <= 0 {
+			return fmt.Errorf("fixed value must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("coupon type is invalid")
+	}
+
+	if c.MinSpend < 0 || c.MaxDiscount < 0 || c.UsageLimit < 0 || c.UsageLimitPerUser < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	if c.StartedAt != nil && c.ExpiredAt != nil && !c.ExpiredAt.After(*c.StartedAt) {
+		return fmt.Errorf("expired_at must be after started_at")
+	}
+	return nil
+}
+
+// CheckAvailable checks the active flag, validity window and usage limits
+func (c *Coupon) CheckAvailable(now time.Time, used, usedByUser int) error {
+	if !c.IsActive {
+		return CouponErrorf("coupon %s is not active", c.Code)
+	}
+	if c.StartedAt != nil && now.Before(*c.StartedAt) {
+		return CouponErrorf("coupon %s is not started yet", c.Code)
+	}
+	if c.ExpiredAt != nil && !now.Before(*c.ExpiredAt) {
+		return CouponErrorf("coupon %s is expired", c.Code)
+	}
+	if c.UsageLimit > 0 && used >= c.UsageLimit {
+		return CouponErrorf("coupon %s is fully redeemed", c.Code)
+	}
+	if c.UsageLimitPerUser > 0 && usedByUser >= c.UsageLimitPerUser {
+		return CouponErrorf("coupon %s usage limit per user is reached", c.Code)
+	}
+	return nil
+}
+
+func (c *Coupon) isEligible(line *CouponLine) bool {
+	// No restriction means every product is eligible
+	if len(c.ProductIds) == 0 && len(c.CategoryIds) == 0 {
+		return true
+	}
+	for _, id := range c.ProductIds {
+		if id == line.ProductId {
+			return true
+		}
+	}
+	for _, id := range c.CategoryIds {
+		for _, lineCategoryId := range line.CategoryIds {
+			if id == lineCategoryId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CalculateDiscount returns the discount of the order lines, min spend is checked against the whole order
+func (c *Coupon) CalculateDiscount(lines []*CouponLine) (float64, error) {
+	var subtotal, eligible float64
+	for _, line := range lines {
+		amount := line.Price * float64(line.Qty)
+		subtotal += amount
+		if c.isEligible(line) {
+			eligible += amount
+		}
+	}
+
+	if subtotal < c.MinSpend {
+		return 0, CouponErrorf("coupon %s requires a minimum spend of %.2f", c.Code, c.MinSpend)
+	}
+	if eligible == 0 {
+		return 0, CouponErrorf("coupon %s is not applicable to these products", c.Code)
+	}
+
+	var discount float64
+	switch c.Type {
+	case Percentage:
+		discount = eligible * c.Value / 100
+	case Fixed:
+		discount = c.Value
+	}
+	if c.MaxDiscount > 0 && discount > c.MaxDiscount {
+		discount = c.MaxDiscount
+	}
+	if discount > eligible {
+		discount = eligible
+	}
+	return math.Round(discount*100) / 100, nil
+}
+
+// SpreadDiscount splits the discount over the eligible lines by their amount, so every line is taxed on what is paid for it.
+// The rounding rest goes to the last eligible line, the shares always add up to the discount
+func (c *Coupon) SpreadDiscount(lines []*CouponLine, discount float64) []float64 {
+	shares := make([]float64, len(lines))
+	var eligible float64
+	last := -1
+	for i, line := range lines {
+		if c.isEligible(line) {
+			eligible += line.Price * float64(line.Qty)
+			last = i
+		}
+	}
+	if last < 0 || eligible == 0 {
+		return shares
+	}
+
+	var spread float64
+	for i, line := range lines {
+		if !c.isEligible(line) {
+			continue
+		}
+		if i == last {
+			shares[i] = math.Round((discount-spread)*100) / 100
+			break
+		}
+		shares[i] = math.Round(discount*line.Price*float64(line.Qty)/eligible*100) / 100
+		spread += shares[i]
+	}
+	return shares
+}
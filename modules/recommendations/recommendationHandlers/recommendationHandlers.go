@@ -0,0 +1,63 @@
+package recommendationHandlers
+
+import (
+	"errors"
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/recommendations"
+	"go_learn_project_rest_api/modules/recommendations/recommendationUsecases"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type recommendationHandlersErrCode string
+
+const (
+	findRecommendationErr recommendationHandlersErrCode = "recommendations-001"
+)
+
+type IRecommendationHandlers interface {
+	FindRecommendation(fiber.Ctx) error
+}
+
+type recommendationHandlers struct {
+	cfg                    config.IConfig
+	recommendationUsecases recommendationUsecases.IRecommendationUsecases
+}
+
+func RecommendationHandlers(cfg config.IConfig, recommendationUsecases recommendationUsecases.IRecommendationUsecases) IRecommendationHandlers {
+	return &recommendationHandlers{
+		cfg:                    cfg,
+		recommendationUsecases: recommendationUsecases,
+	}
+}
+
+func (h *recommendationHandlers) FindRecommendation(c fiber.Ctx) error {
+	req := new(recommendations.RecommendationFilter)
+	if err := c.Bind().Query(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findRecommendationErr),
+			err.Error(),
+		).Res()
+	}
+	if req.Limit < 1 || req.Limit > 20 {
+		req.Limit = 8
+	}
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	result, err := h.recommendationUsecases.FindRecommendation(productId, req)
+	if err != nil {
+		code := fiber.ErrInternalServerError.Code
+		if errors.Is(err, recommendations.ErrProductNotFound) {
+			code = fiber.ErrNotFound.Code
+		}
+		return entities.NewResponse(c).Error(
+			code,
+			string(findRecommendationErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
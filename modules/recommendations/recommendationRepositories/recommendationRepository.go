@@ -0,0 +1,162 @@
+package recommendationRepositories
+
+import (
+	"context"
+	"fmt"
+	"go_learn_project_rest_api/modules/orders"
+	"go_learn_project_rest_api/modules/recommendations"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Only products a customer can order now are recommended
+const visibleProduct = `p.status = 'published' AND COALESCE(p.publish_at <= now(), TRUE)`
+
+type IRecommendationRepository interface {
+	RefreshCoPurchase(ctx context.Context) (int64, error)
+	FindBoughtTogether(productId string, limit int) ([]*recommendations.Recommendation, error)
+	FindSameCategory(productId string, limit int) ([]*recommendations.Recommendation, error)
+	FindBestSeller(limit int) ([]*recommendations.Recommendation, error)
+}
+
+type recommendationRepository struct {
+	db *sqlx.DB
+}
+
+func RecommendationRepository(db *sqlx.DB) IRecommendationRepository {
+	return &recommendationRepository{
+		db: db,
+	}
+}
+
+// RefreshCoPurchase rebuilds the co-purchase counts from the paid orders and gives the number of pairs.
+// Readers keep the old counts until the transaction commits
+func (r *recommendationRepository) RefreshCoPurchase(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute*5)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// Two refreshes at once would insert the same pairs, reads are still allowed
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE "product_co_purchases" IN EXCLUSIVE MODE;`); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("lock co-purchases failed: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "product_co_purchases";`); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("clear co-purchases failed: %v", err)
+	}
+
+	// A product counts once per order whatever its qty, fully refunded lines and deleted products are left out.
+	// A refund takes qty down, so qty is what the customer kept
+	query := fmt.Sprintf(`
+	WITH "lines" AS (
+		SELECT DISTINCT
+			po.order_id,
+			po.product->>'id' AS product_id
+		FROM products_orders po
+		JOIN orders o ON o.id = po.order_id
+		WHERE %s
+		AND po.qty > 0
+		AND EXISTS (SELECT 1 FROM products p WHERE p.id = po.product->>'id')
+	)
+	INSERT INTO "product_co_purchases" (
+		"product_id",
+		"related_id",
+		"orders_count"
+	)
+	SELECT
+		a.product_id,
+		b.product_id,
+		COUNT(*)
+	FROM "lines" a
+	JOIN "lines" b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+	GROUP BY a.product_id, b.product_id;`, orders.PaidOrderStatus)
+
+	result, err := tx.ExecContext(ctx, query)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("insert co-purchases failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
+func (r *recommendationRepository) FindBoughtTogether(productId string, limit int) ([]*recommendations.Recommendation, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		cp.related_id AS product_id,
+		'%s' AS reason,
+		cp.orders_count AS score
+	FROM product_co_purchases cp
+	JOIN products p ON p.id = cp.related_id
+	WHERE cp.product_id = $1
+	AND %s
+	ORDER BY cp.orders_count DESC, cp.related_id
+	LIMIT $2;`, recommendations.ReasonBoughtTogether, visibleProduct)
+
+	result := make([]*recommendations.Recommendation, 0)
+	if err := r.db.Select(&result, query, productId, limit); err != nil {
+		return nil, fmt.Errorf("get bought together failed: %v", err)
+	}
+	return result, nil
+}
+
+// FindSameCategory ranks the products by how many categories they share with the product, newest first on a tie
+func (r *recommendationRepository) FindSameCategory(productId string, limit int) ([]*recommendations.Recommendation, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		pc.product_id,
+		'%s' AS reason,
+		COUNT(*) AS score
+	FROM products_categories pc
+	JOIN products p ON p.id = pc.product_id
+	WHERE pc.category_id IN (
+		SELECT category_id
+		FROM products_categories
+		WHERE product_id = $1
+	)
+	AND pc.product_id <> $1
+	AND %s
+	GROUP BY pc.product_id, p.created_at
+	ORDER BY score DESC, p.created_at DESC, pc.product_id
+	LIMIT $2;`, recommendations.ReasonSameCategory, visibleProduct)
+
+	result := make([]*recommendations.Recommendation, 0)
+	if err := r.db.Select(&result, query, productId, limit); err != nil {
+		return nil, fmt.Errorf("get same category failed: %v", err)
+	}
+	return result, nil
+}
+
+// FindBestSeller ranks the products by units sold in the last 90 days, for products with nothing related
+func (r *recommendationRepository) FindBestSeller(limit int) ([]*recommendations.Recommendation, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		p.id AS product_id,
+		'%s' AS reason,
+		SUM(po.qty) AS score
+	FROM orders o
+	JOIN products_orders po ON po.order_id = o.id
+	JOIN products p ON p.id = po.product->>'id'
+	WHERE %s
+	AND o.created_at >= now() - INTERVAL '90 days'
+	AND po.qty > 0
+	AND %s
+	GROUP BY p.id
+	ORDER BY score DESC, p.id
+	LIMIT $1;`, recommendations.ReasonBestSeller, orders.PaidOrderStatus, visibleProduct)
+
+	result := make([]*recommendations.Recommendation, 0)
+	if err := r.db.Select(&result, query, limit); err != nil {
+		return nil, fmt.Errorf("get best seller failed: %v", err)
+	}
+	return result, nil
+}
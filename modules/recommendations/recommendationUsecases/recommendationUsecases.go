@@ -0,0 +1,107 @@
+package recommendationUsecases
+
+import (
+	"context"
+	"go_learn_project_rest_api/modules/products"
+	"go_learn_project_rest_api/modules/products/productRepositories"
+	"go_learn_project_rest_api/modules/recommendations"
+	"go_learn_project_rest_api/modules/recommendations/recommendationRepositories"
+)
+
+type IRecommendationUsecases interface {
+	FindRecommendation(productId string, req *recommendations.RecommendationFilter) (*recommendations.RecommendationRes, error)
+	RefreshCoPurchase(ctx context.Context) (int64, error)
+}
+
+type recommendationUsecases struct {
+	recommendationRepository recommendationRepositories.IRecommendationRepository
+	productRepository        productRepositories.IProductRepository
+}
+
+func RecommendationUsecases(recommendationRepository recommendationRepositories.IRecommendationRepository, productRepository productRepositories.IProductRepository) IRecommendationUsecases {
+	return &recommendationUsecases{
+		recommendationRepository: recommendationRepository,
+		productRepository:        productRepository,
+	}
+}
+
+// FindRecommendation gives the products bought together with the product and the related ones.
+// Related products share a category, when there are not enough of them the best sellers fill the rest,
+// so a new product with no orders and no category still gets recommendations
+func (u *recommendationUsecases) FindRecommendation(productId string, req *recommendations.RecommendationFilter) (*recommendations.RecommendationRes, error) {
+	prods, err := u.productRepository.FindProductByIds([]string{productId})
+	if err != nil {
+		return nil, err
+	}
+	if len(prods) == 0 || !prods[0].Visible {
+		return nil, recommendations.ErrProductNotFound
+	}
+
+	together, err := u.recommendationRepository.FindBoughtTogether(productId, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{productId: true}
+	res := &recommendations.RecommendationRes{
+		ProductId:      productId,
+		BoughtTogether: recommendations.Fill(req.Limit, seen, together),
+	}
+
+	// The bought together products are left out of related, so ask for that many more
+	sameCategory, err := u.recommendationRepository.FindSameCategory(productId, req.Limit+len(res.BoughtTogether))
+	if err != nil {
+		return nil, err
+	}
+	res.Related = recommendations.Fill(req.Limit, seen, sameCategory)
+	if len(res.Related) < req.Limit {
+		bestSeller, err := u.recommendationRepository.FindBestSeller(req.Limit + len(seen))
+		if err != nil {
+			return nil, err
+		}
+		res.Related = append(res.Related, recommendations.Fill(req.Limit-len(res.Related), seen, bestSeller)...)
+	}
+
+	if err := u.attachProduct(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// attachProduct loads the products of both lists in one query, a product deleted since it was ranked is dropped
+func (u *recommendationUsecases) attachProduct(res *recommendations.RecommendationRes) error {
+	productIds := make([]string, 0, len(res.BoughtTogether)+len(res.Related))
+	for _, list := range [][]*recommendations.Recommendation{res.BoughtTogether, res.Related} {
+		for _, r := range list {
+			productIds = append(productIds, r.ProductId)
+		}
+	}
+	if len(productIds) == 0 {
+		return nil
+	}
+
+	prods, err := u.productRepository.FindProductByIds(productIds)
+	if err != nil {
+		return err
+	}
+	byId := make(map[string]*products.Product, len(prods))
+	for _, prod := range prods {
+		byId[prod.Id] = prod
+	}
+
+	attach := func(list []*recommendations.Recommendation) []*recommendations.Recommendation {
+		result := make([]*recommendations.Recommendation, 0, len(list))
+		for _, r := range list {
+			if r.Product = byId[r.ProductId]; r.Product != nil {
+				result = append(result, r)
+			}
+		}
+		return result
+	}
+	res.BoughtTogether = attach(res.BoughtTogether)
+	res.Related = attach(res.Related)
+	return nil
+}
+
+func (u *recommendationUsecases) RefreshCoPurchase(ctx context.Context) (int64, error) {
+	return u.recommendationRepository.RefreshCoPurchase(ctx)
+}
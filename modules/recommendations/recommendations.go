@@ -0,0 +1,52 @@
+package recommendations
+
+import (
+	"errors"
+	"go_learn_project_rest_api/modules/products"
+)
+
+const (
+	ReasonBoughtTogether = "bought_together"
+	ReasonSameCategory   = "same_category"
+	ReasonBestSeller     = "best_seller"
+)
+
+// ErrProductNotFound is for a product that does not exist or is not visible yet
+var ErrProductNotFound = errors.New("product not found")
+
+type RecommendationFilter struct {
+	Limit int `query:"limit"`
+}
+
+type Recommendation struct {
+	ProductId string            `db:"product_id" json:"product_id"`
+	Reason    string            `db:"reason" json:"reason"`
+	Score     int               `db:"score" json:"score"` // orders bought together, shared categories or units sold, by reason
+	Product   *products.Product `db:"-" json:"product"`
+}
+
+// RecommendationRes keeps the two lists apart, a product nobody ordered yet only has related items
+type RecommendationRes struct {
+	ProductId      string            `json:"product_id"`
+	BoughtTogether []*Recommendation `json:"bought_together"`
+	Related        []*Recommendation `json:"related"`
+}
+
+// Fill takes the candidates in the order of the lists until it has limit items,
+// skipping the products in seen and adding the ones it takes
+func Fill(limit int, seen map[string]bool, lists ...[]*Recommendation) []*Recommendation {
+	result := make([]*Recommendation, 0, limit)
+	for _, list := range lists {
+		for _, r := range list {
+			if len(result) >= limit {
+				return result
+			}
+			if seen[r.ProductId] {
+				continue
+			}
+			seen[r.ProductId] = true
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package reportHandlers
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/reports"
+	"go_learn_project_rest_api/modules/reports/reportUsecases"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type reportHandlersErrCode string
+
+const (
+	revenueByPeriodErr   reportHandlersErrCode = "reports-001"
+	revenueByProductErr  reportHandlersErrCode = "reports-002"
+	revenueByCategoryErr reportHandlersErrCode = "reports-003"
+)
+
+type IReportHandlers interface {
+	RevenueByPeriod(fiber.Ctx) error
+	RevenueByProduct(fiber.Ctx) error
+	RevenueByCategory(fiber.Ctx) error
+}
+
+type reportHandlers struct {
+	cfg            config.IConfig
+	reportUsecases reportUsecases.IReportUsecases
+}
+
+func ReportHandlers(cfg config.IConfig, reportUsecases reportUsecases.IReportUsecases) IReportHandlers {
+	return &reportHandlers{
+		cfg:            cfg,
+		reportUsecases: reportUsecases,
+	}
+}
+
+func (h *reportHandlers) bindReportFilter(c fiber.Ctx) (*reports.ReportFilter, error) {
+	req := new(reports.ReportFilter)
+	if err := c.Bind().Query(req); err != nil {
+		return nil, err
+	}
+
+	groupByMap := map[string]string{
+		"day":   "day",
+		"week":  "week",
+		"month": "month",
+	}
+	req.GroupBy = groupByMap[strings.ToLower(req.GroupBy)]
+	if req.GroupBy == "" {
+		req.GroupBy = groupByMap["day"]
+	}
+
+	// Date	YYYY-MM-DD, default is the last 30 days
+	now := time.Now()
+	if req.StartDate == "" {
+		req.StartDate = now.AddDate(0, 0, -30).Format("2006-01-02")
+	}
+	if req.EndDate == "" {
+		req.EndDate = now.Format("2006-01-02")
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("start date is invalid")
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("end date is invalid")
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end date must not be before start date")
+	}
+	req.StartDate = start.Format("2006-01-02")
+	req.EndDate = end.Format("2006-01-02")
+
+	return req, nil
+}
+
+func (h *reportHandlers) setCacheControl(c fiber.Ctx) {
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+}
+
+func (h *reportHandlers) RevenueByPeriod(c fiber.Ctx) error {
+	req, err := h.bindReportFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(revenueByPeriodErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.reportUsecases.RevenueByPeriod(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(revenueByPeriodErr),
+			err.Error(),
+		).Res()
+	}
+	h.setCacheControl(c)
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *reportHandlers) RevenueByProduct(c fiber.Ctx) error {
+	req, err := h.bindReportFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(revenueByProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.reportUsecases.RevenueByProduct(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(revenueByProductErr),
+			err.Error(),
+		).Res()
+	}
+	h.setCacheControl(c)
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *reportHandlers) RevenueByCategory(c fiber.Ctx) error {
+	req, err := h.bindReportFilter(c)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(revenueByCategoryErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.reportUsecases.RevenueByCategory(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(revenueByCategoryErr),
+			err.Error(),
+		).Res()
+	}
+	h.setCacheControl(c)
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
@@ -0,0 +1,130 @@
+package reportRepositories
+
+import (
+	"context"
+	"fmt"
+	"go_learn_project_rest_api/modules/orders"
+	"go_learn_project_rest_api/modules/reports"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Revenue is net of tax and of the coupon discount, taken from the tax snapshot of the line that already carries its discount share.
+// Lines from before taxes have no snapshot, they take their share of the order discount by their amount
+const (
+	netRevenue    = `COALESCE(ROUND(SUM(COALESCE((po.tax->>'net')::FLOAT, (po.product->>'price')::FLOAT*po.qty*(1 - LEAST(COALESCE(o.discount/NULLIF(os.subtotal, 0), 0), 1))))::NUMERIC, 2), 0)::FLOAT`
+	orderSubtotal = `CROSS JOIN LATERAL (
+		SELECT
+			SUM((spo.product->>'price')::FLOAT*spo.qty) AS subtotal
+		FROM products_orders spo
+		WHERE spo.order_id = o.id
+	) os`
+)
+
+type IReportRepository interface {
+	RevenueByPeriod(*reports.ReportFilter) ([]*reports.RevenueByPeriod, error)
+	RevenueByProduct(*reports.ReportFilter) ([]*reports.RevenueByProduct, error)
+	RevenueByCategory(*reports.ReportFilter) ([]*reports.RevenueByCategory, error)
+}
+
+type reportRepository struct {
+	db *sqlx.DB
+}
+
+func ReportRepository(db *sqlx.DB) IReportRepository {
+	return &reportRepository{
+		db: db,
+	}
+}
+
+func (r *reportRepository) RevenueByPeriod(req *reports.ReportFilter) ([]*reports.RevenueByPeriod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+	SELECT
+		to_char(date_trunc($1, o.created_at), 'YYYY-MM-DD') AS period,
+		%s AS revenue,
+		COUNT(DISTINCT o.id) AS orders,
+		COALESCE(SUM(po.qty), 0) AS units
+	FROM orders o
+	JOIN products_orders po ON po.order_id = o.id
+	%s
+	WHERE %s
+	AND o.created_at >= DATE($2) AND o.created_at < ($3)::DATE + 1
+	GROUP BY 1
+	ORDER BY 1;`, netRevenue, orderSubtotal, orders.PaidOrderStatus)
+
+	result := make([]*reports.RevenueByPeriod, 0)
+	if err := r.db.SelectContext(ctx, &result, query, req.GroupBy, req.StartDate, req.EndDate); err != nil {
+		return nil, fmt.Errorf("get revenue by period failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *reportRepository) RevenueByProduct(req *reports.ReportFilter) ([]*reports.RevenueByProduct, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+	SELECT
+		po.product->>'id' AS product_id,
+		MAX(po.product->>'title') AS title,
+		%s AS revenue,
+		COUNT(DISTINCT o.id) AS orders,
+		COALESCE(SUM(po.qty), 0) AS units
+	FROM orders o
+	JOIN products_orders po ON po.order_id = o.id
+	%s
+	WHERE %s
+	AND o.created_at >= DATE($1) AND o.created_at < ($2)::DATE + 1
+	GROUP BY po.product->>'id'
+	ORDER BY revenue DESC, product_id;`, netRevenue, orderSubtotal, orders.PaidOrderStatus)
+
+	result := make([]*reports.RevenueByProduct, 0)
+	if err := r.db.SelectContext(ctx, &result, query, req.StartDate, req.EndDate); err != nil {
+		return nil, fmt.Errorf("get revenue by product failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *reportRepository) RevenueByCategory(req *reports.ReportFilter) ([]*reports.RevenueByCategory, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	// The snapshot category is preferred, the current catalog is used when the snapshot has none.
+	// A product in several categories counts under its first one by sort order, as the category of a product is read
+	query := fmt.Sprintf(`
+	SELECT
+		c.id AS category_id,
+		c.title,
+		%s AS revenue,
+		COUNT(DISTINCT o.id) AS orders,
+		COALESCE(SUM(po.qty), 0) AS units
+	FROM orders o
+	JOIN products_orders po ON po.order_id = o.id
+	%s
+	JOIN categories c ON c.id = COALESCE(
+		(po.product->'category'->>'id')::INT,
+		(
+			SELECT
+				pc.category_id
+			FROM products_categories pc
+			JOIN categories pcc ON pcc.id = pc.category_id
+			WHERE pc.product_id = po.product->>'id'
+			ORDER BY pcc.sort_order, pcc.id
+			LIMIT 1
+		)
+	)
+	WHERE %s
+	AND o.created_at >= DATE($1) AND o.created_at < ($2)::DATE + 1
+	GROUP BY c.id, c.title
+	ORDER BY revenue DESC, c.id;`, netRevenue, orderSubtotal, orders.PaidOrderStatus)
+
+	result := make([]*reports.RevenueByCategory, 0)
+	if err := r.db.SelectContext(ctx, &result, query, req.StartDate, req.EndDate); err != nil {
+		return nil, fmt.Errorf("get revenue by category failed: %v", err)
+	}
+	return result, nil
+}
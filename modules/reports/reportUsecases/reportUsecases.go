@@ -0,0 +1,72 @@
+package reportUsecases
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/modules/reports"
+	"go_learn_project_rest_api/modules/reports/reportRepositories"
+	"go_learn_project_rest_api/pkgs/cache"
+)
+
+type IReportUsecases interface {
+	RevenueByPeriod(*reports.ReportFilter) ([]*reports.RevenueByPeriod, error)
+	RevenueByProduct(*reports.ReportFilter) ([]*reports.RevenueByProduct, error)
+	RevenueByCategory(*reports.ReportFilter) ([]*reports.RevenueByCategory, error)
+}
+
+type reportUsecases struct {
+	reportRepository reportRepositories.IReportRepository
+	cache            cache.ICache
+}
+
+func ReportUsecases(reportRepository reportRepositories.IReportRepository, cache cache.ICache) IReportUsecases {
+	return &reportUsecases{
+		reportRepository: reportRepository,
+		cache:            cache,
+	}
+}
+
+func cacheKey(report string, req *reports.ReportFilter) string {
+	return fmt.Sprintf("%s:%s:%s:%s", report, req.StartDate, req.EndDate, req.GroupBy)
+}
+
+func (u *reportUsecases) RevenueByPeriod(req *reports.ReportFilter) ([]*reports.RevenueByPeriod, error) {
+	key := cacheKey("period", req)
+	if result, ok := u.cache.Get(key); ok {
+		return result.([]*reports.RevenueByPeriod), nil
+	}
+
+	result, err := u.reportRepository.RevenueByPeriod(req)
+	if err != nil {
+		return nil, err
+	}
+	u.cache.Set(key, result)
+	return result, nil
+}
+
+func (u *reportUsecases) RevenueByProduct(req *reports.ReportFilter) ([]*reports.RevenueByProduct, error) {
+	key := cacheKey("product", req)
+	if result, ok := u.cache.Get(key); ok {
+		return result.([]*reports.RevenueByProduct), nil
+	}
+
+	result, err := u.reportRepository.RevenueByProduct(req)
+	if err != nil {
+		return nil, err
+	}
+	u.cache.Set(key, result)
+	return result, nil
+}
+
+func (u *reportUsecases) RevenueByCategory(req *reports.ReportFilter) ([]*reports.RevenueByCategory, error) {
+	key := cacheKey("category", req)
+	if result, ok := u.cache.Get(key); ok {
+		return result.([]*reports.RevenueByCategory), nil
+	}
+
+	result, err := u.reportRepository.RevenueByCategory(req)
+	if err != nil {
+		return nil, err
+	}
+	u.cache.Set(key, result)
+	return result, nil
+}
@@ -0,0 +1,30 @@
+package reports
+
+type ReportFilter struct {
+	StartDate string `query:"start_date"`
+	EndDate   string `query:"end_date"`
+	GroupBy   string `query:"group_by"` // day, week, month
+}
+
+type RevenueByPeriod struct {
+	Period  string  `db:"period" json:"period"`
+	Revenue float64 `db:"revenue" json:"revenue"`
+	Orders  int     `db:"orders" json:"orders"`
+	Units   int     `db:"units" json:"units"`
+}
+
+type RevenueByProduct struct {
+	ProductId string  `db:"product_id" json:"product_id"`
+	Title     string  `db:"title" json:"title"`
+	Revenue   float64 `db:"revenue" json:"revenue"`
+	Orders    int     `db:"orders" json:"orders"`
+	Units     int     `db:"units" json:"units"`
+}
+
+type RevenueByCategory struct {
+	CategoryId int     `db:"category_id" json:"category_id"`
+	Title      string  `db:"title" json:"title"`
+	Revenue    float64 `db:"revenue" json:"revenue"`
+	Orders     int     `db:"orders" json:"orders"`
+	Units      int     `db:"units" json:"units"`
+}
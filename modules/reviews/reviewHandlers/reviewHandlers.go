@@ -0,0 +1,160 @@
+package reviewHandlers
+
+import (
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/reviews"
+	"go_learn_project_rest_api/modules/reviews/reviewUsecases"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type reviewHandlersErrCode string
+
+const (
+	findReviewErr   reviewHandlersErrCode = "reviews-001"
+	insertReviewErr reviewHandlersErrCode = "reviews-002"
+	updateReviewErr reviewHandlersErrCode = "reviews-003"
+	hideReviewErr   reviewHandlersErrCode = "reviews-004"
+)
+
+type IReviewHandlers interface {
+	FindReview(fiber.Ctx) error
+	FindReviewAdmin(fiber.Ctx) error
+	InsertReview(fiber.Ctx) error
+	UpdateReview(fiber.Ctx) error
+	HideReview(fiber.Ctx) error
+}
+
+type reviewHandlers struct {
+	cfg            config.IConfig
+	reviewUsecases reviewUsecases.IReviewUsecases
+}
+
+func ReviewHandlers(cfg config.IConfig, reviewUsecases reviewUsecases.IReviewUsecases) IReviewHandlers {
+	return &reviewHandlers{
+		cfg:            cfg,
+		reviewUsecases: reviewUsecases,
+	}
+}
+
+// FindReview lists the visible reviews of a product, newest first
+func (h *reviewHandlers) FindReview(c fiber.Ctx) error {
+	return h.findReview(c, false)
+}
+
+// FindReviewAdmin also lists the hidden reviews
+func (h *reviewHandlers) FindReviewAdmin(c fiber.Ctx) error {
+	return h.findReview(c, true)
+}
+
+func (h *reviewHandlers) findReview(c fiber.Ctx, admin bool) error {
+	req := &reviews.ReviewFilter{
+		PaginationReq: &entities.PaginationReq{},
+	}
+	if err := c.Bind().Query(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findReviewErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+	req.Admin = admin
+	if req.Rating < 0 || req.Rating > 5 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(findReviewErr),
+			"rating must be between 1 and 5",
+		).Res()
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 5 {
+		req.Limit = 5
+	}
+
+	result, err := h.reviewUsecases.FindReview(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *reviewHandlers) InsertReview(c fiber.Ctx) error {
+	req := new(reviews.Review)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertReviewErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+	req.UserId = c.Locals("userId").(string)
+
+	result, err := h.reviewUsecases.InsertReview(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, result).Res()
+}
+
+// UpdateReview lets the author change the rating or the comment
+func (h *reviewHandlers) UpdateReview(c fiber.Ctx) error {
+	req := new(reviews.Review)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateReviewErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.Trim(c.Params("review_id"), " ")
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+	req.UserId = c.Locals("userId").(string)
+
+	result, err := h.reviewUsecases.UpdateReview(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+// HideReview hides a review from the listing and the product rating, or shows it again
+func (h *reviewHandlers) HideReview(c fiber.Ctx) error {
+	req := new(reviews.HideReviewReq)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(hideReviewErr),
+			err.Error(),
+		).Res()
+	}
+	productId := strings.Trim(c.Params("product_id"), " ")
+	reviewId := strings.Trim(c.Params("review_id"), " ")
+
+	result, err := h.reviewUsecases.HideReview(productId, reviewId, req.IsHidden)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(hideReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
@@ -0,0 +1,171 @@
+package reviewRepositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go_learn_project_rest_api/modules/reviews"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const reviewColumns = `
+		r."id",
+		r."product_id",
+		r."user_id",
+		u."username",
+		r."rating",
+		r."comment",
+		r."is_hidden",
+		r."created_at"::TEXT AS "created_at",
+		r."updated_at"::TEXT AS "updated_at"`
+
+type IReviewRepository interface {
+	FindReview(*reviews.ReviewFilter) ([]*reviews.Review, int, error)
+	FindOneReview(reviewId string) (*reviews.Review, error)
+	IsVerifiedBuyer(userId, productId string) (bool, error)
+	InsertReview(*reviews.Review) (string, error)
+	UpdateReview(*reviews.Review) error
+	HideReview(reviewId string, isHidden bool) error
+}
+
+type reviewRepository struct {
+	db *sqlx.DB
+}
+
+func ReviewRepository(db *sqlx.DB) IReviewRepository {
+	return &reviewRepository{
+		db: db,
+	}
+}
+
+func (r *reviewRepository) FindReview(req *reviews.ReviewFilter) ([]*reviews.Review, int, error) {
+	where := `
+	WHERE r."product_id" = $1
+	AND ($2 = 0 OR r."rating" = $2)
+	AND ($3 OR NOT r."is_hidden")`
+	values := []any{req.ProductId, req.Rating, req.Admin}
+
+	var count int
+	if err := r.db.Get(&count, `SELECT COUNT(*) FROM "reviews" r`+where+`;`, values...); err != nil {
+		return nil, 0, fmt.Errorf("count reviews failed: %v", err)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM "reviews" r
+	JOIN "users" u ON u."id" = r."user_id"%s
+	ORDER BY r."created_at" DESC, r."id"
+	OFFSET $4 LIMIT $5;`, reviewColumns, where)
+
+	result := make([]*reviews.Review, 0)
+	if err := r.db.Select(&result, query, append(values, (req.Page-1)*req.Limit, req.Limit)...); err != nil {
+		return nil, 0, fmt.Errorf("get reviews failed: %v", err)
+	}
+	return result, count, nil
+}
+
+func (r *reviewRepository) FindOneReview(reviewId string) (*reviews.Review, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM "reviews" r
+	JOIN "users" u ON u."id" = r."user_id"
+	WHERE r."id" = $1;`, reviewColumns)
+
+	result := new(reviews.Review)
+	if err := r.db.Get(result, query, reviewId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("review not found")
+		}
+		return nil, fmt.Errorf("get review failed: %v", err)
+	}
+	return result, nil
+}
+
+// IsVerifiedBuyer tells if the user has a completed order with the product, order lines keep the product as a snapshot
+func (r *reviewRepository) IsVerifiedBuyer(userId, productId string) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM "orders" o
+		JOIN "products_orders" po ON po."order_id" = o."id"
+		WHERE o."user_id" = $1
+		AND o."status" = 'completed'
+		AND po."product"->>'id' = $2
+	);`
+
+	var isBuyer bool
+	if err := r.db.Get(&isBuyer, query, userId, productId); err != nil {
+		return false, fmt.Errorf("check verified buyer failed: %v", err)
+	}
+	return isBuyer, nil
+}
+
+func (r *reviewRepository) InsertReview(req *reviews.Review) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	query := `
+	INSERT INTO "reviews" (
+		"product_id",
+		"user_id",
+		"rating",
+		"comment"
+	)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT ("product_id", "user_id") DO NOTHING
+	RETURNING "id";`
+
+	var reviewId string
+	if err := r.db.QueryRowxContext(ctx, query, req.ProductId, req.UserId, req.Rating, req.Comment).Scan(&reviewId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("product is already reviewed, edit the review instead")
+		}
+		return "", fmt.Errorf("insert review failed: %v", err)
+	}
+	return reviewId, nil
+}
+
+// UpdateReview only changes a review of its author, a rating of 0 and an empty comment are kept
+func (r *reviewRepository) UpdateReview(req *reviews.Review) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	query := `
+	UPDATE "reviews" SET
+		"rating" = COALESCE(NULLIF($1, 0), "rating"),
+		"comment" = COALESCE(NULLIF($2, ''), "comment")
+	WHERE "id" = $3
+	AND "product_id" = $4
+	AND "user_id" = $5;`
+
+	result, err := r.db.ExecContext(ctx, query, req.Rating, req.Comment, req.Id, req.ProductId, req.UserId)
+	if err != nil {
+		return fmt.Errorf("update review failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("review not found")
+	}
+	return nil
+}
+
+func (r *reviewRepository) HideReview(reviewId string, isHidden bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	query := `
+	UPDATE "reviews" SET
+		"is_hidden" = $1
+	WHERE "id" = $2;`
+
+	result, err := r.db.ExecContext(ctx, query, isHidden, reviewId)
+	if err != nil {
+		return fmt.Errorf("hide review failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("review not found")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package reviewUsecases
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/reviews"
+	"go_learn_project_rest_api/modules/reviews/reviewRepositories"
+	"math"
+)
+
+type IReviewUsecases interface {
+	FindReview(*reviews.ReviewFilter) (*entities.PaginateRes, error)
+	InsertReview(*reviews.Review) (*reviews.Review, error)
+	UpdateReview(*reviews.Review) (*reviews.Review, error)
+	HideReview(productId, reviewId string, isHidden bool) (*reviews.Review, error)
+}
+
+type reviewUsecases struct {
+	reviewRepository reviewRepositories.IReviewRepository
+}
+
+func ReviewUsecases(reviewRepository reviewRepositories.IReviewRepository) IReviewUsecases {
+	return &reviewUsecases{
+		reviewRepository: reviewRepository,
+	}
+}
+
+func (u *reviewUsecases) FindReview(req *reviews.ReviewFilter) (*entities.PaginateRes, error) {
+	result, count, err := u.reviewRepository.FindReview(req)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.PaginateRes{
+		Data:      result,
+		Page:      req.Page,
+		Limit:     req.Limit,
+		TotalPage: int(math.Ceil(float64(count) / float64(req.Limit))),
+		TotalItem: count,
+	}, nil
+}
+
+func (u *reviewUsecases) InsertReview(req *reviews.Review) (*reviews.Review, error) {
+	if err := req.Validate(false); err != nil {
+		return nil, err
+	}
+
+	isBuyer, err := u.reviewRepository.IsVerifiedBuyer(req.UserId, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	if !isBuyer {
+		return nil, fmt.Errorf("only customers with a completed order of the product can review it")
+	}
+
+	reviewId, err := u.reviewRepository.InsertReview(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.reviewRepository.FindOneReview(reviewId)
+}
+
+func (u *reviewUsecases) UpdateReview(req *reviews.Review) (*reviews.Review, error) {
+	if err := req.Validate(true); err != nil {
+		return nil, err
+	}
+	if err := u.reviewRepository.UpdateReview(req); err != nil {
+		return nil, err
+	}
+	return u.reviewRepository.FindOneReview(req.Id)
+}
+
+func (u *reviewUsecases) HideReview(productId, reviewId string, isHidden bool) (*reviews.Review, error) {
+	review, err := u.reviewRepository.FindOneReview(reviewId)
+	if err != nil {
+		return nil, err
+	}
+	if review.ProductId != productId {
+		return nil, fmt.Errorf("review not found")
+	}
+
+	if err := u.reviewRepository.HideReview(reviewId, isHidden); err != nil {
+		return nil, err
+	}
+	review.IsHidden = isHidden
+	return review, nil
+}
@@ -0,0 +1,48 @@
+package reviews
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/modules/entities"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxCommentLength = 2000
+
+type Review struct {
+	Id        string `db:"id" json:"id"`
+	ProductId string `db:"product_id" json:"product_id"`
+	UserId    string `db:"user_id" json:"user_id"`
+	Username  string `db:"username" json:"username"`
+	Rating    int    `db:"rating" json:"rating"`
+	Comment   string `db:"comment" json:"comment"`
+	IsHidden  bool   `db:"is_hidden" json:"is_hidden"`
+	CreatedAt string `db:"created_at" json:"created_at"`
+	UpdatedAt string `db:"updated_at" json:"updated_at"`
+}
+
+// Validate checks a new review, an update may leave the rating at 0 to keep it
+func (r *Review) Validate(isUpdate bool) error {
+	if !(isUpdate && r.Rating == 0) && (r.Rating < 1 || r.Rating > 5) {
+		return fmt.Errorf("rating must be between 1 and 5")
+	}
+	r.Comment = strings.TrimSpace(r.Comment)
+	if utf8.RuneCountInString(r.Comment) > maxCommentLength {
+		return fmt.Errorf("comment must not be longer than %d characters", maxCommentLength)
+	}
+	if isUpdate && r.Rating == 0 && r.Comment == "" {
+		return fmt.Errorf("nothing to update")
+	}
+	return nil
+}
+
+type ReviewFilter struct {
+	ProductId string `query:"-"`
+	Rating    int    `query:"rating"` // only the reviews with this rating
+	Admin     bool   `query:"-"`      // hidden reviews are listed too
+	*entities.PaginationReq
+}
+
+type HideReviewReq struct {
+	IsHidden bool `json:"is_hidden"`
+}
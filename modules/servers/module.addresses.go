@@ -0,0 +1,47 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/addresses/addressHandlers"
+	"go_learn_project_rest_api/modules/addresses/addressRepositories"
+	"go_learn_project_rest_api/modules/addresses/addressUsecases"
+)
+
+type IAddressesModule interface {
+	Init()
+	Repository() addressRepositories.IAddressRepository
+	Usecase() addressUsecases.IAddressUsecases
+	Handler() addressHandlers.IAddressHandlers
+}
+
+type addressesModule struct {
+	*moduleFactory
+	repository addressRepositories.IAddressRepository
+	usecase    addressUsecases.IAddressUsecases
+	handler    addressHandlers.IAddressHandlers
+}
+
+func (m *moduleFactory) AddressModule() IAddressesModule {
+	repository := addressRepositories.AddressRepository(m.server.db)
+	usecase := addressUsecases.AddressUsecases(repository)
+	handler := addressHandlers.AddressHandlers(m.server.cfg, usecase)
+
+	return &addressesModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (a *addressesModule) Init() {
+	router := a.router.Group("/addresses")
+	router.Get("/:user_id", a.handler.FindAddress, a.mid.JwtAuth(), a.mid.ParamsCheck())
+	router.Post("/:user_id", a.handler.InsertAddress, a.mid.JwtAuth(), a.mid.ParamsCheck())
+	router.Get("/:user_id/:address_id", a.handler.FindOneAddress, a.mid.JwtAuth(), a.mid.ParamsCheck())
+	router.Patch("/:user_id/:address_id", a.handler.UpdateAddress, a.mid.JwtAuth(), a.mid.ParamsCheck())
+	router.Delete("/:user_id/:address_id", a.handler.DeleteAddress, a.mid.JwtAuth(), a.mid.ParamsCheck())
+}
+
+func (a *addressesModule) Repository() addressRepositories.IAddressRepository { return a.repository }
+func (a *addressesModule) Usecase() addressUsecases.IAddressUsecases          { return a.usecase }
+func (a *addressesModule) Handler() addressHandlers.IAddressHandlers          { return a.handler }
@@ -4,15 +4,10 @@ import (
 	"go_learn_project_rest_api/modules/appInfo/appInfoHandlers"
 	"go_learn_project_rest_api/modules/appInfo/appInfoRepositories"
 	"go_learn_project_rest_api/modules/appInfo/appInfoUsecases"
-	"go_learn_project_rest_api/modules/files/fileUsecases"
 	middlewaresHandler "go_learn_project_rest_api/modules/middlewares/middlewaresHandlers"
 	"go_learn_project_rest_api/modules/middlewares/middlewaresRepository"
 	"go_learn_project_rest_api/modules/middlewares/middlewaresUsecases"
 	"go_learn_project_rest_api/modules/monitor/handlers"
-	"go_learn_project_rest_api/modules/orders/orderHandlers"
-	"go_learn_project_rest_api/modules/orders/orderRepositories"
-	"go_learn_project_rest_api/modules/orders/orderUsecases"
-	"go_learn_project_rest_api/modules/products/productRepositories"
 	"go_learn_project_rest_api/modules/users/usersHandlers"
 	"go_learn_project_rest_api/modules/users/usersRepositories"
 	"go_learn_project_rest_api/modules/users/usersUsecases"
@@ -26,7 +21,18 @@ type IModuleFactory interface {
 	AppInfoModule()
 	FilesModule() IFilesModule
 	ProductModule() IProductsModule
-	OrderModule()
+	OrderModule() IOrdersModule
+	ReportModule() IReportsModule
+	AddressModule() IAddressesModule
+	PromotionModule() IPromotionsModule
+	TaxModule() ITaxesModule
+	PaymentModule() IPaymentsModule
+	NotificationModule() INotificationsModule
+	ShipmentModule() IShipmentsModule
+	ReviewModule() IReviewsModule
+	WishlistModule() IWishlistsModule
+	RecommendationModule() IRecommendationsModule
+	JobModule()
 }
 
 type moduleFactory struct {
@@ -82,20 +88,7 @@ func (m *moduleFactory) AppInfoModule() {
 
 	router.Get("/apikey", handlers.GenerateApiKey, m.mid.JwtAuth(), m.mid.Authorize(2))
 	router.Post("/insertcategory", handlers.InsertCategory, m.mid.JwtAuth(), m.mid.Authorize(2))
+	router.Post("/updatecategory", handlers.UpdateCategory, m.mid.JwtAuth(), m.mid.Authorize(2))
 	router.Post("/deletecategory", handlers.DeleteCategory, m.mid.JwtAuth(), m.mid.Authorize(2))
 	router.Get("/category", handlers.FindCategory, m.mid.ApiKeyAuth())
 }
-
-func (m *moduleFactory) OrderModule() {
-	fileUsecase := fileUsecases.FileUsecases(m.server.cfg)
-	productRepository := productRepositories.ProductRepository(m.server.db, m.server.cfg, fileUsecase)
-	repository := orderRepositories.OrderRepository(m.server.db)
-	usecase := orderUsecases.OrderUsecases(repository, productRepository)
-	handlers := orderHandlers.OrderHandlers(m.server.cfg, usecase)
-
-	router := m.router.Group("/orders")
-	router.Get("/", handlers.FindOrder, m.mid.JwtAuth(), m.mid.Authorize(2))
-	router.Post("/", handlers.InsertOrder, m.mid.JwtAuth())
-	router.Get("/:user_id/:order_id", handlers.FindOneOrder, m.mid.JwtAuth(), m.mid.ParamsCheck())
-	router.Patch("/:user_id/:order_id", handlers.UpdateOrder, m.mid.JwtAuth(), m.mid.ParamsCheck())
-}
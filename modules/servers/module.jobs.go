@@ -0,0 +1,43 @@
+package servers
+
+import (
+	"context"
+	"go_learn_project_rest_api/pkgs/scheduler"
+	"time"
+)
+
+// JobModule registers the background jobs, they start and stop with the server
+func (m *moduleFactory) JobModule() {
+	orderUsecase := m.OrderModule().Usecase()
+	shipmentUsecase := m.ShipmentModule().Usecase()
+	recommendationUsecase := m.RecommendationModule().Usecase()
+
+	m.server.scheduler.Add(&scheduler.Job{
+		Name:     "cancel_unpaid_orders",
+		Interval: m.server.cfg.Job().Interval(),
+		Timeout:  time.Minute * 5,
+		Run: func(ctx context.Context) error {
+			_, err := orderUsecase.CancelExpiredOrder(ctx, m.server.cfg.Job().OrderPaymentTimeout())
+			return err
+		},
+	})
+
+	m.server.scheduler.Add(&scheduler.Job{
+		Name:     "poll_shipments",
+		Interval: m.server.cfg.Job().Interval(),
+		Timeout:  time.Minute * 5,
+		Run: func(ctx context.Context) error {
+			return shipmentUsecase.PollShipments(ctx, 50)
+		},
+	})
+
+	m.server.scheduler.Add(&scheduler.Job{
+		Name:     "refresh_co_purchases",
+		Interval: m.server.cfg.Job().RecommendationInterval(),
+		Timeout:  time.Minute * 5,
+		Run: func(ctx context.Context) error {
+			_, err := recommendationUsecase.RefreshCoPurchase(ctx)
+			return err
+		},
+	})
+}
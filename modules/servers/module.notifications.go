@@ -0,0 +1,46 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/notifications/notificationHandlers"
+	"go_learn_project_rest_api/modules/notifications/notificationRepositories"
+	"go_learn_project_rest_api/modules/notifications/notificationUsecases"
+)
+
+type INotificationsModule interface {
+	Init()
+	Repository() notificationRepositories.INotificationRepository
+	Usecase() notificationUsecases.INotificationUsecases
+	Handler() notificationHandlers.INotificationHandlers
+}
+
+type notificationsModule struct {
+	*moduleFactory
+	repository notificationRepositories.INotificationRepository
+	usecase    notificationUsecases.INotificationUsecases
+	handler    notificationHandlers.INotificationHandlers
+}
+
+func (m *moduleFactory) NotificationModule() INotificationsModule {
+	repository := notificationRepositories.NotificationRepository(m.server.db)
+	usecase := notificationUsecases.NotificationUsecases(repository)
+	handler := notificationHandlers.NotificationHandlers(m.server.cfg, usecase)
+
+	return &notificationsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (n *notificationsModule) Init() {
+	router := n.router.Group("/notifications")
+	router.Get("/:user_id", n.handler.FindNotification, n.mid.JwtAuth(), n.mid.ParamsCheck())
+	router.Patch("/:user_id/:notification_id/read", n.handler.ReadNotification, n.mid.JwtAuth(), n.mid.ParamsCheck())
+}
+
+func (n *notificationsModule) Repository() notificationRepositories.INotificationRepository {
+	return n.repository
+}
+func (n *notificationsModule) Usecase() notificationUsecases.INotificationUsecases { return n.usecase }
+func (n *notificationsModule) Handler() notificationHandlers.INotificationHandlers { return n.handler }
@@ -0,0 +1,68 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/addresses/addressRepositories"
+	"go_learn_project_rest_api/modules/files/fileUsecases"
+	"go_learn_project_rest_api/modules/notifications/notificationRepositories"
+	"go_learn_project_rest_api/modules/orders/orderHandlers"
+	"go_learn_project_rest_api/modules/orders/orderRepositories"
+	"go_learn_project_rest_api/modules/orders/orderUsecases"
+	"go_learn_project_rest_api/modules/products/productRepositories"
+	"go_learn_project_rest_api/modules/taxes/taxRepositories"
+)
+
+type IOrdersModule interface {
+	Init()
+	Repository() orderRepositories.IOrderRepository
+	Usecase() orderUsecases.IOrderUsecases
+	Handler() orderHandlers.IOrderHandlers
+}
+
+type ordersModule struct {
+	*moduleFactory
+	repository orderRepositories.IOrderRepository
+	usecase    orderUsecases.IOrderUsecases
+	handler    orderHandlers.IOrderHandlers
+}
+
+func (m *moduleFactory) OrderModule() IOrdersModule {
+	fileUsecase := fileUsecases.FileUsecases(m.server.cfg)
+	productRepository := productRepositories.ProductRepository(m.server.db, m.server.cfg, fileUsecase)
+	addressRepository := addressRepositories.AddressRepository(m.server.db)
+	taxRepository := taxRepositories.TaxRepository(m.server.db)
+	notificationRepository := notificationRepositories.NotificationRepository(m.server.db)
+	payment := m.PaymentModule()
+	repository := orderRepositories.OrderRepository(m.server.db)
+	usecase := orderUsecases.OrderUsecases(repository, productRepository, addressRepository, taxRepository, notificationRepository, fileUsecase, payment.Repository(), payment.Usecase())
+	handler := orderHandlers.OrderHandlers(m.server.cfg, usecase)
+
+	return &ordersModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (o *ordersModule) Init() {
+	router := o.router.Group("/orders")
+	router.Get("/", o.handler.FindOrder, o.mid.JwtAuth(), o.mid.Authorize(2))
+	router.Get("/export", o.handler.ExportOrder, o.mid.JwtAuth(), o.mid.Authorize(2))
+	router.Post("/", o.handler.InsertOrder, o.mid.JwtAuth())
+	// Registered before /:user_id/:order_id, which would match the same path
+	router.Get("/:order_id/comments", o.handler.FindOrderComment, o.mid.JwtAuth(), o.mid.Authorize(2))
+	router.Post("/:order_id/comments", o.handler.InsertOrderComment, o.mid.JwtAuth(), o.mid.Authorize(2))
+	router.Get("/:user_id/:order_id", o.handler.FindOneOrder, o.mid.JwtAuth(), o.mid.ParamsCheck())
+	router.Patch("/:user_id/:order_id", o.handler.UpdateOrder, o.mid.JwtAuth(), o.mid.ParamsCheck())
+	router.Get("/:user_id/:order_id/invoice", o.handler.Invoice, o.mid.JwtAuth(), o.mid.ParamsCheck())
+	router.Post("/:user_id/:order_id/reorder", o.handler.Reorder, o.mid.JwtAuth(), o.mid.ParamsCheck())
+	router.Post("/:user_id/:order_id/slip", o.handler.UploadTransferSlip, o.mid.JwtAuth(), o.mid.ParamsCheck())
+	router.Patch("/:order_id/slip/approve", o.handler.ApproveTransferSlip, o.mid.JwtAuth(), o.mid.Authorize(2))
+	router.Patch("/:order_id/slip/reject", o.handler.RejectTransferSlip, o.mid.JwtAuth(), o.mid.Authorize(2))
+	router.Post("/:order_id/refunds", o.handler.InsertRefund, o.mid.JwtAuth(), o.mid.Authorize(2))
+	router.Patch("/:order_id/refunds/:refund_id", o.handler.UpdateRefund, o.mid.JwtAuth(), o.mid.Authorize(2))
+}
+
+func (o *ordersModule) Repository() orderRepositories.IOrderRepository { return o.repository }
+func (o *ordersModule) Usecase() orderUsecases.IOrderUsecases          { return o.usecase }
+func (o *ordersModule) Handler() orderHandlers.IOrderHandlers          { return o.handler }
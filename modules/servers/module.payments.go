@@ -0,0 +1,58 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/orders/orderRepositories"
+	"go_learn_project_rest_api/modules/payments/paymentHandlers"
+	"go_learn_project_rest_api/modules/payments/paymentProviders"
+	"go_learn_project_rest_api/modules/payments/paymentRepositories"
+	"go_learn_project_rest_api/modules/payments/paymentUsecases"
+	"log"
+)
+
+type IPaymentsModule interface {
+	Init()
+	Repository() paymentRepositories.IPaymentRepository
+	Usecase() paymentUsecases.IPaymentUsecases
+	Handler() paymentHandlers.IPaymentHandlers
+}
+
+type paymentsModule struct {
+	*moduleFactory
+	repository paymentRepositories.IPaymentRepository
+	usecase    paymentUsecases.IPaymentUsecases
+	handler    paymentHandlers.IPaymentHandlers
+}
+
+func (m *moduleFactory) PaymentModule() IPaymentsModule {
+	provider, err := paymentProviders.PaymentProvider(
+		paymentProviders.ProviderType(m.server.cfg.Payment().Provider()),
+		m.server.cfg.Payment(),
+	)
+	if err != nil {
+		log.Fatalf("load payment provider failed: %v", err)
+	}
+
+	orderRepository := orderRepositories.OrderRepository(m.server.db)
+	repository := paymentRepositories.PaymentRepository(m.server.db)
+	usecase := paymentUsecases.PaymentUsecases(repository, orderRepository, provider)
+	handler := paymentHandlers.PaymentHandlers(m.server.cfg, usecase)
+
+	return &paymentsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (p *paymentsModule) Init() {
+	router := p.router.Group("/payments")
+	router.Post("/webhook", p.handler.Webhook)
+	router.Post("/:payment_id/refund", p.handler.Refund, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Get("/orders/:order_id", p.handler.FindPaymentByOrder, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Post("/:user_id/:order_id", p.handler.CreateIntent, p.mid.JwtAuth(), p.mid.ParamsCheck())
+}
+
+func (p *paymentsModule) Repository() paymentRepositories.IPaymentRepository { return p.repository }
+func (p *paymentsModule) Usecase() paymentUsecases.IPaymentUsecases          { return p.usecase }
+func (p *paymentsModule) Handler() paymentHandlers.IPaymentHandlers          { return p.handler }
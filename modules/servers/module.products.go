@@ -36,6 +36,13 @@ func (m *moduleFactory) ProductModule() IProductsModule {
 func (p *productsModule) Init() {
 	router := p.router.Group("/products")
 	router.Post("/addProduct", p.handler.AddProduct, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Post("/import", p.handler.ImportProduct, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Get("/export", p.handler.ExportProduct, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Get("/admin", p.handler.FindProductAdmin, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Get("/admin/:product_id", p.handler.FindOneProductAdmin, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Get("/:product_id/prices", p.handler.FindPriceTimeline, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Post("/:product_id/prices", p.handler.InsertScheduledPrice, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Delete("/:product_id/prices/:schedule_id", p.handler.DeleteScheduledPrice, p.mid.JwtAuth(), p.mid.Authorize(2))
 	router.Patch("/:product_id", p.handler.UpdateProduct, p.mid.JwtAuth(), p.mid.Authorize(2))
 	router.Get("/", p.handler.FindProduct, p.mid.ApiKeyAuth())
 	router.Get("/:product_id", p.handler.FindOneProduct, p.mid.ApiKeyAuth())
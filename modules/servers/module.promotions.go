@@ -0,0 +1,48 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/promotions/promotionHandlers"
+	"go_learn_project_rest_api/modules/promotions/promotionRepositories"
+	"go_learn_project_rest_api/modules/promotions/promotionUsecases"
+)
+
+type IPromotionsModule interface {
+	Init()
+	Repository() promotionRepositories.IPromotionRepository
+	Usecase() promotionUsecases.IPromotionUsecases
+	Handler() promotionHandlers.IPromotionHandlers
+}
+
+type promotionsModule struct {
+	*moduleFactory
+	repository promotionRepositories.IPromotionRepository
+	usecase    promotionUsecases.IPromotionUsecases
+	handler    promotionHandlers.IPromotionHandlers
+}
+
+func (m *moduleFactory) PromotionModule() IPromotionsModule {
+	repository := promotionRepositories.PromotionRepository(m.server.db)
+	usecase := promotionUsecases.PromotionUsecases(repository)
+	handler := promotionHandlers.PromotionHandlers(m.server.cfg, usecase)
+
+	return &promotionsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (p *promotionsModule) Init() {
+	router := p.router.Group("/promotions")
+	router.Get("/coupons", p.handler.FindCoupon, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Post("/coupons", p.handler.InsertCoupon, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Get("/coupons/:coupon_id", p.handler.FindOneCoupon, p.mid.JwtAuth(), p.mid.Authorize(2))
+	router.Delete("/coupons/:coupon_id", p.handler.DeactivateCoupon, p.mid.JwtAuth(), p.mid.Authorize(2))
+}
+
+func (p *promotionsModule) Repository() promotionRepositories.IPromotionRepository {
+	return p.repository
+}
+func (p *promotionsModule) Usecase() promotionUsecases.IPromotionUsecases { return p.usecase }
+func (p *promotionsModule) Handler() promotionHandlers.IPromotionHandlers { return p.handler }
@@ -0,0 +1,49 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/recommendations/recommendationHandlers"
+	"go_learn_project_rest_api/modules/recommendations/recommendationRepositories"
+	"go_learn_project_rest_api/modules/recommendations/recommendationUsecases"
+)
+
+type IRecommendationsModule interface {
+	Init()
+	Repository() recommendationRepositories.IRecommendationRepository
+	Usecase() recommendationUsecases.IRecommendationUsecases
+	Handler() recommendationHandlers.IRecommendationHandlers
+}
+
+type recommendationsModule struct {
+	*moduleFactory
+	repository recommendationRepositories.IRecommendationRepository
+	usecase    recommendationUsecases.IRecommendationUsecases
+	handler    recommendationHandlers.IRecommendationHandlers
+}
+
+func (m *moduleFactory) RecommendationModule() IRecommendationsModule {
+	repository := recommendationRepositories.RecommendationRepository(m.server.db)
+	usecase := recommendationUsecases.RecommendationUsecases(repository, m.ProductModule().Repository())
+	handler := recommendationHandlers.RecommendationHandlers(m.server.cfg, usecase)
+
+	return &recommendationsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (r *recommendationsModule) Init() {
+	router := r.router.Group("/products/:product_id/recommendations")
+	router.Get("/", r.handler.FindRecommendation, r.mid.ApiKeyAuth())
+}
+
+func (r *recommendationsModule) Repository() recommendationRepositories.IRecommendationRepository {
+	return r.repository
+}
+func (r *recommendationsModule) Usecase() recommendationUsecases.IRecommendationUsecases {
+	return r.usecase
+}
+func (r *recommendationsModule) Handler() recommendationHandlers.IRecommendationHandlers {
+	return r.handler
+}
@@ -0,0 +1,47 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/reports/reportHandlers"
+	"go_learn_project_rest_api/modules/reports/reportRepositories"
+	"go_learn_project_rest_api/modules/reports/reportUsecases"
+	"go_learn_project_rest_api/pkgs/cache"
+	"time"
+)
+
+type IReportsModule interface {
+	Init()
+	Repository() reportRepositories.IReportRepository
+	Usecase() reportUsecases.IReportUsecases
+	Handler() reportHandlers.IReportHandlers
+}
+
+type reportsModule struct {
+	*moduleFactory
+	repository reportRepositories.IReportRepository
+	usecase    reportUsecases.IReportUsecases
+	handler    reportHandlers.IReportHandlers
+}
+
+func (m *moduleFactory) ReportModule() IReportsModule {
+	repository := reportRepositories.ReportRepository(m.server.db)
+	usecase := reportUsecases.ReportUsecases(repository, cache.NewCache(time.Minute*5))
+	handler := reportHandlers.ReportHandlers(m.server.cfg, usecase)
+
+	return &reportsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (r *reportsModule) Init() {
+	router := r.router.Group("/reports")
+	router.Get("/revenue", r.handler.RevenueByPeriod, r.mid.JwtAuth(), r.mid.Authorize(2))
+	router.Get("/products", r.handler.RevenueByProduct, r.mid.JwtAuth(), r.mid.Authorize(2))
+	router.Get("/categories", r.handler.RevenueByCategory, r.mid.JwtAuth(), r.mid.Authorize(2))
+}
+
+func (r *reportsModule) Repository() reportRepositories.IReportRepository { return r.repository }
+func (r *reportsModule) Usecase() reportUsecases.IReportUsecases          { return r.usecase }
+func (r *reportsModule) Handler() reportHandlers.IReportHandlers          { return r.handler }
@@ -0,0 +1,47 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/reviews/reviewHandlers"
+	"go_learn_project_rest_api/modules/reviews/reviewRepositories"
+	"go_learn_project_rest_api/modules/reviews/reviewUsecases"
+)
+
+type IReviewsModule interface {
+	Init()
+	Repository() reviewRepositories.IReviewRepository
+	Usecase() reviewUsecases.IReviewUsecases
+	Handler() reviewHandlers.IReviewHandlers
+}
+
+type reviewsModule struct {
+	*moduleFactory
+	repository reviewRepositories.IReviewRepository
+	usecase    reviewUsecases.IReviewUsecases
+	handler    reviewHandlers.IReviewHandlers
+}
+
+func (m *moduleFactory) ReviewModule() IReviewsModule {
+	repository := reviewRepositories.ReviewRepository(m.server.db)
+	usecase := reviewUsecases.ReviewUsecases(repository)
+	handler := reviewHandlers.ReviewHandlers(m.server.cfg, usecase)
+
+	return &reviewsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (r *reviewsModule) Init() {
+	router := r.router.Group("/products/:product_id/reviews")
+	router.Get("/", r.handler.FindReview, r.mid.ApiKeyAuth())
+	router.Get("/admin", r.handler.FindReviewAdmin, r.mid.JwtAuth(), r.mid.Authorize(2))
+	router.Post("/", r.handler.InsertReview, r.mid.JwtAuth())
+	router.Patch("/:review_id", r.handler.UpdateReview, r.mid.JwtAuth())
+	router.Patch("/:review_id/hide", r.handler.HideReview, r.mid.JwtAuth(), r.mid.Authorize(2))
+}
+
+func (r *reviewsModule) Repository() reviewRepositories.IReviewRepository { return r.repository }
+func (r *reviewsModule) Usecase() reviewUsecases.IReviewUsecases          { return r.usecase }
+func (r *reviewsModule) Handler() reviewHandlers.IReviewHandlers          { return r.handler }
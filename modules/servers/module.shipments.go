@@ -0,0 +1,53 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/orders/orderRepositories"
+	"go_learn_project_rest_api/modules/shipments/shipmentCarriers"
+	"go_learn_project_rest_api/modules/shipments/shipmentHandlers"
+	"go_learn_project_rest_api/modules/shipments/shipmentRepositories"
+	"go_learn_project_rest_api/modules/shipments/shipmentUsecases"
+)
+
+type IShipmentsModule interface {
+	Init()
+	Repository() shipmentRepositories.IShipmentRepository
+	Usecase() shipmentUsecases.IShipmentUsecases
+	Handler() shipmentHandlers.IShipmentHandlers
+}
+
+type shipmentsModule struct {
+	*moduleFactory
+	repository shipmentRepositories.IShipmentRepository
+	usecase    shipmentUsecases.IShipmentUsecases
+	handler    shipmentHandlers.IShipmentHandlers
+}
+
+func (m *moduleFactory) ShipmentModule() IShipmentsModule {
+	// Carriers without an adapter are tracked by hand through UpdateShipment
+	carriers := shipmentCarriers.NewCarriers(shipmentCarriers.NewFake())
+
+	orderRepository := orderRepositories.OrderRepository(m.server.db)
+	repository := shipmentRepositories.ShipmentRepository(m.server.db)
+	usecase := shipmentUsecases.ShipmentUsecases(repository, orderRepository, carriers)
+	handler := shipmentHandlers.ShipmentHandlers(m.server.cfg, usecase)
+
+	return &shipmentsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (s *shipmentsModule) Init() {
+	router := s.router.Group("/shipments")
+	router.Get("/orders/:order_id", s.handler.FindShipmentByOrder, s.mid.JwtAuth(), s.mid.Authorize(2))
+	router.Post("/orders/:order_id", s.handler.InsertShipment, s.mid.JwtAuth(), s.mid.Authorize(2))
+	router.Patch("/:shipment_id", s.handler.UpdateShipment, s.mid.JwtAuth(), s.mid.Authorize(2))
+	router.Post("/:shipment_id/poll", s.handler.PollShipment, s.mid.JwtAuth(), s.mid.Authorize(2))
+	router.Get("/:user_id/:order_id", s.handler.TrackOrder, s.mid.JwtAuth(), s.mid.ParamsCheck())
+}
+
+func (s *shipmentsModule) Repository() shipmentRepositories.IShipmentRepository { return s.repository }
+func (s *shipmentsModule) Usecase() shipmentUsecases.IShipmentUsecases          { return s.usecase }
+func (s *shipmentsModule) Handler() shipmentHandlers.IShipmentHandlers          { return s.handler }
@@ -0,0 +1,45 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/taxes/taxHandlers"
+	"go_learn_project_rest_api/modules/taxes/taxRepositories"
+	"go_learn_project_rest_api/modules/taxes/taxUsecases"
+)
+
+type ITaxesModule interface {
+	Init()
+	Repository() taxRepositories.ITaxRepository
+	Usecase() taxUsecases.ITaxUsecases
+	Handler() taxHandlers.ITaxHandlers
+}
+
+type taxesModule struct {
+	*moduleFactory
+	repository taxRepositories.ITaxRepository
+	usecase    taxUsecases.ITaxUsecases
+	handler    taxHandlers.ITaxHandlers
+}
+
+func (m *moduleFactory) TaxModule() ITaxesModule {
+	repository := taxRepositories.TaxRepository(m.server.db)
+	usecase := taxUsecases.TaxUsecases(repository)
+	handler := taxHandlers.TaxHandlers(m.server.cfg, usecase)
+
+	return &taxesModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (t *taxesModule) Init() {
+	router := t.router.Group("/taxes")
+	router.Get("/", t.handler.FindTaxRate, t.mid.JwtAuth(), t.mid.Authorize(2))
+	router.Post("/", t.handler.InsertTaxRate, t.mid.JwtAuth(), t.mid.Authorize(2))
+	router.Delete("/:tax_rate_id", t.handler.DeleteTaxRate, t.mid.JwtAuth(), t.mid.Authorize(2))
+}
+
+func (t *taxesModule) Repository() taxRepositories.ITaxRepository { return t.repository }
+func (t *taxesModule) Usecase() taxUsecases.ITaxUsecases          { return t.usecase }
+func (t *taxesModule) Handler() taxHandlers.ITaxHandlers          { return t.handler }
@@ -0,0 +1,46 @@
+package servers
+
+import (
+	"go_learn_project_rest_api/modules/wishlists/wishlistHandlers"
+	"go_learn_project_rest_api/modules/wishlists/wishlistRepositories"
+	"go_learn_project_rest_api/modules/wishlists/wishlistUsecases"
+)
+
+type IWishlistsModule interface {
+	Init()
+	Repository() wishlistRepositories.IWishlistRepository
+	Usecase() wishlistUsecases.IWishlistUsecases
+	Handler() wishlistHandlers.IWishlistHandlers
+}
+
+type wishlistsModule struct {
+	*moduleFactory
+	repository wishlistRepositories.IWishlistRepository
+	usecase    wishlistUsecases.IWishlistUsecases
+	handler    wishlistHandlers.IWishlistHandlers
+}
+
+func (m *moduleFactory) WishlistModule() IWishlistsModule {
+	repository := wishlistRepositories.WishlistRepository(m.server.db)
+	usecase := wishlistUsecases.WishlistUsecases(repository, m.ProductModule().Repository())
+	handler := wishlistHandlers.WishlistHandlers(m.server.cfg, usecase)
+
+	return &wishlistsModule{
+		moduleFactory: m,
+		repository:    repository,
+		usecase:       usecase,
+		handler:       handler,
+	}
+}
+
+func (w *wishlistsModule) Init() {
+	router := w.router.Group("/wishlists")
+	router.Get("/report", w.handler.MostWishlisted, w.mid.JwtAuth(), w.mid.Authorize(2))
+	router.Get("/:user_id", w.handler.FindWishlist, w.mid.JwtAuth(), w.mid.ParamsCheck())
+	router.Post("/:user_id", w.handler.InsertWishlist, w.mid.JwtAuth(), w.mid.ParamsCheck())
+	router.Delete("/:user_id/:product_id", w.handler.DeleteWishlist, w.mid.JwtAuth(), w.mid.ParamsCheck())
+}
+
+func (w *wishlistsModule) Repository() wishlistRepositories.IWishlistRepository { return w.repository }
+func (w *wishlistsModule) Usecase() wishlistUsecases.IWishlistUsecases          { return w.usecase }
+func (w *wishlistsModule) Handler() wishlistHandlers.IWishlistHandlers          { return w.handler }
@@ -3,6 +3,7 @@ package servers
 import (
 	"encoding/json"
 	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/pkgs/scheduler"
 	"log"
 	"os"
 	"os/signal"
@@ -17,15 +18,17 @@ type IServer interface {
 }
 
 type server struct {
-	app *fiber.App
-	db  *sqlx.DB
-	cfg config.IConfig
+	app       *fiber.App
+	db        *sqlx.DB
+	cfg       config.IConfig
+	scheduler scheduler.IScheduler
 }
 
 func NewServer(cfg config.IConfig, db *sqlx.DB) IServer {
 	return &server{
-		cfg: cfg,
-		db:  db,
+		cfg:       cfg,
+		db:        db,
+		scheduler: scheduler.NewScheduler(db),
 		app: fiber.New(
 			fiber.Config{
 				AppName:      cfg.App().Name(),
@@ -56,7 +59,18 @@ func (s *server) Start() {
 	modules.AppInfoModule()
 	modules.FilesModule().Init()
 	modules.ProductModule().Init()
-	modules.OrderModule()
+	modules.OrderModule().Init()
+	modules.ReportModule().Init()
+	modules.AddressModule().Init()
+	modules.PromotionModule().Init()
+	modules.TaxModule().Init()
+	modules.PaymentModule().Init()
+	modules.NotificationModule().Init()
+	modules.ShipmentModule().Init()
+	modules.ReviewModule().Init()
+	modules.WishlistModule().Init()
+	modules.RecommendationModule().Init()
+	modules.JobModule()
 
 	s.app.Use(middlewares.RouterCheck())
 	//graceful shut down
@@ -68,7 +82,13 @@ func (s *server) Start() {
 		_ = s.app.Shutdown()
 	}()
 
+	s.scheduler.Start()
+
 	// listen to host:port
 	log.Printf("server start on %v", s.cfg.App().Url())
 	s.app.Listen(s.cfg.App().Url())
+
+	// Listen returns after shutdown, let the running jobs finish before the db is closed
+	s.scheduler.Stop()
+	log.Println("background jobs stopped")
 }
@@ -0,0 +1,62 @@
+package shipmentCarriers
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/modules/shipments"
+	"sync"
+	"time"
+)
+
+const Fake = "fake"
+
+// FakeCarrier keeps tracking statuses in memory, it is meant for tests
+type FakeCarrier struct {
+	mu       sync.Mutex
+	statuses map[string]string
+}
+
+func NewFake() *FakeCarrier {
+	return &FakeCarrier{
+		statuses: make(map[string]string),
+	}
+}
+
+func (c *FakeCarrier) Name() string { return Fake }
+
+// SetStatus sets what the next Track call reports for the tracking number
+func (c *FakeCarrier) SetStatus(trackingNumber, status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.statuses[trackingNumber] = status
+}
+
+func (c *FakeCarrier) Track(trackingNumber string) (*shipments.TrackingInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status, ok := c.statuses[trackingNumber]
+	if !ok {
+		status = shipments.InTransit
+	}
+	if !shipments.IsStatus(status) {
+		return nil, fmt.Errorf("tracking number %s is invalid", trackingNumber)
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	info := &shipments.TrackingInfo{
+		Status: status,
+		Events: []*shipments.TrackingEvent{
+			{
+				Status:      status,
+				Description: fmt.Sprintf("parcel is %s", status),
+				Location:    "fake hub",
+				Time:        now,
+			},
+		},
+	}
+	if status == shipments.Delivered {
+		info.DeliveredAt = now
+	}
+	return info, nil
+}
@@ -0,0 +1,42 @@
+package shipmentCarriers
+
+import (
+	"errors"
+	"go_learn_project_rest_api/modules/shipments"
+)
+
+// ErrNotTrackable is returned by carriers that can not be polled, their shipments are updated by hand
+var ErrNotTrackable = errors.New("carrier does not support tracking")
+
+type ICarrierAdapter interface {
+	Name() string
+	Track(trackingNumber string) (*shipments.TrackingInfo, error)
+}
+
+// Carriers maps a carrier name to its adapter, unknown carriers are tracked by hand
+type Carriers map[string]ICarrierAdapter
+
+func NewCarriers(adapters ...ICarrierAdapter) Carriers {
+	carriers := make(Carriers)
+	for _, a := range adapters {
+		carriers[a.Name()] = a
+	}
+	return carriers
+}
+
+func (c Carriers) Get(name string) ICarrierAdapter {
+	if adapter, ok := c[name]; ok {
+		return adapter
+	}
+	return &manualCarrier{name: name}
+}
+
+type manualCarrier struct {
+	name string
+}
+
+func (c *manualCarrier) Name() string { return c.name }
+
+func (c *manualCarrier) Track(trackingNumber string) (*shipments.TrackingInfo, error) {
+	return nil, ErrNotTrackable
+}
@@ -0,0 +1,139 @@
+package shipmentHandlers
+
+import (
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/shipments"
+	"go_learn_project_rest_api/modules/shipments/shipmentUsecases"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type shipmentHandlersErrCode string
+
+const (
+	insertShipmentErr      shipmentHandlersErrCode = "shipments-001"
+	updateShipmentErr      shipmentHandlersErrCode = "shipments-002"
+	findShipmentByOrderErr shipmentHandlersErrCode = "shipments-003"
+	trackOrderErr          shipmentHandlersErrCode = "shipments-004"
+	pollShipmentErr        shipmentHandlersErrCode = "shipments-005"
+)
+
+type IShipmentHandlers interface {
+	InsertShipment(fiber.Ctx) error
+	UpdateShipment(fiber.Ctx) error
+	FindShipmentByOrder(fiber.Ctx) error
+	TrackOrder(fiber.Ctx) error
+	PollShipment(fiber.Ctx) error
+}
+
+type shipmentHandlers struct {
+	cfg              config.IConfig
+	shipmentUsecases shipmentUsecases.IShipmentUsecases
+}
+
+func ShipmentHandlers(cfg config.IConfig, shipmentUsecases shipmentUsecases.IShipmentUsecases) IShipmentHandlers {
+	return &shipmentHandlers{
+		cfg:              cfg,
+		shipmentUsecases: shipmentUsecases,
+	}
+}
+
+func (h *shipmentHandlers) InsertShipment(c fiber.Ctx) error {
+	req := &shipments.Shipment{
+		Parcels: make([]*shipments.Parcel, 0),
+	}
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertShipmentErr),
+			err.Error(),
+		).Res()
+	}
+	req.OrderId = strings.Trim(c.Params("order_id"), " ")
+
+	shipment, err := h.shipmentUsecases.InsertShipment(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertShipmentErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, shipment).Res()
+}
+
+func (h *shipmentHandlers) UpdateShipment(c fiber.Ctx) error {
+	req := new(shipments.UpdateShipmentReq)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateShipmentErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.Trim(c.Params("shipment_id"), " ")
+	req.Status = strings.ToLower(req.Status)
+
+	if !shipments.IsStatus(req.Status) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateShipmentErr),
+			"status is invalid",
+		).Res()
+	}
+
+	shipment, err := h.shipmentUsecases.UpdateShipment(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(updateShipmentErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, shipment).Res()
+}
+
+func (h *shipmentHandlers) FindShipmentByOrder(c fiber.Ctx) error {
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	result, err := h.shipmentUsecases.FindShipmentByOrder(orderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findShipmentByOrderErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *shipmentHandlers) TrackOrder(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	result, err := h.shipmentUsecases.TrackOrder(userId, orderId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(trackOrderErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *shipmentHandlers) PollShipment(c fiber.Ctx) error {
+	shipmentId := strings.Trim(c.Params("shipment_id"), " ")
+
+	shipment, err := h.shipmentUsecases.PollShipment(shipmentId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(pollShipmentErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, shipment).Res()
+}
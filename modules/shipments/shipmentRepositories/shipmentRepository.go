@@ -0,0 +1,208 @@
+package shipmentRepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go_learn_project_rest_api/modules/shipments"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const shipmentQuery = `
+	SELECT
+		COALESCE(json_agg(t ORDER BY t.created_at), '[]'::json)
+	FROM (
+		SELECT
+			s.id,
+			s.order_id,
+			s.carrier,
+			s.tracking_number,
+			s.status,
+			s.parcels,
+			s.events,
+			s.shipped_at,
+			s.delivered_at,
+			s.checked_at,
+			s.created_at,
+			s.updated_at
+		FROM shipments s
+		%s
+	) AS t;`
+
+type IShipmentRepository interface {
+	FindOneShipment(string) (*shipments.Shipment, error)
+	FindShipmentByOrder(string) ([]*shipments.Shipment, error)
+	FindTrackableShipment(limit int) ([]*shipments.Shipment, error)
+	InsertShipment(*shipments.Shipment) error
+	UpdateShipment(*shipments.Shipment) error
+}
+
+type shipmentRepository struct {
+	db *sqlx.DB
+}
+
+func ShipmentRepository(db *sqlx.DB) IShipmentRepository {
+	return &shipmentRepository{
+		db: db,
+	}
+}
+
+func (r *shipmentRepository) findShipment(where string, args ...any) ([]*shipments.Shipment, error) {
+	raw := make([]byte, 0)
+	if err := r.db.Get(&raw, fmt.Sprintf(shipmentQuery, where), args...); err != nil {
+		return nil, fmt.Errorf("get shipments failed: %v", err)
+	}
+
+	result := make([]*shipments.Shipment, 0)
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal shipments failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *shipmentRepository) FindOneShipment(shipmentId string) (*shipments.Shipment, error) {
+	result, err := r.findShipment(`WHERE s.id = $1`, shipmentId)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("shipment not found")
+	}
+	return result[0], nil
+}
+
+func (r *shipmentRepository) FindShipmentByOrder(orderId string) ([]*shipments.Shipment, error) {
+	return r.findShipment(`WHERE s.order_id = $1`, orderId)
+}
+
+// FindTrackableShipment returns the shipments still on the way, the least recently checked first
+func (r *shipmentRepository) FindTrackableShipment(limit int) ([]*shipments.Shipment, error) {
+	return r.findShipment(`
+		WHERE s.status IN ('shipped', 'in_transit')
+		ORDER BY s.checked_at ASC NULLS FIRST
+		LIMIT $1`, limit)
+}
+
+func (r *shipmentRepository) InsertShipment(req *shipments.Shipment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var status string
+	if err := tx.GetContext(ctx, &status, `SELECT "status"::TEXT FROM "orders" WHERE "id" = $1 FOR UPDATE;`, req.OrderId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("get order failed: %v", err)
+	}
+	if status != "paid" && status != "shipping" {
+		tx.Rollback()
+		return fmt.Errorf("order status %s can not be shipped", status)
+	}
+
+	parcels, err := json.Marshal(req.Parcels)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("marshal parcels failed: %v", err)
+	}
+
+	query := `
+	INSERT INTO "shipments" (
+		"order_id",
+		"carrier",
+		"tracking_number",
+		"parcels"
+	)
+	VALUES
+	($1, $2, $3, $4)
+		RETURNING "id";`
+
+	if err := tx.QueryRowxContext(
+		ctx,
+		query,
+		req.OrderId,
+		req.Carrier,
+		req.TrackingNumber,
+		string(parcels),
+	).Scan(&req.Id); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("insert shipment failed: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE "orders" SET "status" = 'shipping' WHERE "id" = $1;`, req.OrderId); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update order status failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// UpdateShipment saves the tracking status, the order is completed once all of its shipments are delivered
+func (r *shipmentRepository) UpdateShipment(req *shipments.Shipment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var events any
+	if req.Events != nil {
+		eventsBytes, err := json.Marshal(req.Events)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("marshal events failed: %v", err)
+		}
+		events = string(eventsBytes)
+	}
+
+	query := `
+	UPDATE "shipments" SET
+		"status" = $1,
+		"events" = COALESCE($2::jsonb, "events"),
+		"delivered_at" = CASE
+			WHEN $1 = 'delivered' THEN COALESCE($3::TIMESTAMP, "delivered_at", now())
+			ELSE NULL
+		END,
+		"checked_at" = COALESCE($4::TIMESTAMP, "checked_at")
+	WHERE "id" = $5;`
+
+	if _, err := tx.ExecContext(ctx, query, req.Status, events, req.DeliveredAt, req.CheckedAt, req.Id); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update shipment failed: %v", err)
+	}
+
+	if req.Status == shipments.Delivered {
+		query = `
+		UPDATE "orders" SET
+			"status" = 'completed'
+		WHERE "id" = $1
+		AND "status" = 'shipping'
+		AND NOT EXISTS (
+			SELECT 1
+			FROM "shipments" s
+			WHERE s."order_id" = $1
+			AND s."status" <> 'delivered'
+		);`
+
+		if _, err := tx.ExecContext(ctx, query, req.OrderId); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("complete order failed: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
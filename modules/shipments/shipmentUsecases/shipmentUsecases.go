@@ -0,0 +1,134 @@
+package shipmentUsecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_learn_project_rest_api/modules/orders/orderRepositories"
+	"go_learn_project_rest_api/modules/shipments"
+	"go_learn_project_rest_api/modules/shipments/shipmentCarriers"
+	"go_learn_project_rest_api/modules/shipments/shipmentRepositories"
+	"log"
+	"time"
+)
+
+type IShipmentUsecases interface {
+	InsertShipment(*shipments.Shipment) (*shipments.Shipment, error)
+	UpdateShipment(*shipments.UpdateShipmentReq) (*shipments.Shipment, error)
+	FindShipmentByOrder(orderId string) ([]*shipments.Shipment, error)
+	TrackOrder(userId, orderId string) ([]*shipments.Shipment, error)
+	PollShipment(shipmentId string) (*shipments.Shipment, error)
+	PollShipments(ctx context.Context, limit int) error
+}
+
+type shipmentUsecases struct {
+	shipmentRepository shipmentRepositories.IShipmentRepository
+	orderRepository    orderRepositories.IOrderRepository
+	carriers           shipmentCarriers.Carriers
+}
+
+func ShipmentUsecases(shipmentRepository shipmentRepositories.IShipmentRepository, orderRepository orderRepositories.IOrderRepository, carriers shipmentCarriers.Carriers) IShipmentUsecases {
+	return &shipmentUsecases{
+		shipmentRepository: shipmentRepository,
+		orderRepository:    orderRepository,
+		carriers:           carriers,
+	}
+}
+
+func (u *shipmentUsecases) InsertShipment(req *shipments.Shipment) (*shipments.Shipment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.Parcels == nil {
+		req.Parcels = make([]*shipments.Parcel, 0)
+	}
+
+	if err := u.shipmentRepository.InsertShipment(req); err != nil {
+		return nil, err
+	}
+	return u.shipmentRepository.FindOneShipment(req.Id)
+}
+
+func (u *shipmentUsecases) UpdateShipment(req *shipments.UpdateShipmentReq) (*shipments.Shipment, error) {
+	shipment, err := u.shipmentRepository.FindOneShipment(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	shipment.Status = req.Status
+	shipment.Events = nil
+	shipment.DeliveredAt = nil
+	shipment.CheckedAt = nil
+	if err := u.shipmentRepository.UpdateShipment(shipment); err != nil {
+		return nil, err
+	}
+	return u.shipmentRepository.FindOneShipment(shipment.Id)
+}
+
+func (u *shipmentUsecases) FindShipmentByOrder(orderId string) ([]*shipments.Shipment, error) {
+	return u.shipmentRepository.FindShipmentByOrder(orderId)
+}
+
+func (u *shipmentUsecases) TrackOrder(userId, orderId string) ([]*shipments.Shipment, error) {
+	order, err := u.orderRepository.FindOneOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userId {
+		return nil, fmt.Errorf("order not found")
+	}
+	return u.shipmentRepository.FindShipmentByOrder(orderId)
+}
+
+func (u *shipmentUsecases) PollShipment(shipmentId string) (*shipments.Shipment, error) {
+	shipment, err := u.shipmentRepository.FindOneShipment(shipmentId)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.poll(shipment); err != nil {
+		return nil, err
+	}
+	return u.shipmentRepository.FindOneShipment(shipmentId)
+}
+
+// PollShipments refreshes the shipments on the way, one failing carrier does not stop the others.
+// It stops between shipments once ctx is done, the rest are picked up next run
+func (u *shipmentUsecases) PollShipments(ctx context.Context, limit int) error {
+	list, err := u.shipmentRepository.FindTrackableShipment(limit)
+	if err != nil {
+		return err
+	}
+	for _, shipment := range list {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := u.poll(shipment); err != nil && !errors.Is(err, shipmentCarriers.ErrNotTrackable) {
+			log.Printf("poll shipment %s failed: %v\n", shipment.Id, err)
+		}
+	}
+	return nil
+}
+
+func (u *shipmentUsecases) poll(shipment *shipments.Shipment) error {
+	if shipment.IsFinal() {
+		return nil
+	}
+
+	info, err := u.carriers.Get(shipment.Carrier).Track(shipment.TrackingNumber)
+	if err != nil {
+		return err
+	}
+	if !shipments.IsStatus(info.Status) {
+		return fmt.Errorf("carrier %s returned unknown status %s", shipment.Carrier, info.Status)
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	shipment.Status = info.Status
+	shipment.Events = info.Events
+	shipment.DeliveredAt = nil
+	if info.DeliveredAt != "" {
+		shipment.DeliveredAt = &info.DeliveredAt
+	}
+	shipment.CheckedAt = &now
+	return u.shipmentRepository.UpdateShipment(shipment)
+}
@@ -0,0 +1,81 @@
+package shipments
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	Shipped   = "shipped"
+	InTransit = "in_transit"
+	Delivered = "delivered"
+	Failed    = "failed"
+)
+
+type Shipment struct {
+	Id             string           `db:"id" json:"id"`
+	OrderId        string           `db:"order_id" json:"order_id"`
+	Carrier        string           `db:"carrier" json:"carrier"`
+	TrackingNumber string           `db:"tracking_number" json:"tracking_number"`
+	Status         string           `db:"status" json:"status"`
+	Parcels        []*Parcel        `db:"parcels" json:"parcels"`
+	Events         []*TrackingEvent `db:"events" json:"events"`
+	ShippedAt      string           `db:"shipped_at" json:"shipped_at"`
+	DeliveredAt    *string          `db:"delivered_at" json:"delivered_at"`
+	CheckedAt      *string          `db:"checked_at" json:"checked_at"`
+	CreatedAt      string           `db:"created_at" json:"created_at"`
+	UpdatedAt      string           `db:"updated_at" json:"updated_at"`
+}
+
+// Parcel is one box of a shipment, a box may have its own tracking number
+type Parcel struct {
+	TrackingNumber string  `json:"tracking_number"`
+	Weight         float64 `json:"weight"` // kg
+	Note           string  `json:"note"`
+}
+
+type TrackingEvent struct {
+	Status      string `json:"status"`
+	Description string `json:"description"`
+	Location    string `json:"location"`
+	Time        string `json:"time"` // YYYY-MM-DD HH:MM:SS
+}
+
+// TrackingInfo is what a carrier reports for a tracking number
+type TrackingInfo struct {
+	Status      string
+	DeliveredAt string
+	Events      []*TrackingEvent
+}
+
+type UpdateShipmentReq struct {
+	Id     string `json:"-"`
+	Status string `json:"status"`
+}
+
+func IsStatus(status string) bool {
+	return status == Shipped || status == InTransit || status == Delivered || status == Failed
+}
+
+// IsFinal is true when the carrier will not report anything new
+func (s *Shipment) IsFinal() bool {
+	return s.Status == Delivered || s.Status == Failed
+}
+
+func (s *Shipment) Validate() error {
+	s.Carrier = strings.ToLower(strings.TrimSpace(s.Carrier))
+	s.TrackingNumber = strings.ToUpper(strings.TrimSpace(s.TrackingNumber))
+	if s.Carrier == "" {
+		return fmt.Errorf("carrier is required")
+	}
+	if s.TrackingNumber == "" {
+		return fmt.Errorf("tracking number is required")
+	}
+	for i, p := range s.Parcels {
+		if p == nil || p.Weight < 0 {
+			return fmt.Errorf("parcel %d is invalid", i+1)
+		}
+		p.TrackingNumber = strings.ToUpper(strings.TrimSpace(p.TrackingNumber))
+	}
+	return nil
+}
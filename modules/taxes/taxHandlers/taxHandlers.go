@@ -0,0 +1,99 @@
+package taxHandlers
+
+import (
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/taxes"
+	"go_learn_project_rest_api/modules/taxes/taxUsecases"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type taxHandlersErrCode string
+
+const (
+	findTaxRateErr   taxHandlersErrCode = "taxes-001"
+	insertTaxRateErr taxHandlersErrCode = "taxes-002"
+	deleteTaxRateErr taxHandlersErrCode = "taxes-003"
+)
+
+type ITaxHandlers interface {
+	FindTaxRate(fiber.Ctx) error
+	InsertTaxRate(fiber.Ctx) error
+	DeleteTaxRate(fiber.Ctx) error
+}
+
+type taxHandlers struct {
+	cfg         config.IConfig
+	taxUsecases taxUsecases.ITaxUsecases
+}
+
+func TaxHandlers(cfg config.IConfig, taxUsecases taxUsecases.ITaxUsecases) ITaxHandlers {
+	return &taxHandlers{
+		cfg:         cfg,
+		taxUsecases: taxUsecases,
+	}
+}
+
+func (h *taxHandlers) FindTaxRate(c fiber.Ctx) error {
+	result, err := h.taxUsecases.FindTaxRate()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findTaxRateErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *taxHandlers) InsertTaxRate(c fiber.Ctx) error {
+	req := new(taxes.TaxRate)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertTaxRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertTaxRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.taxUsecases.InsertTaxRate(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(insertTaxRateErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, result).Res()
+}
+
+func (h *taxHandlers) DeleteTaxRate(c fiber.Ctx) error {
+	id, err := strconv.Atoi(strings.Trim(c.Params("tax_rate_id"), " "))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deleteTaxRateErr),
+			"tax rate id is invalid",
+		).Res()
+	}
+
+	if err := h.taxUsecases.DeleteTaxRate(id); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(deleteTaxRateErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusNoContent, nil).Res()
+}
@@ -0,0 +1,151 @@
+package taxRepositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go_learn_project_rest_api/modules/taxes"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const taxRateColumns = `
+		"id",
+		"title",
+		"rate",
+		"category_id",
+		"region",
+		"is_active",
+		"created_at"::TEXT AS "created_at",
+		"updated_at"::TEXT AS "updated_at"`
+
+type ITaxRepository interface {
+	FindTaxRate() ([]*taxes.TaxRate, error)
+	FindOneTaxRate(int) (*taxes.TaxRate, error)
+	InsertTaxRate(*taxes.TaxRate) (int, error)
+	DeleteTaxRate(int) error
+	ResolveTaxRate(productId string, regions []string) (*taxes.TaxRate, error)
+}
+
+type taxRepository struct {
+	db *sqlx.DB
+}
+
+func TaxRepository(db *sqlx.DB) ITaxRepository {
+	return &taxRepository{
+		db: db,
+	}
+}
+
+func (r *taxRepository) FindTaxRate() ([]*taxes.TaxRate, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM "tax_rates"
+	ORDER BY "id";`, taxRateColumns)
+
+	result := make([]*taxes.TaxRate, 0)
+	if err := r.db.Select(&result, query); err != nil {
+		return nil, fmt.Errorf("get tax rates failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *taxRepository) FindOneTaxRate(id int) (*taxes.TaxRate, error) {
+	query := fmt.Sprintf(`
+	SELECT%s
+	FROM "tax_rates"
+	WHERE "id" = $1;`, taxRateColumns)
+
+	result := new(taxes.TaxRate)
+	if err := r.db.Get(result, query, id); err != nil {
+		return nil, fmt.Errorf("get tax rate failed: %v", err)
+	}
+	return result, nil
+}
+
+func (r *taxRepository) InsertTaxRate(req *taxes.TaxRate) (int, error) {
+	query := `
+	INSERT INTO "tax_rates" (
+		"title",
+		"rate",
+		"category_id",
+		"region"
+	)
+	VALUES
+	($1, $2, $3, $4)
+		RETURNING "id";`
+
+	if err := r.db.QueryRowxContext(
+		context.Background(),
+		query,
+		req.Title,
+		req.Rate,
+		req.CategoryId,
+		req.Region,
+	).Scan(&req.Id); err != nil {
+		return 0, fmt.Errorf("insert tax rate failed: %v", err)
+	}
+	return req.Id, nil
+}
+
+func (r *taxRepository) DeleteTaxRate(id int) error {
+	query := `DELETE FROM "tax_rates" WHERE "id" = $1;`
+
+	result, err := r.db.ExecContext(context.Background(), query, id)
+	if err != nil {
+		return fmt.Errorf("delete tax rate failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("tax rate not found")
+	}
+	return nil
+}
+
+// ResolveTaxRate picks the most specific active rate, category and region beat category only, then region only, then the default rate
+func (r *taxRepository) ResolveTaxRate(productId string, regions []string) (*taxes.TaxRate, error) {
+	lowerRegions := make([]string, 0, len(regions))
+	for _, region := range regions {
+		if region = strings.ToLower(strings.TrimSpace(region)); region != "" {
+			lowerRegions = append(lowerRegions, region)
+		}
+	}
+
+	query := `
+	SELECT
+		t.id,
+		t.title,
+		t.rate,
+		t.category_id,
+		t.region,
+		t.is_active,
+		t.created_at::TEXT AS created_at,
+		t.updated_at::TEXT AS updated_at
+	FROM tax_rates t
+	WHERE t.is_active
+	AND (
+		t.category_id IS NULL OR
+		t.category_id IN (
+			SELECT
+				pc.category_id
+			FROM products_categories pc
+			WHERE pc.product_id = $1
+		)
+	)
+	AND (t.region IS NULL OR LOWER(t.region) = ANY($2))
+	ORDER BY
+		(t.category_id IS NOT NULL) DESC,
+		(t.region IS NOT NULL) DESC,
+		t.id
+	LIMIT 1;`
+
+	result := new(taxes.TaxRate)
+	if err := r.db.Get(result, query, productId, lowerRegions); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("resolve tax rate failed: %v", err)
+	}
+	return result, nil
+}
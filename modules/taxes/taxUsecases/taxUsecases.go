@@ -0,0 +1,42 @@
+package taxUsecases
+
+import (
+	"go_learn_project_rest_api/modules/taxes"
+	"go_learn_project_rest_api/modules/taxes/taxRepositories"
+)
+
+type ITaxUsecases interface {
+	FindTaxRate() ([]*taxes.TaxRate, error)
+	InsertTaxRate(*taxes.TaxRate) (*taxes.TaxRate, error)
+	DeleteTaxRate(int) error
+}
+
+type taxUsecases struct {
+	taxRepository taxRepositories.ITaxRepository
+}
+
+func TaxUsecases(taxRepository taxRepositories.ITaxRepository) ITaxUsecases {
+	return &taxUsecases{
+		taxRepository: taxRepository,
+	}
+}
+
+func (u *taxUsecases) FindTaxRate() ([]*taxes.TaxRate, error) {
+	return u.taxRepository.FindTaxRate()
+}
+
+func (u *taxUsecases) InsertTaxRate(req *taxes.TaxRate) (*taxes.TaxRate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	id, err := u.taxRepository.InsertTaxRate(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.taxRepository.FindOneTaxRate(id)
+}
+
+func (u *taxUsecases) DeleteTaxRate(id int) error {
+	return u.taxRepository.DeleteTaxRate(id)
+}
@@ -0,0 +1,92 @@
+package taxes
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	Inclusive = "inclusive"
+	Exclusive = "exclusive"
+)
+
+type TaxRate struct {
+	Id         int     `db:"id" json:"id"`
+	Title      string  `db:"title" json:"title"`
+	Rate       float64 `db:"rate" json:"rate"` // percent, 7 is 7%
+	CategoryId *int    `db:"category_id" json:"category_id"`
+	Region     *string `db:"region" json:"region"`
+	IsActive   bool    `db:"is_active" json:"is_active"`
+	CreatedAt  string  `db:"created_at" json:"created_at"`
+	UpdatedAt  string  `db:"updated_at" json:"updated_at"`
+}
+
+type LineTax struct {
+	Title    string  `json:"title"`
+	Rate     float64 `json:"rate"`
+	Mode     string  `json:"mode"`
+	Net      float64 `json:"net"`
+	Tax      float64 `json:"tax"`
+	Gross    float64 `json:"gross"`
+	Discount float64 `json:"discount"` // share of the coupon discount, taken off the amount before the tax
+}
+
+func IsMode(mode string) bool {
+	return mode == Inclusive || mode == Exclusive
+}
+
+func (t *TaxRate) Validate() error {
+	if strings.TrimSpace(t.Title) == "" {
+		return fmt.Errorf("title is required")
+	}
+	if t.Rate < 0 || t.Rate > 100 {
+		return fmt.Errorf("rate must be between 0 and 100")
+	}
+	if t.Region != nil {
+		region := strings.TrimSpace(*t.Region)
+		if region == "" {
+			t.Region = nil
+		} else {
+			t.Region = &region
+		}
+	}
+	return nil
+}
+
+func round(n float64) float64 {
+	return math.Round(n*100) / 100
+}
+
+// Calculate splits the line amount into net and tax, inclusive prices already contain the tax
+func Calculate(rate *TaxRate, price float64, qty int, mode string) *LineTax {
+	return CalculateDiscounted(rate, price, qty, mode, 0)
+}
+
+// CalculateDiscounted is Calculate on the line amount less its discount, the discount is in the same mode as the price
+func CalculateDiscounted(rate *TaxRate, price float64, qty int, mode string, discount float64) *LineTax {
+	if !IsMode(mode) {
+		mode = Inclusive
+	}
+	result := &LineTax{
+		Mode:     mode,
+		Discount: round(discount),
+	}
+	if rate != nil {
+		result.Title = rate.Title
+		result.Rate = rate.Rate
+	}
+
+	amount := math.Max(price*float64(qty)-result.Discount, 0)
+	switch mode {
+	case Exclusive:
+		result.Net = round(amount)
+		result.Tax = round(amount * result.Rate / 100)
+		result.Gross = round(result.Net + result.Tax)
+	default:
+		result.Gross = round(amount)
+		result.Net = round(amount * 100 / (100 + result.Rate))
+		result.Tax = round(result.Gross - result.Net)
+	}
+	return result
+}
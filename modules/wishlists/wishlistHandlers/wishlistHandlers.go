@@ -0,0 +1,122 @@
+package wishlistHandlers
+
+import (
+	"go_learn_project_rest_api/config"
+	"go_learn_project_rest_api/modules/entities"
+	"go_learn_project_rest_api/modules/wishlists"
+	"go_learn_project_rest_api/modules/wishlists/wishlistUsecases"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type wishlistHandlersErrCode string
+
+const (
+	findWishlistErr   wishlistHandlersErrCode = "wishlists-001"
+	insertWishlistErr wishlistHandlersErrCode = "wishlists-002"
+	deleteWishlistErr wishlistHandlersErrCode = "wishlists-003"
+	mostWishlistedErr wishlistHandlersErrCode = "wishlists-004"
+)
+
+type IWishlistHandlers interface {
+	FindWishlist(fiber.Ctx) error
+	InsertWishlist(fiber.Ctx) error
+	DeleteWishlist(fiber.Ctx) error
+	MostWishlisted(fiber.Ctx) error
+}
+
+type wishlistHandlers struct {
+	cfg              config.IConfig
+	wishlistUsecases wishlistUsecases.IWishlistUsecases
+}
+
+func WishlistHandlers(cfg config.IConfig, wishlistUsecases wishlistUsecases.IWishlistUsecases) IWishlistHandlers {
+	return &wishlistHandlers{
+		cfg:              cfg,
+		wishlistUsecases: wishlistUsecases,
+	}
+}
+
+func (h *wishlistHandlers) FindWishlist(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+
+	result, err := h.wishlistUsecases.FindWishlist(userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(findWishlistErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
+
+func (h *wishlistHandlers) InsertWishlist(c fiber.Ctx) error {
+	req := new(wishlists.WishlistReq)
+	if err := c.Bind().Body(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertWishlistErr),
+			err.Error(),
+		).Res()
+	}
+	userId := strings.Trim(c.Params("user_id"), " ")
+	req.ProductId = strings.TrimSpace(req.ProductId)
+	if req.ProductId == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertWishlistErr),
+			"product_id is required",
+		).Res()
+	}
+
+	result, err := h.wishlistUsecases.InsertWishlist(userId, req.ProductId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(insertWishlistErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusCreated, result).Res()
+}
+
+func (h *wishlistHandlers) DeleteWishlist(c fiber.Ctx) error {
+	userId := strings.Trim(c.Params("user_id"), " ")
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	if err := h.wishlistUsecases.DeleteWishlist(userId, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(deleteWishlistErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusNoContent, nil).Res()
+}
+
+// MostWishlisted ranks the products by how many customers have them in their wishlist
+func (h *wishlistHandlers) MostWishlisted(c fiber.Ctx) error {
+	req := new(wishlists.WishlistReportFilter)
+	if err := c.Bind().Query(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(mostWishlistedErr),
+			err.Error(),
+		).Res()
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 20
+	}
+
+	result, err := h.wishlistUsecases.MostWishlisted(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(mostWishlistedErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).SuccessResponse(fiber.StatusOK, result).Res()
+}
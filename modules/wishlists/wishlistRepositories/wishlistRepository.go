@@ -0,0 +1,103 @@
+package wishlistRepositories
+
+import (
+	"context"
+	"fmt"
+	"go_learn_project_rest_api/modules/wishlists"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IWishlistRepository interface {
+	FindWishlist(userId string) ([]*wishlists.WishlistItem, error)
+	InsertWishlist(userId, productId string) error
+	DeleteWishlist(userId, productId string) error
+	MostWishlisted(*wishlists.WishlistReportFilter) ([]*wishlists.MostWishlisted, error)
+}
+
+type wishlistRepository struct {
+	db *sqlx.DB
+}
+
+func WishlistRepository(db *sqlx.DB) IWishlistRepository {
+	return &wishlistRepository{
+		db: db,
+	}
+}
+
+func (r *wishlistRepository) FindWishlist(userId string) ([]*wishlists.WishlistItem, error) {
+	query := `
+	SELECT
+		"product_id",
+		"created_at"::TEXT AS "created_at"
+	FROM "wishlists"
+	WHERE "user_id" = $1
+	ORDER BY "created_at" DESC;`
+
+	result := make([]*wishlists.WishlistItem, 0)
+	if err := r.db.Select(&result, query, userId); err != nil {
+		return nil, fmt.Errorf("get wishlist failed: %v", err)
+	}
+	return result, nil
+}
+
+// InsertWishlist keeps the first added time when the product is already in the wishlist
+func (r *wishlistRepository) InsertWishlist(userId, productId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	query := `
+	INSERT INTO "wishlists" (
+		"user_id",
+		"product_id"
+	)
+	VALUES ($1, $2)
+	ON CONFLICT ("user_id", "product_id") DO NOTHING;`
+
+	if _, err := r.db.ExecContext(ctx, query, userId, productId); err != nil {
+		return fmt.Errorf("insert wishlist failed: %v", err)
+	}
+	return nil
+}
+
+func (r *wishlistRepository) DeleteWishlist(userId, productId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	query := `
+	DELETE FROM "wishlists"
+	WHERE "user_id" = $1
+	AND "product_id" = $2;`
+
+	result, err := r.db.ExecContext(ctx, query, userId, productId)
+	if err != nil {
+		return fmt.Errorf("delete wishlist failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("product is not in the wishlist")
+	}
+	return nil
+}
+
+func (r *wishlistRepository) MostWishlisted(req *wishlists.WishlistReportFilter) ([]*wishlists.MostWishlisted, error) {
+	query := `
+	SELECT
+		w."product_id",
+		p."title",
+		product_current_price(p."id", p."price") AS "price",
+		p."status"::TEXT AS "status",
+		COUNT(*) AS "count",
+		MAX(w."created_at")::TEXT AS "last_added_at"
+	FROM "wishlists" w
+	JOIN "products" p ON p."id" = w."product_id"
+	GROUP BY w."product_id", p."id", p."title", p."price", p."status"
+	ORDER BY "count" DESC, w."product_id"
+	LIMIT $1;`
+
+	result := make([]*wishlists.MostWishlisted, 0)
+	if err := r.db.Select(&result, query, req.Limit); err != nil {
+		return nil, fmt.Errorf("get most wishlisted failed: %v", err)
+	}
+	return result, nil
+}
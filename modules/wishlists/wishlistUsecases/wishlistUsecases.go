@@ -0,0 +1,86 @@
+package wishlistUsecases
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/modules/products"
+	"go_learn_project_rest_api/modules/products/productRepositories"
+	"go_learn_project_rest_api/modules/wishlists"
+	"go_learn_project_rest_api/modules/wishlists/wishlistRepositories"
+)
+
+type IWishlistUsecases interface {
+	FindWishlist(userId string) ([]*wishlists.WishlistItem, error)
+	InsertWishlist(userId, productId string) ([]*wishlists.WishlistItem, error)
+	DeleteWishlist(userId, productId string) error
+	MostWishlisted(*wishlists.WishlistReportFilter) ([]*wishlists.MostWishlisted, error)
+}
+
+type wishlistUsecases struct {
+	wishlistRepository wishlistRepositories.IWishlistRepository
+	productRepository  productRepositories.IProductRepository
+}
+
+func WishlistUsecases(wishlistRepository wishlistRepositories.IWishlistRepository, productRepository productRepositories.IProductRepository) IWishlistUsecases {
+	return &wishlistUsecases{
+		wishlistRepository: wishlistRepository,
+		productRepository:  productRepository,
+	}
+}
+
+// FindWishlist fills every item with the product as it is now
+func (u *wishlistUsecases) FindWishlist(userId string) ([]*wishlists.WishlistItem, error) {
+	items, err := u.wishlistRepository.FindWishlist(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	productIds := make([]string, 0, len(items))
+	for _, item := range items {
+		productIds = append(productIds, item.ProductId)
+	}
+	prods, err := u.productRepository.FindProductByIds(productIds)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]*products.Product, len(prods))
+	for _, prod := range prods {
+		byId[prod.Id] = prod
+	}
+
+	// A product deleted since the wishlist was read is left out
+	result := make([]*wishlists.WishlistItem, 0, len(items))
+	for _, item := range items {
+		if item.Product = byId[item.ProductId]; item.Product != nil {
+			item.Available = wishlists.IsAvailable(item.Product)
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (u *wishlistUsecases) InsertWishlist(userId, productId string) ([]*wishlists.WishlistItem, error) {
+	prod, err := u.productRepository.FindOneProduct(productId)
+	if err != nil {
+		return nil, fmt.Errorf("product not found")
+	}
+	if !prod.Visible {
+		return nil, fmt.Errorf("product %s is not available", prod.Title)
+	}
+
+	if err := u.wishlistRepository.InsertWishlist(userId, productId); err != nil {
+		return nil, err
+	}
+	return u.FindWishlist(userId)
+}
+
+func (u *wishlistUsecases) DeleteWishlist(userId, productId string) error {
+	return u.wishlistRepository.DeleteWishlist(userId, productId)
+}
+
+func (u *wishlistUsecases) MostWishlisted(req *wishlists.WishlistReportFilter) ([]*wishlists.MostWishlisted, error) {
+	return u.wishlistRepository.MostWishlisted(req)
+}
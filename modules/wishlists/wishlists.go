@@ -0,0 +1,45 @@
+package wishlists
+
+import "go_learn_project_rest_api/modules/products"
+
+type WishlistItem struct {
+	ProductId string            `db:"product_id" json:"product_id"`
+	AddedAt   string            `db:"created_at" json:"added_at"`
+	Available bool              `db:"-" json:"available"`
+	Product   *products.Product `db:"-" json:"product"` // current price and stock, not the ones when it was added
+}
+
+type WishlistReq struct {
+	ProductId string `json:"product_id"`
+}
+
+type WishlistReportFilter struct {
+	Limit int `query:"limit"`
+}
+
+type MostWishlisted struct {
+	ProductId   string  `db:"product_id" json:"product_id"`
+	Title       string  `db:"title" json:"title"`
+	Price       float64 `db:"price" json:"price"`
+	Status      string  `db:"status" json:"status"`
+	Count       int     `db:"count" json:"count"`
+	LastAddedAt string  `db:"last_added_at" json:"last_added_at"`
+}
+
+// IsAvailable tells if the product can be ordered now, a product with variants needs one of them in stock
+func IsAvailable(p *products.Product) bool {
+	if !p.Visible {
+		return false
+	}
+	inStock := func(stock *int) bool { return stock == nil || *stock > 0 }
+
+	if len(p.Variants) == 0 {
+		return inStock(p.Stock)
+	}
+	for _, v := range p.Variants {
+		if inStock(v.Stock) {
+			return true
+		}
+	}
+	return false
+}
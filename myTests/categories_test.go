@@ -0,0 +1,49 @@
+package myTests
+
+import (
+	"go_learn_project_rest_api/modules/appInfo"
+	"testing"
+)
+
+type testSlugify struct {
+	title  string
+	expect string
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []testSlugify{
+		{title: "food & beverage", expect: "food-beverage"},
+		{title: "  Hot Drinks  ", expect: "hot-drinks"},
+		{title: "Tea-Time 2024!", expect: "tea-time-2024"},
+		{title: "&&", expect: ""},
+	}
+
+	for _, test := range tests {
+		if got := appInfo.Slugify(test.title); got != test.expect {
+			t.Errorf("expect: %v, got: %v", test.expect, got)
+		}
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	one, two := 1, 2
+	missing := 99
+	roots := appInfo.BuildTree([]*appInfo.Category{
+		{Id: 1, Title: "food"},
+		{Id: 2, Title: "drinks", ParentId: &one},
+		{Id: 3, Title: "coffee", ParentId: &two},
+		{Id: 4, Title: "tea", ParentId: &two},
+		{Id: 5, Title: "orphan", ParentId: &missing},
+	})
+
+	if len(roots) != 2 || roots[0].Id != 1 || roots[1].Id != 5 {
+		t.Fatalf("expect roots 1 and 5, got: %v", roots)
+	}
+	drinks := roots[0].Children
+	if len(drinks) != 1 || len(drinks[0].Children) != 2 {
+		t.Fatalf("expect drinks with 2 children, got: %v", drinks)
+	}
+	if drinks[0].Children[0].Title != "coffee" || drinks[0].Children[1].Title != "tea" {
+		t.Errorf("expect children in order, got: %v, %v", drinks[0].Children[0].Title, drinks[0].Children[1].Title)
+	}
+}
@@ -0,0 +1,85 @@
+package myTests
+
+import (
+	"errors"
+	"go_learn_project_rest_api/modules/promotions"
+	"testing"
+	"time"
+)
+
+type testCouponAvailable struct {
+	label      string
+	coupon     *promotions.Coupon
+	used       int
+	usedByUser int
+	isErr      bool
+}
+
+func TestCheckCouponAvailable(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	tomorrow := now.AddDate(0, 0, 1)
+
+	tests := []testCouponAvailable{
+		{label: "active", coupon: &promotions.Coupon{Code: "A", IsActive: true}},
+		{label: "inactive", coupon: &promotions.Coupon{Code: "A"}, isErr: true},
+		{label: "in window", coupon: &promotions.Coupon{Code: "A", IsActive: true, StartedAt: &yesterday, ExpiredAt: &tomorrow}},
+		{label: "not started", coupon: &promotions.Coupon{Code: "A", IsActive: true, StartedAt: &tomorrow}, isErr: true},
+		{label: "expired", coupon: &promotions.Coupon{Code: "A", IsActive: true, ExpiredAt: &yesterday}, isErr: true},
+		{label: "expires now", coupon: &promotions.Coupon{Code: "A", IsActive: true, ExpiredAt: &now}, isErr: true},
+		{label: "under limit", coupon: &promotions.Coupon{Code: "A", IsActive: true, UsageLimit: 10}, used: 9},
+		{label: "fully redeemed", coupon: &promotions.Coupon{Code: "A", IsActive: true, UsageLimit: 10}, used: 10, isErr: true},
+		{label: "unlimited", coupon: &promotions.Coupon{Code: "A", IsActive: true}, used: 1000, usedByUser: 1000},
+		{label: "per user limit", coupon: &promotions.Coupon{Code: "A", IsActive: true, UsageLimitPerUser: 1}, used: 5, usedByUser: 1, isErr: true},
+	}
+
+	for _, test := range tests {
+		err := test.coupon.CheckAvailable(now, test.used, test.usedByUser)
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+		}
+		var couponErr *promotions.CouponError
+		if err != nil && !errors.As(err, &couponErr) {
+			t.Errorf("%s: expect a coupon error, got: %T", test.label, err)
+		}
+	}
+}
+
+type testCouponDiscount struct {
+	label  string
+	coupon *promotions.Coupon
+	expect float64
+	isErr  bool
+}
+
+// Coffee 150 x 1 in category 1 and Steak 200 x 2 in category 2
+func TestCalculateCouponDiscount(t *testing.T) {
+	lines := []*promotions.CouponLine{
+		{ProductId: "P000001", CategoryIds: []int{1}, Price: 150, Qty: 1},
+		{ProductId: "P000002", CategoryIds: []int{2, 5}, Price: 200, Qty: 2},
+	}
+
+	tests := []testCouponDiscount{
+		{label: "percentage", coupon: &promotions.Coupon{Code: "A", Type: promotions.Percentage, Value: 10}, expect: 55},
+		{label: "percentage capped", coupon: &promotions.Coupon{Code: "A", Type: promotions.Percentage, Value: 50, MaxDiscount: 100}, expect: 100},
+		{label: "fixed", coupon: &promotions.Coupon{Code: "A", Type: promotions.Fixed, Value: 30}, expect: 30},
+		{label: "fixed larger than eligible", coupon: &promotions.Coupon{Code: "A", Type: promotions.Fixed, Value: 500, ProductIds: []string{"P000001"}}, expect: 150},
+		{label: "product only", coupon: &promotions.Coupon{Code: "A", Type: promotions.Percentage, Value: 10, ProductIds: []string{"P000002"}}, expect: 40},
+		{label: "category only", coupon: &promotions.Coupon{Code: "A", Type: promotions.Percentage, Value: 10, CategoryIds: []int{5}}, expect: 40},
+		{label: "rounded", coupon: &promotions.Coupon{Code: "A", Type: promotions.Percentage, Value: 3.333}, expect: 18.33},
+		{label: "min spend met", coupon: &promotions.Coupon{Code: "A", Type: promotions.Fixed, Value: 30, MinSpend: 550}, expect: 30},
+		{label: "min spend not met", coupon: &promotions.Coupon{Code: "A", Type: promotions.Fixed, Value: 30, MinSpend: 551}, isErr: true},
+		{label: "not applicable", coupon: &promotions.Coupon{Code: "A", Type: promotions.Fixed, Value: 30, CategoryIds: []int{9}}, isErr: true},
+	}
+
+	for _, test := range tests {
+		discount, err := test.coupon.CalculateDiscount(lines)
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+			continue
+		}
+		if discount != test.expect {
+			t.Errorf("%s: expect: %v, got: %v", test.label, test.expect, discount)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package myTests
+
+import (
+	"errors"
+	"go_learn_project_rest_api/modules/entities"
+	"testing"
+)
+
+type testIfMatch struct {
+	header  string
+	version int
+	err     error
+	isErr   bool
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []testIfMatch{
+		{header: entities.ETag(3), version: 3},
+		{header: ` "12" `, version: 12},
+		{header: "*", version: 0},
+		{header: "", err: entities.ErrIfMatchRequired, isErr: true},
+		{header: `W/"3"`, isErr: true},
+		{header: "3", isErr: true},
+		{header: `"0"`, isErr: true},
+		{header: `"abc"`, isErr: true},
+		{header: `"1", "2"`, isErr: true},
+	}
+
+	for _, test := range tests {
+		version, err := entities.ParseIfMatch(test.header)
+		if (err != nil) != test.isErr {
+			t.Errorf("%q: expect error: %v, got: %v", test.header, test.isErr, err)
+			continue
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%q: expect: %v, got: %v", test.header, test.err, err)
+		}
+		if version != test.version {
+			t.Errorf("%q: expect version: %d, got: %d", test.header, test.version, version)
+		}
+	}
+}
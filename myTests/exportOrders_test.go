@@ -0,0 +1,149 @@
+package myTests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"go_learn_project_rest_api/modules/orders"
+	"go_learn_project_rest_api/modules/orders/orderRepositories"
+	"go_learn_project_rest_api/modules/orders/orderUsecases"
+	"go_learn_project_rest_api/pkgs/utils"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type fakeExportOrderRepository struct {
+	orderRepositories.IOrderRepository
+	rows []*orders.OrderExportRow
+	err  error // returned once the rows are handed over, as a connection lost mid export
+}
+
+func (r *fakeExportOrderRepository) ExportOrder(req *orders.OrderFilter, fn func(*orders.OrderExportRow) error) error {
+	for _, row := range r.rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return r.err
+}
+
+type testOrderExportRow struct {
+	label string
+	row   *orders.OrderExportRow
+	csv   []string
+	xlsx  []any
+}
+
+func TestOrderExportRow(t *testing.T) {
+	tests := []testOrderExportRow{
+		{
+			label: "line",
+			row:   &orders.OrderExportRow{OrderId: "O000001", UserId: "U000001", Status: "paid", Address: "99 Sukhumvit, Bangkok", Contact: "0812345678", ProductId: "P000001", ProductTitle: "Coffee", Price: 35.5, Qty: 2, Total: 71, CreatedAt: "2025-06-01 12:00:00"},
+			csv:   []string{"O000001", "U000001", "paid", "99 Sukhumvit, Bangkok", "0812345678", "P000001", "Coffee", "35.50", "2", "0", "71.00", "2025-06-01 12:00:00"},
+			xlsx:  []any{"O000001", "U000001", "paid", "99 Sukhumvit, Bangkok", "0812345678", "P000001", "Coffee", 35.5, 2, 0, 71.0, "2025-06-01 12:00:00"},
+		},
+		{
+			label: "rounded amounts",
+			row:   &orders.OrderExportRow{OrderId: "O000002", Price: 33.333, Qty: 3, Total: 99.999},
+			csv:   []string{"O000002", "", "", "", "", "", "", "33.33", "3", "0", "100.00", ""},
+			xlsx:  []any{"O000002", "", "", "", "", "", "", 33.333, 3, 0, 99.999, ""},
+		},
+		{
+			label: "refunded line",
+			row:   &orders.OrderExportRow{OrderId: "O000003", ProductId: "P000001", Price: 35.5, Qty: 0, CanceledQty: 2, Total: 0},
+			csv:   []string{"O000003", "", "", "", "", "P000001", "", "35.50", "0", "2", "0.00", ""},
+			xlsx:  []any{"O000003", "", "", "", "", "P000001", "", 35.5, 0, 2, 0.0, ""},
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.row.CsvRecord(); !reflect.DeepEqual(got, test.csv) {
+			t.Errorf("%s: expect csv: %q, got: %q", test.label, test.csv, got)
+		}
+		if got := test.row.XlsxRow(); !reflect.DeepEqual(got, test.xlsx) {
+			t.Errorf("%s: expect xlsx: %v, got: %v", test.label, test.xlsx, got)
+		}
+		if len(test.row.CsvRecord()) != len(orders.OrderExportHeader) || len(test.row.XlsxRow()) != len(orders.OrderExportHeader) {
+			t.Errorf("%s: expect %d columns as the header", test.label, len(orders.OrderExportHeader))
+		}
+	}
+}
+
+type testExportOrder struct {
+	label  string
+	format string
+	err    error
+	isErr  bool
+}
+
+func TestExportOrder(t *testing.T) {
+	rows := []*orders.OrderExportRow{
+		{OrderId: "O000001", Status: "paid", ProductId: "P000001", ProductTitle: "Coffee", Price: 35.5, Qty: 2, Total: 71},
+		{OrderId: "O000001", Status: "paid", ProductId: "P000002", ProductTitle: "ชาเย็น", Price: 20, Qty: 1, Total: 20},
+	}
+
+	tests := []testExportOrder{
+		{label: "csv", format: "csv"},
+		{label: "xlsx", format: "xlsx"},
+		{label: "csv failed mid export", format: "csv", err: errors.New("connection reset"), isErr: true},
+		{label: "xlsx failed mid export", format: "xlsx", err: errors.New("connection reset"), isErr: true},
+		{label: "unknown format", format: "pdf", isErr: true},
+	}
+
+	for _, test := range tests {
+		usecase := orderUsecases.OrderUsecases(&fakeExportOrderRepository{rows: rows, err: test.err}, nil, nil, nil, nil, nil, nil, nil)
+
+		// As the handler does, a failure comes back before anything could be sent
+		f, size, err := utils.SpoolFile(func(w io.Writer) error {
+			return usecase.ExportOrder(&orders.OrderFilter{}, test.format, w)
+		})
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil || int64(len(data)) != size {
+			t.Errorf("%s: expect %d bytes, got: %d %v", test.label, size, len(data), err)
+			continue
+		}
+
+		got := make([][]string, 0)
+		switch test.format {
+		case "csv":
+			got, err = csv.NewReader(bytes.NewReader(data)).ReadAll()
+		case "xlsx":
+			var file *excelize.File
+			if file, err = excelize.OpenReader(bytes.NewReader(data)); err == nil {
+				got, err = file.GetRows(file.GetSheetName(0))
+				file.Close()
+			}
+		}
+		if err != nil {
+			t.Errorf("%s: read export failed: %v", test.label, err)
+			continue
+		}
+
+		expect := [][]string{orders.OrderExportHeader}
+		for _, row := range rows {
+			record := row.CsvRecord()
+			if test.format == "xlsx" {
+				// Cells are numbers, the sheet shows them without the trailing zeros
+				record[7], record[10] = strconv.FormatFloat(row.Price, 'f', -1, 64), strconv.FormatFloat(row.Total, 'f', -1, 64)
+				// Trailing empty cells are not stored
+				record = record[:len(record)-1]
+			}
+			expect = append(expect, record)
+		}
+		if !reflect.DeepEqual(got, expect) {
+			t.Errorf("%s: expect: %q, got: %q", test.label, expect, got)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package myTests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"go_learn_project_rest_api/modules/products"
+	"go_learn_project_rest_api/modules/products/productRepositories"
+	"go_learn_project_rest_api/modules/products/productUsecases"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeImportProductRepository struct {
+	productRepositories.IProductRepository
+	req    *products.ImportReq
+	export []*products.ProductExportRow
+}
+
+func (r *fakeImportProductRepository) ImportProduct(req *products.ImportReq) (*products.ImportRes, error) {
+	r.req = req
+	return &products.ImportRes{Mode: req.Mode, Total: len(req.Rows) + len(req.Errors), Failed: len(req.Errors), Errors: req.Errors}, nil
+}
+
+func (r *fakeImportProductRepository) ExportProduct(fn func(*products.ProductExportRow) error) error {
+	for _, row := range r.export {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type testImportCsv struct {
+	label  string
+	csv    string
+	isErr  bool
+	rows   []*products.ImportRow
+	errors []int // lines reported as failed
+}
+
+func TestImportProductCsv(t *testing.T) {
+	tests := []testImportCsv{
+		{
+			label: "valid rows",
+			csv: "\ufeffExternal_SKU,title,description,price,category,image_urls,status\n" +
+				"SKU-1, Coffee ,Hot,35.5,Drinks,https://cdn.shop/a.png|https://cdn.shop/b.png,\n" +
+				"SKU-2,Tea,,20, 1 | drinks |1,,Draft\n",
+			rows: []*products.ImportRow{
+				{Line: 2, ExternalSku: "SKU-1", Title: "Coffee", Description: "Hot", Price: 35.5, Categories: []string{"Drinks"}, ImageUrls: []string{"https://cdn.shop/a.png", "https://cdn.shop/b.png"}},
+				{Line: 3, ExternalSku: "SKU-2", Title: "Tea", Price: 20, Categories: []string{"1", "drinks"}, ImageUrls: []string{}, Status: products.ProductDraft},
+			},
+			errors: []int{},
+		},
+		{
+			label: "matched by id without a sku",
+			csv: "external_sku,title,price,category,id\n" +
+				",Coffee,35,Drinks,P000001\n" +
+				",Tea,20,Drinks,\n",
+			rows: []*products.ImportRow{
+				{Line: 2, Id: "P000001", Title: "Coffee", Price: 35, Categories: []string{"Drinks"}, ImageUrls: []string{}},
+			},
+			errors: []int{3},
+		},
+		{
+			label: "invalid rows are reported with their line",
+			csv: "external_sku,title,price,category,image_urls,status\n" +
+				"SKU-1,Coffee,abc,Drinks,,\n" +
+				"SKU-2,,10,Drinks,,\n" +
+				"SKU-3,Tea,10, | ,,\n" +
+				"SKU-4,Tea,-1,Drinks,,\n" +
+				"SKU-5,Tea,NaN,Drinks,,\n" +
+				"SKU-6,Tea,10,Drinks,ftp://cdn.shop/a.png,\n" +
+				"SKU-7,Tea,10,Drinks,,sold\n" +
+				"SKU-8,Tea,10,Drinks,,\n" +
+				"SKU-8,Tea again,10,Drinks,,\n",
+			rows: []*products.ImportRow{
+				{Line: 9, ExternalSku: "SKU-8", Title: "Tea", Price: 10, Categories: []string{"Drinks"}, ImageUrls: []string{}},
+			},
+			errors: []int{2, 3, 4, 5, 6, 7, 8, 10},
+		},
+		{
+			label: "blank lines are skipped",
+			csv: "external_sku,title,price,category\n" +
+				",,,\n" +
+				"SKU-1,Coffee,10,Drinks\n",
+			rows: []*products.ImportRow{
+				{Line: 3, ExternalSku: "SKU-1", Title: "Coffee", Price: 10, Categories: []string{"Drinks"}, ImageUrls: []string{}},
+			},
+			errors: []int{},
+		},
+		{label: "missing column", csv: "external_sku,title,category\nSKU-1,Coffee,Drinks\n", isErr: true},
+		{label: "neither sku nor id column", csv: "title,price,category\nCoffee,10,Drinks\n", isErr: true},
+		{label: "empty file", csv: "", isErr: true},
+	}
+
+	for _, test := range tests {
+		repo := new(fakeImportProductRepository)
+		usecase := productUsecases.ProductUsecases(repo)
+
+		_, err := usecase.ImportProductCsv(strings.NewReader(test.csv), products.ImportPartial)
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if !reflect.DeepEqual(repo.req.Rows, test.rows) {
+			t.Errorf("%s: expect rows:", test.label)
+			for _, row := range test.rows {
+				t.Errorf("\t%+v", row)
+			}
+			t.Errorf("got:")
+			for _, row := range repo.req.Rows {
+				t.Errorf("\t%+v", row)
+			}
+		}
+		lines := make([]int, 0)
+		for _, rowErr := range repo.req.Errors {
+			lines = append(lines, rowErr.Line)
+		}
+		if !reflect.DeepEqual(lines, test.errors) {
+			t.Errorf("%s: expect failed lines: %v, got: %v", test.label, test.errors, lines)
+		}
+	}
+}
+
+// An export is imported back as it is, products without an external sku are matched by id
+func TestExportProductCsvRoundTrip(t *testing.T) {
+	repo := &fakeImportProductRepository{export: []*products.ProductExportRow{
+		{Id: "P000001", ExternalSku: "SKU-1", Title: "Coffee, hot", Description: "Line one\nline two", Price: 35.5, Categories: "drinks|hot-drinks", ImageUrls: "https://cdn.shop/a.png", Status: products.ProductPublished},
+		{Id: "P000002", Title: "Tea", Price: 20, Categories: "drinks", Status: products.ProductDraft},
+	}}
+	usecase := productUsecases.ProductUsecases(repo)
+
+	buf := new(bytes.Buffer)
+	if err := usecase.ExportProductCsv(buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	header, err := csv.NewReader(bytes.NewReader(buf.Bytes())).Read()
+	if err != nil || !reflect.DeepEqual(header, products.ProductCsvHeader) {
+		t.Errorf("header: expect: %v, got: %v %v", products.ProductCsvHeader, header, err)
+	}
+
+	if _, err := usecase.ImportProductCsv(bytes.NewReader(buf.Bytes()), products.ImportTransactional); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(repo.req.Errors) != 0 {
+		t.Errorf("import: expect no errors, got: %+v", repo.req.Errors[0])
+	}
+	expect := []*products.ImportRow{
+		{Line: 2, Id: "P000001", ExternalSku: "SKU-1", Title: "Coffee, hot", Description: "Line one\nline two", Price: 35.5, Categories: []string{"drinks", "hot-drinks"}, ImageUrls: []string{"https://cdn.shop/a.png"}, Status: products.ProductPublished},
+		{Line: 4, Id: "P000002", Title: "Tea", Price: 20, Categories: []string{"drinks"}, ImageUrls: []string{}, Status: products.ProductDraft},
+	}
+	if !reflect.DeepEqual(repo.req.Rows, expect) {
+		for i, row := range repo.req.Rows {
+			t.Errorf("row %d: expect: %+v, got: %+v", i, expect[i], row)
+		}
+	}
+}
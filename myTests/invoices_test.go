@@ -0,0 +1,157 @@
+package myTests
+
+import (
+	"bytes"
+	"go_learn_project_rest_api/modules/orders"
+	"go_learn_project_rest_api/modules/orders/orderRepositories"
+	"go_learn_project_rest_api/modules/orders/orderUsecases"
+	"go_learn_project_rest_api/modules/products"
+	"go_learn_project_rest_api/modules/taxes"
+	"math"
+	"testing"
+)
+
+type testInvoiceTotals struct {
+	label    string
+	order    *orders.Order
+	lines    int
+	subtotal float64
+	discount float64
+	taxTotal float64
+	total    float64
+}
+
+// Coffee 107 x 1 and Steak 214 x 2, the totals are what the order was placed with
+func TestInvoiceTotals(t *testing.T) {
+	vat := &taxes.TaxRate{Title: "VAT", Rate: 7}
+	coffee := &products.Product{Id: "P000001", Title: "Coffee", Price: 107}
+	steak := &products.Product{Id: "P000002", Title: "Steak", Price: 214}
+	beer := &products.Product{Id: "P000003", Title: "Beer", Price: 100}
+
+	tests := []testInvoiceTotals{
+		{
+			label: "inclusive without coupon",
+			order: &orders.Order{TaxTotal: 35, TotalPaid: 535, Products: []*orders.ProductsOrder{
+				{Qty: 1, Product: coffee, Tax: taxes.Calculate(vat, 107, 1, taxes.Inclusive)},
+				{Qty: 2, Product: steak, Tax: taxes.Calculate(vat, 214, 2, taxes.Inclusive)},
+			}},
+			lines: 2, subtotal: 535, taxTotal: 35, total: 535,
+		},
+		{
+			label: "inclusive with coupon",
+			order: &orders.Order{CouponCode: "TEN", TaxTotal: 31.5, TotalPaid: 481.5, Products: []*orders.ProductsOrder{
+				{Qty: 1, Product: coffee, Tax: taxes.CalculateDiscounted(vat, 107, 1, taxes.Inclusive, 10.7)},
+				{Qty: 2, Product: steak, Tax: taxes.CalculateDiscounted(vat, 214, 2, taxes.Inclusive, 42.8)},
+			}},
+			lines: 2, subtotal: 535, discount: 53.5, taxTotal: 31.5, total: 481.5,
+		},
+		{
+			label: "exclusive with coupon",
+			order: &orders.Order{CouponCode: "TEN", TaxTotal: 12.6, TotalPaid: 192.6, Products: []*orders.ProductsOrder{
+				{Qty: 2, Product: beer, Tax: taxes.CalculateDiscounted(vat, 100, 2, taxes.Exclusive, 20)},
+			}},
+			lines: 1, subtotal: 214, discount: 21.4, taxTotal: 12.6, total: 192.6,
+		},
+		{
+			label: "refunded line left out",
+			order: &orders.Order{TaxTotal: 7, TotalPaid: 107, Products: []*orders.ProductsOrder{
+				{Qty: 1, Product: coffee, Tax: taxes.Calculate(vat, 107, 1, taxes.Inclusive)},
+				{Qty: 0, CanceledQty: 2, Product: steak, Tax: taxes.Calculate(vat, 214, 0, taxes.Inclusive)},
+			}},
+			lines: 1, subtotal: 107, taxTotal: 7, total: 107,
+		},
+		{
+			label: "order before tax snapshots",
+			order: &orders.Order{TotalPaid: 321, Products: []*orders.ProductsOrder{
+				{Qty: 3, Product: coffee},
+			}},
+			lines: 1, subtotal: 321, total: 321,
+		},
+	}
+
+	for _, test := range tests {
+		got := test.order.InvoiceTotals()
+		if len(got.Lines) != test.lines {
+			t.Errorf("%s: expect lines: %d, got: %d", test.label, test.lines, len(got.Lines))
+		}
+		for name, pair := range map[string][2]float64{
+			"subtotal": {test.subtotal, got.Subtotal},
+			"discount": {test.discount, got.Discount},
+			"tax":      {test.taxTotal, got.TaxTotal},
+			"total":    {test.total, got.Total},
+		} {
+			if math.Abs(pair[0]-pair[1]) > 0.001 {
+				t.Errorf("%s: expect %s: %v, got: %v", test.label, name, pair[0], pair[1])
+			}
+		}
+		if math.Abs(got.Subtotal-got.Discount-got.Total) > 0.001 {
+			t.Errorf("%s: subtotal %v less discount %v is not the total %v", test.label, got.Subtotal, got.Discount, got.Total)
+		}
+	}
+}
+
+type fakeInvoiceOrderRepository struct {
+	orderRepositories.IOrderRepository
+	order    *orders.Order
+	invoices int
+}
+
+func (r *fakeInvoiceOrderRepository) FindOneOrder(orderId string) (*orders.Order, error) {
+	return r.order, nil
+}
+
+func (r *fakeInvoiceOrderRepository) FindOrInsertInvoice(orderId string) (*orders.Invoice, error) {
+	r.invoices++
+	return &orders.Invoice{OrderId: orderId, Number: "INV-2025-000001", IssuedAt: "2025-06-01T12:00:00+07:00"}, nil
+}
+
+type testInvoicePdf struct {
+	label  string
+	status string
+	isErr  bool
+}
+
+func TestInvoicePdf(t *testing.T) {
+	vat := &taxes.TaxRate{Title: "VAT", Rate: 7}
+
+	tests := []testInvoicePdf{
+		{label: "paid", status: "paid"},
+		{label: "shipping", status: "shipping"},
+		{label: "completed", status: "completed"},
+		{label: "waiting", status: "waiting", isErr: true},
+		{label: "pending verification", status: "pending_verification", isErr: true},
+		{label: "canceled", status: "canceled", isErr: true},
+	}
+
+	for _, test := range tests {
+		repo := &fakeInvoiceOrderRepository{order: &orders.Order{
+			Id:        "O000001",
+			UserId:    "U000001",
+			Status:    test.status,
+			Contact:   "สมชาย ใจดี 0812345678",
+			Address:   "99 ถนนสุขุมวิท กรุงเทพฯ 10110",
+			TaxTotal:  7,
+			TotalPaid: 107,
+			Products: []*orders.ProductsOrder{
+				{Qty: 1, Product: &products.Product{Id: "P000001", Title: "กาแฟเย็น", Price: 107}, Tax: taxes.Calculate(vat, 107, 1, taxes.Inclusive)},
+			},
+		}}
+		usecase := orderUsecases.OrderUsecases(repo, nil, nil, nil, nil, nil, nil, nil)
+
+		buf := new(bytes.Buffer)
+		invoice, err := usecase.InvoicePdf("U000001", "O000001", "ร้านค้า", buf)
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+			continue
+		}
+		if test.isErr {
+			if repo.invoices != 0 {
+				t.Errorf("%s: expect no invoice number taken, got: %d", test.label, repo.invoices)
+			}
+			continue
+		}
+		if invoice.Number != "INV-2025-000001" || !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+			t.Errorf("%s: expect a pdf of INV-2025-000001, got: %s %q", test.label, invoice.Number, buf.Bytes()[:min(buf.Len(), 8)])
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package myTests
+
+import (
+	"go_learn_project_rest_api/modules/entities"
+	"testing"
+)
+
+type testCursorPage struct {
+	label      string
+	after      *entities.Cursor
+	rows       int
+	hasMore    bool
+	expectNext string // id the next cursor points at, empty for none
+	expectPrev string // id the prev cursor points at, empty for none
+}
+
+func TestCursorPage(t *testing.T) {
+	sort := &entities.SortReq{OrderBy: "title", Sort: "ASC"}
+	ids := []string{"P000001", "P000002", "P000003"}
+
+	tests := []testCursorPage{
+		{label: "first page", rows: 3, hasMore: true, expectNext: "P000003"},
+		{label: "only page", rows: 3},
+		{label: "middle page", after: &entities.Cursor{Id: "P000000"}, rows: 3, hasMore: true, expectNext: "P000003", expectPrev: "P000001"},
+		{label: "last page", after: &entities.Cursor{Id: "P000000"}, rows: 3, expectPrev: "P000001"},
+		{label: "back to middle", after: &entities.Cursor{Id: "P000004", Backward: true}, rows: 3, hasMore: true, expectNext: "P000003", expectPrev: "P000001"},
+		{label: "back to first", after: &entities.Cursor{Id: "P000004", Backward: true}, rows: 3, expectNext: "P000003"},
+		{label: "empty", after: &entities.Cursor{Id: "P000000"}},
+	}
+
+	for _, test := range tests {
+		req := &entities.CursorReq{After: test.after}
+		next, prev := entities.CursorPage(req, sort, test.rows, test.hasMore, func(i int) (string, string) {
+			return "title " + ids[i], ids[i]
+		})
+
+		for _, c := range []struct {
+			cursor   string
+			expect   string
+			backward bool
+		}{
+			{cursor: next, expect: test.expectNext},
+			{cursor: prev, expect: test.expectPrev, backward: true},
+		} {
+			if c.expect == "" {
+				if c.cursor != "" {
+					t.Errorf("%s: expect no cursor, got: %v", test.label, c.cursor)
+				}
+				continue
+			}
+
+			// A cursor made for the sort decodes back to the row it points at
+			decode := &entities.CursorReq{Cursor: c.cursor}
+			if err := decode.Decode(sort); err != nil {
+				t.Errorf("%s: expect: %v, got: %v", test.label, nil, err.Error())
+				continue
+			}
+			if decode.After.Id != c.expect || decode.After.Value != "title "+c.expect || decode.After.Backward != c.backward {
+				t.Errorf("%s: expect: %v, got: %v", test.label, c.expect, decode.After)
+			}
+		}
+	}
+
+	// A cursor is only valid for the sort it was made with
+	next, _ := entities.CursorPage(&entities.CursorReq{}, sort, 3, true, func(i int) (string, string) { return ids[i], ids[i] })
+	if err := (&entities.CursorReq{Cursor: next}).Decode(&entities.SortReq{OrderBy: "price", Sort: "ASC"}); err == nil {
+		t.Errorf("expect: cursor does not match order_by and sort, got: %v", nil)
+	}
+	if err := (&entities.CursorReq{Cursor: "not a cursor"}).Decode(sort); err == nil {
+		t.Errorf("expect: cursor is invalid, got: %v", nil)
+	}
+}
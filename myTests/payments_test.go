@@ -0,0 +1,162 @@
+package myTests
+
+import (
+	"fmt"
+	"go_learn_project_rest_api/modules/payments"
+	"go_learn_project_rest_api/modules/payments/paymentProviders"
+	"go_learn_project_rest_api/modules/payments/paymentUsecases"
+	"math"
+	"testing"
+)
+
+type testWebhookSignature struct {
+	label     string
+	secret    []byte
+	body      string
+	signature string
+	isErr     bool
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	secret := []byte("webhook-secret")
+	body := `{"provider_ref":"FAKE000001","status":"succeeded","amount":150}`
+
+	tests := []testWebhookSignature{
+		{label: "valid", secret: secret, body: body, signature: paymentProviders.Sign(secret, []byte(body))},
+		{label: "other secret", secret: secret, body: body, signature: paymentProviders.Sign([]byte("other"), []byte(body)), isErr: true},
+		{label: "body changed", secret: secret, body: `{"provider_ref":"FAKE000001","status":"succeeded","amount":1}`, signature: paymentProviders.Sign(secret, []byte(body)), isErr: true},
+		{label: "not hex", secret: secret, body: body, signature: "not-a-signature", isErr: true},
+		{label: "empty signature", secret: secret, body: body, isErr: true},
+		{label: "no secret configured", body: body, signature: paymentProviders.Sign(nil, []byte(body)), isErr: true},
+		{label: "invalid status", secret: secret, body: `{"provider_ref":"FAKE000001","status":"refunded"}`, signature: paymentProviders.Sign(secret, []byte(`{"provider_ref":"FAKE000001","status":"refunded"}`)), isErr: true},
+	}
+
+	for _, test := range tests {
+		_, err := paymentProviders.NewFake(test.secret).VerifyWebhook([]byte(test.body), test.signature)
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+		}
+	}
+}
+
+type testWebhookStatus struct {
+	label  string
+	status string
+	event  *payments.WebhookEvent
+	expect string
+	moves  bool
+}
+
+func TestWebhookStatus(t *testing.T) {
+	tests := []testWebhookStatus{
+		{label: "paid", status: payments.Pending, event: &payments.WebhookEvent{Status: payments.Succeeded, Amount: 150}, expect: payments.Succeeded, moves: true},
+		{label: "wrong amount", status: payments.Pending, event: &payments.WebhookEvent{Status: payments.Succeeded, Amount: 149}, expect: payments.Failed, moves: true},
+		{label: "failed", status: payments.Pending, event: &payments.WebhookEvent{Status: payments.Failed}, expect: payments.Failed, moves: true},
+		{label: "replayed success", status: payments.Succeeded, event: &payments.WebhookEvent{Status: payments.Succeeded, Amount: 150}, expect: payments.Succeeded},
+		{label: "failed after success", status: payments.Succeeded, event: &payments.WebhookEvent{Status: payments.Failed}, expect: payments.Succeeded},
+		{label: "success after failed", status: payments.Failed, event: &payments.WebhookEvent{Status: payments.Succeeded, Amount: 150}, expect: payments.Failed},
+		{label: "refunded", status: payments.Refunded, event: &payments.WebhookEvent{Status: payments.Succeeded, Amount: 150}, expect: payments.Refunded},
+	}
+
+	for _, test := range tests {
+		status, moves := payments.WebhookStatus(&payments.Payment{Amount: 150, Status: test.status}, test.event)
+		if status != test.expect || moves != test.moves {
+			t.Errorf("%s: expect: %s %v, got: %s %v", test.label, test.expect, test.moves, status, moves)
+		}
+	}
+}
+
+type testRefundAmount struct {
+	label    string
+	payment  *payments.Payment
+	amount   float64
+	expect   float64
+	isErr    bool
+	provider float64 // what the provider has refunded after the call
+}
+
+// fakePaymentRepository keeps one payment in memory with the same bounds as the SQL
+type fakePaymentRepository struct {
+	payment *payments.Payment
+}
+
+func (r *fakePaymentRepository) FindOnePayment(string) (*payments.Payment, error) {
+	p := *r.payment
+	return &p, nil
+}
+func (r *fakePaymentRepository) FindPaymentByRef(string, string) (*payments.Payment, error) {
+	return r.FindOnePayment("")
+}
+func (r *fakePaymentRepository) FindPaymentByOrder(string) ([]*payments.Payment, error) {
+	return nil, nil
+}
+func (r *fakePaymentRepository) InsertPayment(*payments.Payment) (string, error) { return "", nil }
+func (r *fakePaymentRepository) ApplyWebhook(_ *payments.Payment, event *payments.WebhookEvent) error {
+	r.payment.Status = event.Status
+	return nil
+}
+func (r *fakePaymentRepository) UpdateRefund(_ string, amount float64) error {
+	if r.payment.Status != payments.Succeeded || r.payment.RefundedAmount+amount > r.payment.Amount+0.009 {
+		return fmt.Errorf("payment has been changed, please try again")
+	}
+	r.payment.RefundedAmount += amount
+	if r.payment.RefundedAmount >= r.payment.Amount-0.009 {
+		r.payment.Status = payments.Refunded
+	}
+	return nil
+}
+func (r *fakePaymentRepository) ReleaseRefund(_ string, amount float64) error {
+	r.payment.RefundedAmount = math.Max(r.payment.RefundedAmount-amount, 0)
+	r.payment.Status = payments.Succeeded
+	return nil
+}
+
+func TestRefundAmount(t *testing.T) {
+	tests := []testRefundAmount{
+		{label: "all", payment: &payments.Payment{Amount: 150, Status: payments.Succeeded}, expect: 150, provider: 150},
+		{label: "part", payment: &payments.Payment{Amount: 150, Status: payments.Succeeded}, amount: 50.5, expect: 50.5, provider: 50.5},
+		{label: "rest", payment: &payments.Payment{Amount: 150, RefundedAmount: 100, Status: payments.Succeeded}, expect: 50, provider: 50},
+		{label: "more than left", payment: &payments.Payment{Amount: 150, RefundedAmount: 100, Status: payments.Succeeded}, amount: 60, isErr: true},
+		{label: "negative", payment: &payments.Payment{Amount: 150, Status: payments.Succeeded}, amount: -1, isErr: true},
+		{label: "pending", payment: &payments.Payment{Amount: 150, Status: payments.Pending}, isErr: true},
+		{label: "already refunded", payment: &payments.Payment{Amount: 150, RefundedAmount: 150, Status: payments.Refunded}, isErr: true},
+	}
+
+	for _, test := range tests {
+		repo := &fakePaymentRepository{payment: test.payment}
+		provider := paymentProviders.NewFake([]byte("secret"))
+		usecase := paymentUsecases.PaymentUsecases(repo, nil, provider)
+
+		payment, err := usecase.Refund(&payments.RefundReq{PaymentId: "1", Amount: test.amount})
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+			continue
+		}
+		if err == nil && math.Abs(payment.RefundedAmount-test.payment.RefundedAmount) > 0.001 {
+			t.Errorf("%s: refunded amount is not the stored one", test.label)
+		}
+		if test.isErr {
+			continue
+		}
+		if got := provider.Refunds[payment.ProviderRef]; math.Abs(got-test.provider) > 0.001 {
+			t.Errorf("%s: expect provider refund: %v, got: %v", test.label, test.provider, got)
+		}
+		if test.payment.RefundedAmount > test.payment.Amount+0.009 {
+			t.Errorf("%s: refunded %v of %v", test.label, test.payment.RefundedAmount, test.payment.Amount)
+		}
+	}
+}
+
+func TestRefundProviderFailed(t *testing.T) {
+	repo := &fakePaymentRepository{payment: &payments.Payment{Amount: 150, Status: payments.Succeeded}}
+	provider := paymentProviders.NewFake([]byte("secret"))
+	provider.FailRefund = true
+	usecase := paymentUsecases.PaymentUsecases(repo, nil, provider)
+
+	if _, err := usecase.Refund(&payments.RefundReq{PaymentId: "1"}); err == nil {
+		t.Fatalf("expect error when the provider fails")
+	}
+	if repo.payment.RefundedAmount != 0 || repo.payment.Status != payments.Succeeded {
+		t.Errorf("expect the refund to be released, got: %v %s", repo.payment.RefundedAmount, repo.payment.Status)
+	}
+}
@@ -0,0 +1,39 @@
+package myTests
+
+import (
+	"go_learn_project_rest_api/modules/products"
+	"testing"
+	"time"
+)
+
+type testValidateSchedule struct {
+	label    string
+	schedule *products.ScheduledPrice
+	isErr    bool
+}
+
+func TestValidateScheduledPrice(t *testing.T) {
+	start := time.Now().Add(time.Hour).Format(time.RFC3339)
+	end := time.Now().Add(time.Hour * 48).Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	empty := ""
+	notTime := "tomorrow"
+
+	tests := []testValidateSchedule{
+		{label: "sale", schedule: &products.ScheduledPrice{Price: 99, StartsAt: start, EndsAt: &end}},
+		{label: "no end", schedule: &products.ScheduledPrice{Price: 99, StartsAt: start}},
+		{label: "empty end", schedule: &products.ScheduledPrice{Price: 99, StartsAt: start, EndsAt: &empty}},
+		{label: "negative price", schedule: &products.ScheduledPrice{Price: -1, StartsAt: start}, isErr: true},
+		{label: "invalid start", schedule: &products.ScheduledPrice{Price: 99, StartsAt: "2025-01-01"}, isErr: true},
+		{label: "invalid end", schedule: &products.ScheduledPrice{Price: 99, StartsAt: start, EndsAt: &notTime}, isErr: true},
+		{label: "end before start", schedule: &products.ScheduledPrice{Price: 99, StartsAt: end, EndsAt: &start}, isErr: true},
+		{label: "ended", schedule: &products.ScheduledPrice{Price: 99, StartsAt: past, EndsAt: &past}, isErr: true},
+	}
+
+	for _, test := range tests {
+		err := test.schedule.Validate()
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package myTests
+
+import (
+	"errors"
+	"go_learn_project_rest_api/modules/products"
+	"go_learn_project_rest_api/modules/products/productRepositories"
+	"go_learn_project_rest_api/modules/recommendations"
+	"go_learn_project_rest_api/modules/recommendations/recommendationRepositories"
+	"go_learn_project_rest_api/modules/recommendations/recommendationUsecases"
+	"reflect"
+	"testing"
+)
+
+type testFill struct {
+	label  string
+	limit  int
+	seen   []string
+	lists  [][]string
+	expect []string
+}
+
+func TestFillRecommendations(t *testing.T) {
+	tests := []testFill{
+		{label: "first list only", limit: 2, lists: [][]string{{"P1", "P2", "P3"}}, expect: []string{"P1", "P2"}},
+		{label: "fallback fills the rest", limit: 3, lists: [][]string{{"P1"}, {"P2", "P3", "P4"}}, expect: []string{"P1", "P2", "P3"}},
+		{label: "no duplicates", limit: 3, lists: [][]string{{"P1", "P2"}, {"P2", "P1", "P3"}}, expect: []string{"P1", "P2", "P3"}},
+		{label: "seen left out", limit: 3, seen: []string{"P1"}, lists: [][]string{{"P1", "P2"}, {"P3"}}, expect: []string{"P2", "P3"}},
+		{label: "nothing to recommend", limit: 3, lists: [][]string{{}, {}}, expect: []string{}},
+	}
+
+	for _, test := range tests {
+		seen := make(map[string]bool)
+		for _, id := range test.seen {
+			seen[id] = true
+		}
+		lists := make([][]*recommendations.Recommendation, 0)
+		for _, ids := range test.lists {
+			list := make([]*recommendations.Recommendation, 0)
+			for _, id := range ids {
+				list = append(list, &recommendations.Recommendation{ProductId: id})
+			}
+			lists = append(lists, list)
+		}
+
+		got := make([]string, 0)
+		for _, r := range recommendations.Fill(test.limit, seen, lists...) {
+			got = append(got, r.ProductId)
+		}
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("%s: expect: %v, got: %v", test.label, test.expect, got)
+		}
+		for _, id := range got {
+			if !seen[id] {
+				t.Errorf("%s: %s is not marked as seen", test.label, id)
+			}
+		}
+	}
+}
+
+type fakeRecommendationProductRepository struct {
+	productRepositories.IProductRepository
+	products map[string]*products.Product
+	err      error
+	calls    int
+}
+
+func (r *fakeRecommendationProductRepository) FindProductByIds(productIds []string) ([]*products.Product, error) {
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	result := make([]*products.Product, 0)
+	for _, id := range productIds {
+		if p, ok := r.products[id]; ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+type fakeRecommendationRepository struct {
+	recommendationRepositories.IRecommendationRepository
+	together []*recommendations.Recommendation
+	related  []*recommendations.Recommendation
+}
+
+func (r *fakeRecommendationRepository) FindBoughtTogether(productId string, limit int) ([]*recommendations.Recommendation, error) {
+	return r.together, nil
+}
+
+func (r *fakeRecommendationRepository) FindSameCategory(productId string, limit int) ([]*recommendations.Recommendation, error) {
+	return r.related, nil
+}
+
+func (r *fakeRecommendationRepository) FindBestSeller(limit int) ([]*recommendations.Recommendation, error) {
+	return []*recommendations.Recommendation{}, nil
+}
+
+type testFindRecommendation struct {
+	label     string
+	productId string
+	dbErr     error
+	notFound  bool
+	isErr     bool
+	together  []string
+	related   []string
+}
+
+// P4 is ranked but deleted before the products are loaded
+func TestFindRecommendation(t *testing.T) {
+	tests := []testFindRecommendation{
+		{label: "found", productId: "P1", together: []string{"P2"}, related: []string{"P3"}},
+		{label: "not found", productId: "P9", notFound: true, isErr: true},
+		{label: "not visible", productId: "P5", notFound: true, isErr: true},
+		{label: "database error", productId: "P1", dbErr: errors.New("connection refused"), isErr: true},
+	}
+
+	for _, test := range tests {
+		productRepo := &fakeRecommendationProductRepository{
+			products: map[string]*products.Product{
+				"P1": {Id: "P1", Visible: true},
+				"P2": {Id: "P2", Visible: true},
+				"P3": {Id: "P3", Visible: true},
+				"P5": {Id: "P5"},
+			},
+			err: test.dbErr,
+		}
+		recommendationRepo := &fakeRecommendationRepository{
+			together: []*recommendations.Recommendation{{ProductId: "P2"}, {ProductId: "P4"}},
+			related:  []*recommendations.Recommendation{{ProductId: "P3"}},
+		}
+		usecase := recommendationUsecases.RecommendationUsecases(recommendationRepo, productRepo)
+
+		res, err := usecase.FindRecommendation(test.productId, &recommendations.RecommendationFilter{Limit: 4})
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+			continue
+		}
+		if errors.Is(err, recommendations.ErrProductNotFound) != test.notFound {
+			t.Errorf("%s: expect not found: %v, got: %v", test.label, test.notFound, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		ids := func(list []*recommendations.Recommendation) []string {
+			result := make([]string, 0)
+			for _, r := range list {
+				if r.Product == nil || r.Product.Id != r.ProductId {
+					t.Errorf("%s: %s has no product", test.label, r.ProductId)
+				}
+				result = append(result, r.ProductId)
+			}
+			return result
+		}
+		if got := ids(res.BoughtTogether); !reflect.DeepEqual(got, test.together) {
+			t.Errorf("%s: expect bought together: %v, got: %v", test.label, test.together, got)
+		}
+		if got := ids(res.Related); !reflect.DeepEqual(got, test.related) {
+			t.Errorf("%s: expect related: %v, got: %v", test.label, test.related, got)
+		}
+		if productRepo.calls != 2 {
+			t.Errorf("%s: expect 2 product queries, got: %d", test.label, productRepo.calls)
+		}
+	}
+}
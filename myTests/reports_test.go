@@ -0,0 +1,81 @@
+package myTests
+
+import (
+	"go_learn_project_rest_api/modules/reports"
+	"testing"
+)
+
+type testReport struct {
+	req    *reports.ReportFilter
+	expect string
+}
+
+// Seeded data has one paid order (O000001): 1 x Coffee (150) and 2 x Steak (200)
+var reportRange = &reports.ReportFilter{
+	StartDate: "2000-01-01",
+	EndDate:   "2100-01-01",
+	GroupBy:   "month",
+}
+
+func TestRevenueByProduct(t *testing.T) {
+	tests := []testReport{
+		{
+			req:    reportRange,
+			expect: `[{"product_id":"P000002","title":"Steak","revenue":400,"orders":1,"units":2},{"product_id":"P000001","title":"Coffee","revenue":150,"orders":1,"units":1}]`,
+		},
+		{
+			req: &reports.ReportFilter{
+				StartDate: "1990-01-01",
+				EndDate:   "1990-12-31",
+				GroupBy:   "month",
+			},
+			expect: `[]`,
+		},
+	}
+
+	reportsModule := SetupTest().ReportModule()
+	for _, test := range tests {
+		result, err := reportsModule.Usecase().RevenueByProduct(test.req)
+		if err != nil {
+			t.Errorf("expect: %v, got: %v", nil, err.Error())
+		}
+		if CompressToJSON(&result) != test.expect {
+			t.Errorf("expect: %v, got: %v", test.expect, CompressToJSON(&result))
+		}
+	}
+}
+
+func TestRevenueByCategory(t *testing.T) {
+	tests := []testReport{
+		{
+			req:    reportRange,
+			expect: `[{"category_id":1,"title":"food & beverage","revenue":550,"orders":1,"units":3}]`,
+		},
+	}
+
+	reportsModule := SetupTest().ReportModule()
+	for _, test := range tests {
+		result, err := reportsModule.Usecase().RevenueByCategory(test.req)
+		if err != nil {
+			t.Errorf("expect: %v, got: %v", nil, err.Error())
+		}
+		if CompressToJSON(&result) != test.expect {
+			t.Errorf("expect: %v, got: %v", test.expect, CompressToJSON(&result))
+		}
+	}
+}
+
+func TestRevenueByPeriod(t *testing.T) {
+	reportsModule := SetupTest().ReportModule()
+
+	result, err := reportsModule.Usecase().RevenueByPeriod(reportRange)
+	if err != nil {
+		t.Errorf("expect: %v, got: %v", nil, err.Error())
+	}
+	if len(result) != 1 {
+		t.Fatalf("expect: %v, got: %v", 1, len(result))
+	}
+	if result[0].Revenue != 550 || result[0].Orders != 1 || result[0].Units != 3 {
+		t.Errorf("expect: %v, got: %v", `{"revenue":550,"orders":1,"units":3}`, CompressToJSON(result[0]))
+	}
+}
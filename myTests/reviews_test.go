@@ -0,0 +1,33 @@
+package myTests
+
+import (
+	"go_learn_project_rest_api/modules/reviews"
+	"strings"
+	"testing"
+)
+
+type testValidateReview struct {
+	label    string
+	review   *reviews.Review
+	isUpdate bool
+	isErr    bool
+}
+
+func TestValidateReview(t *testing.T) {
+	tests := []testValidateReview{
+		{label: "valid", review: &reviews.Review{Rating: 5, Comment: "Great coffee"}},
+		{label: "no comment", review: &reviews.Review{Rating: 1}},
+		{label: "rating too low", review: &reviews.Review{Rating: 0, Comment: "meh"}, isErr: true},
+		{label: "rating too high", review: &reviews.Review{Rating: 6}, isErr: true},
+		{label: "comment too long", review: &reviews.Review{Rating: 3, Comment: strings.Repeat("a", 2001)}, isErr: true},
+		{label: "update comment only", review: &reviews.Review{Comment: "Changed my mind"}, isUpdate: true},
+		{label: "update nothing", review: &reviews.Review{Comment: "   "}, isUpdate: true, isErr: true},
+	}
+
+	for _, test := range tests {
+		err := test.review.Validate(test.isUpdate)
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package myTests
+
+import (
+	"context"
+	"go_learn_project_rest_api/pkgs/scheduler"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeLocker struct {
+	mu     sync.Mutex
+	held   map[string]bool
+	denied bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, name string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.denied || l.held[name] {
+		return nil, nil
+	}
+	l.held[name] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, name)
+	}, nil
+}
+
+type testSchedulerJob struct {
+	label   string
+	job     func(runs *int32) *scheduler.Job
+	denied  bool
+	wait    time.Duration
+	minRuns int32
+	maxRuns int32
+}
+
+func TestScheduler(t *testing.T) {
+	tests := []testSchedulerJob{
+		{
+			label: "runs every interval",
+			job: func(runs *int32) *scheduler.Job {
+				return &scheduler.Job{Name: "tick", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+					atomic.AddInt32(runs, 1)
+					return nil
+				}}
+			},
+			wait:    55 * time.Millisecond,
+			minRuns: 2,
+			maxRuns: 6,
+		},
+		{
+			label: "zero interval is skipped",
+			job: func(runs *int32) *scheduler.Job {
+				return &scheduler.Job{Name: "zero", Run: func(ctx context.Context) error {
+					atomic.AddInt32(runs, 1)
+					return nil
+				}}
+			},
+			wait: 30 * time.Millisecond,
+		},
+		{
+			label: "lock held by another instance",
+			job: func(runs *int32) *scheduler.Job {
+				return &scheduler.Job{Name: "locked", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+					atomic.AddInt32(runs, 1)
+					return nil
+				}}
+			},
+			denied: true,
+			wait:   35 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		var runs int32
+		s := scheduler.NewSchedulerWithLocker(&fakeLocker{held: make(map[string]bool), denied: test.denied})
+		s.Add(test.job(&runs))
+		s.Start()
+		time.Sleep(test.wait)
+		s.Stop()
+
+		got := atomic.LoadInt32(&runs)
+		if got < test.minRuns || got > test.maxRuns {
+			t.Errorf("%s: expect runs between %d and %d, got: %d", test.label, test.minRuns, test.maxRuns, got)
+		}
+	}
+}
+
+func TestSchedulerTimeoutAndStop(t *testing.T) {
+	timedOut := make(chan error, 1)
+	stopped := make(chan error, 1)
+
+	s := scheduler.NewSchedulerWithLocker(&fakeLocker{held: make(map[string]bool)})
+	s.Add(&scheduler.Job{Name: "slow", Interval: 10 * time.Millisecond, Timeout: 5 * time.Millisecond, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		select {
+		case timedOut <- ctx.Err():
+		default:
+		}
+		return ctx.Err()
+	}})
+	s.Add(&scheduler.Job{Name: "long", Interval: 10 * time.Millisecond, Timeout: time.Hour, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		select {
+		case stopped <- ctx.Err():
+		default:
+		}
+		return ctx.Err()
+	}})
+	s.Start()
+	time.Sleep(30 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("stop: expect the running jobs to be canceled, still waiting")
+	}
+
+	if err := <-timedOut; err != context.DeadlineExceeded {
+		t.Errorf("timeout: expect: %v, got: %v", context.DeadlineExceeded, err)
+	}
+	if err := <-stopped; err != context.Canceled {
+		t.Errorf("stop: expect: %v, got: %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,51 @@
+package myTests
+
+import (
+	"errors"
+	"go_learn_project_rest_api/modules/shipments"
+	"go_learn_project_rest_api/modules/shipments/shipmentCarriers"
+	"testing"
+)
+
+type testCarrier struct {
+	carrier        string
+	trackingNumber string
+	setStatus      string
+	expect         string
+	err            error
+}
+
+func TestCarrierTrack(t *testing.T) {
+	fake := shipmentCarriers.NewFake()
+	carriers := shipmentCarriers.NewCarriers(fake)
+
+	tests := []testCarrier{
+		{carrier: shipmentCarriers.Fake, trackingNumber: "TH001", expect: shipments.InTransit},
+		{carrier: shipmentCarriers.Fake, trackingNumber: "TH002", setStatus: shipments.Delivered, expect: shipments.Delivered},
+		{carrier: "kerry", trackingNumber: "KE001", err: shipmentCarriers.ErrNotTrackable},
+	}
+
+	for _, test := range tests {
+		if test.setStatus != "" {
+			fake.SetStatus(test.trackingNumber, test.setStatus)
+		}
+
+		info, err := carriers.Get(test.carrier).Track(test.trackingNumber)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("expect: %v, got: %v", test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expect: %v, got: %v", nil, err.Error())
+			continue
+		}
+		if info.Status != test.expect {
+			t.Errorf("expect: %v, got: %v", test.expect, info.Status)
+		}
+		if (info.DeliveredAt != "") != (test.expect == shipments.Delivered) {
+			t.Errorf("expect delivered_at only when delivered, got: %v", info.DeliveredAt)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package myTests
+
+import (
+	"go_learn_project_rest_api/modules/orders"
+	"go_learn_project_rest_api/modules/products"
+	"go_learn_project_rest_api/modules/promotions"
+	"go_learn_project_rest_api/modules/taxes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type testLineTax struct {
+	label    string
+	mode     string
+	price    float64
+	qty      int
+	discount float64
+	expect   *taxes.LineTax
+}
+
+func TestCalculateTax(t *testing.T) {
+	vat := &taxes.TaxRate{Title: "VAT", Rate: 7}
+
+	tests := []testLineTax{
+		{label: "inclusive", mode: taxes.Inclusive, price: 107, qty: 2, expect: &taxes.LineTax{Title: "VAT", Rate: 7, Mode: taxes.Inclusive, Net: 200, Tax: 14, Gross: 214}},
+		{label: "exclusive", mode: taxes.Exclusive, price: 100, qty: 2, expect: &taxes.LineTax{Title: "VAT", Rate: 7, Mode: taxes.Exclusive, Net: 200, Tax: 14, Gross: 214}},
+		{label: "inclusive with coupon", mode: taxes.Inclusive, price: 107, qty: 2, discount: 21.4, expect: &taxes.LineTax{Title: "VAT", Rate: 7, Mode: taxes.Inclusive, Net: 180, Tax: 12.6, Gross: 192.6, Discount: 21.4}},
+		{label: "exclusive with coupon", mode: taxes.Exclusive, price: 100, qty: 2, discount: 20, expect: &taxes.LineTax{Title: "VAT", Rate: 7, Mode: taxes.Exclusive, Net: 180, Tax: 12.6, Gross: 192.6, Discount: 20}},
+		{label: "discount larger than the line", mode: taxes.Exclusive, price: 10, qty: 1, discount: 15, expect: &taxes.LineTax{Title: "VAT", Rate: 7, Mode: taxes.Exclusive, Discount: 15}},
+		{label: "unknown mode is inclusive", mode: "", price: 107, qty: 1, expect: &taxes.LineTax{Title: "VAT", Rate: 7, Mode: taxes.Inclusive, Net: 100, Tax: 7, Gross: 107}},
+	}
+
+	for _, test := range tests {
+		got := taxes.CalculateDiscounted(vat, test.price, test.qty, test.mode, test.discount)
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("%s: expect: %+v, got: %+v", test.label, test.expect, got)
+		}
+	}
+}
+
+type testOrderTax struct {
+	label    string
+	mode     string
+	coupon   *promotions.Coupon
+	discount float64
+	taxTotal float64
+	total    float64
+}
+
+// Coffee 107 x 1 and Steak 214 x 2, only the steak is in the coupon category
+func TestOrderTaxWithCoupon(t *testing.T) {
+	vat := &taxes.TaxRate{Title: "VAT", Rate: 7}
+	lines := []*promotions.CouponLine{
+		{ProductId: "P000001", CategoryIds: []int{1}, Price: 107, Qty: 1},
+		{ProductId: "P000002", CategoryIds: []int{2}, Price: 214, Qty: 2},
+	}
+
+	tests := []testOrderTax{
+		{label: "inclusive without coupon", mode: taxes.Inclusive, taxTotal: 35, total: 535},
+		{label: "exclusive without coupon", mode: taxes.Exclusive, taxTotal: 37.45, total: 572.45},
+		{label: "inclusive 10%", mode: taxes.Inclusive, coupon: &promotions.Coupon{Code: "TEN", Type: promotions.Percentage, Value: 10}, discount: 53.5, taxTotal: 31.5, total: 481.5},
+		{label: "exclusive 10%", mode: taxes.Exclusive, coupon: &promotions.Coupon{Code: "TEN", Type: promotions.Percentage, Value: 10}, discount: 53.5, taxTotal: 33.7, total: 515.2},
+		{label: "inclusive fixed on a category", mode: taxes.Inclusive, coupon: &promotions.Coupon{Code: "STEAK", Type: promotions.Fixed, Value: 42.8, CategoryIds: []int{2}}, discount: 42.8, taxTotal: 32.2, total: 492.2},
+	}
+
+	for _, test := range tests {
+		shares := make([]float64, len(lines))
+		discount := 0.0
+		if test.coupon != nil {
+			var err error
+			discount, err = test.coupon.CalculateDiscount(lines)
+			if err != nil {
+				t.Errorf("%s: %v", test.label, err)
+				continue
+			}
+			shares = test.coupon.SpreadDiscount(lines, discount)
+		}
+		if discount != test.discount {
+			t.Errorf("%s: expect discount: %v, got: %v", test.label, test.discount, discount)
+		}
+
+		var taxTotal, total, spread float64
+		for i, line := range lines {
+			tax := taxes.CalculateDiscounted(vat, line.Price, line.Qty, test.mode, shares[i])
+			taxTotal += tax.Tax
+			total += tax.Gross
+			spread += tax.Discount
+		}
+		if math.Abs(spread-discount) > 0.001 {
+			t.Errorf("%s: shares add up to %v, expect: %v", test.label, spread, discount)
+		}
+		if math.Abs(taxTotal-test.taxTotal) > 0.001 || math.Abs(total-test.total) > 0.001 {
+			t.Errorf("%s: expect tax: %v total: %v, got tax: %v total: %v", test.label, test.taxTotal, test.total, taxTotal, total)
+		}
+	}
+}
+
+type testSpreadDiscount struct {
+	label    string
+	coupon   *promotions.Coupon
+	discount float64
+	expect   []float64
+}
+
+func TestSpreadDiscount(t *testing.T) {
+	lines := []*promotions.CouponLine{
+		{ProductId: "P1", Price: 10, Qty: 1},
+		{ProductId: "P2", Price: 10, Qty: 1},
+		{ProductId: "P3", Price: 10, Qty: 1},
+	}
+
+	tests := []testSpreadDiscount{
+		{label: "even", coupon: &promotions.Coupon{}, discount: 3, expect: []float64{1, 1, 1}},
+		{label: "rounding rest on the last line", coupon: &promotions.Coupon{}, discount: 10, expect: []float64{3.33, 3.33, 3.34}},
+		{label: "eligible lines only", coupon: &promotions.Coupon{ProductIds: []string{"P1", "P3"}}, discount: 5, expect: []float64{2.5, 0, 2.5}},
+		{label: "nothing eligible", coupon: &promotions.Coupon{ProductIds: []string{"P9"}}, discount: 5, expect: []float64{0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		got := test.coupon.SpreadDiscount(lines, test.discount)
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("%s: expect: %v, got: %v", test.label, test.expect, got)
+		}
+	}
+}
+
+type testRefundLineTax struct {
+	label  string
+	line   *orders.ProductsOrder
+	qty    int
+	amount float64
+	left   float64
+}
+
+func TestRefundLineTax(t *testing.T) {
+	vat := &taxes.TaxRate{Title: "VAT", Rate: 7}
+	coffee := &products.Product{Id: "P000001", Price: 107}
+	steak := &products.Product{Id: "P000002", Price: 100}
+
+	tests := []testRefundLineTax{
+		{label: "inclusive part", line: &orders.ProductsOrder{Qty: 3, Product: coffee, Tax: taxes.Calculate(vat, 107, 3, taxes.Inclusive)}, qty: 1, amount: 107, left: 214},
+		{label: "inclusive all", line: &orders.ProductsOrder{Qty: 3, Product: coffee, Tax: taxes.Calculate(vat, 107, 3, taxes.Inclusive)}, qty: 3, amount: 321, left: 0},
+		{label: "exclusive part", line: &orders.ProductsOrder{Qty: 2, Product: steak, Tax: taxes.Calculate(vat, 100, 2, taxes.Exclusive)}, qty: 1, amount: 107, left: 107},
+		{label: "discounted part", line: &orders.ProductsOrder{Qty: 2, Product: coffee, Tax: taxes.CalculateDiscounted(vat, 107, 2, taxes.Inclusive, 21.4)}, qty: 1, amount: 96.3, left: 96.3},
+		{label: "no snapshot", line: &orders.ProductsOrder{Qty: 2, Product: coffee}, qty: 1, amount: 107, left: 107},
+	}
+
+	for _, test := range tests {
+		tax, amount := orders.RefundLineTax(test.line, test.qty)
+		if math.Abs(amount-test.amount) > 0.001 || math.Abs(tax.Gross-test.left) > 0.001 {
+			t.Errorf("%s: expect refund: %v left: %v, got refund: %v left: %v", test.label, test.amount, test.left, amount, tax.Gross)
+		}
+	}
+}
+
+type testRefundInSteps struct {
+	label string
+	mode  string
+	price float64
+	qty   int
+	steps []int
+}
+
+// Refunding a line piece by piece gives back what was paid for it, no cent is lost to rounding
+func TestRefundLineTaxInSteps(t *testing.T) {
+	vat := &taxes.TaxRate{Title: "VAT", Rate: 7}
+
+	tests := []testRefundInSteps{
+		{label: "inclusive one by one", mode: taxes.Inclusive, price: 99.99, qty: 3, steps: []int{1, 1, 1}},
+		{label: "exclusive one by one", mode: taxes.Exclusive, price: 33.33, qty: 3, steps: []int{1, 1, 1}},
+		{label: "inclusive uneven", mode: taxes.Inclusive, price: 10.01, qty: 7, steps: []int{2, 4, 1}},
+		{label: "exclusive uneven", mode: taxes.Exclusive, price: 10.01, qty: 7, steps: []int{3, 3, 1}},
+	}
+
+	for _, test := range tests {
+		for _, discount := range []float64{0, 10} {
+			line := &orders.ProductsOrder{
+				Qty:     test.qty,
+				Product: &products.Product{Id: "P000001", Price: test.price},
+				Tax:     taxes.CalculateDiscounted(vat, test.price, test.qty, test.mode, discount),
+			}
+			paid := line.Tax.Gross
+
+			refunded := 0.0
+			for _, qty := range test.steps {
+				tax, amount := orders.RefundLineTax(line, qty)
+				if amount < 0 {
+					t.Errorf("%s discount %v: refund of %d is negative: %v", test.label, discount, qty, amount)
+				}
+				refunded += amount
+				line.Qty -= qty
+				line.CanceledQty += qty
+				line.Tax = tax
+			}
+
+			if math.Abs(refunded-paid) > 0.001 {
+				t.Errorf("%s discount %v: expect refunded: %v, got: %v", test.label, discount, paid, refunded)
+			}
+			if line.Tax.Gross != 0 || line.Tax.Tax != 0 || line.Tax.Discount != 0 {
+				t.Errorf("%s discount %v: expect nothing left on the line, got: %+v", test.label, discount, line.Tax)
+			}
+		}
+	}
+}
+
+type testRefundableAmount struct {
+	label  string
+	order  *orders.Order
+	expect float64
+}
+
+// What was paid less what the refunds gave back, an override above the refunded lines counts in full
+func TestRefundableAmount(t *testing.T) {
+	tests := []testRefundableAmount{
+		{label: "no refund", order: &orders.Order{TotalPaid: 535}, expect: 535},
+		{
+			label: "refund of a line",
+			order: &orders.Order{TotalPaid: 428, Refunds: []*orders.Refund{
+				{Amount: 107, Lines: []*orders.RefundLine{{Amount: 107}}},
+			}},
+			expect: 428,
+		},
+		{
+			label: "override above the line",
+			order: &orders.Order{TotalPaid: 428, Refunds: []*orders.Refund{
+				{Amount: 300, Lines: []*orders.RefundLine{{Amount: 107}}},
+			}},
+			expect: 235,
+		},
+		{
+			label: "failed refund is still owed",
+			order: &orders.Order{TotalPaid: 428, Refunds: []*orders.Refund{
+				{Amount: 107, Status: orders.RefundFailed, Lines: []*orders.RefundLine{{Amount: 107}}},
+			}},
+			expect: 428,
+		},
+		{
+			label: "everything given back",
+			order: &orders.Order{TotalPaid: 0, Refunds: []*orders.Refund{
+				{Amount: 535, Lines: []*orders.RefundLine{{Amount: 107}, {Amount: 428}}},
+				{Amount: 10, Lines: []*orders.RefundLine{}},
+			}},
+			expect: 0,
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.order.RefundableAmount(); math.Abs(got-test.expect) > 0.001 {
+			t.Errorf("%s: expect: %v, got: %v", test.label, test.expect, got)
+		}
+	}
+}
+
+type testLegacyDiscountRate struct {
+	label  string
+	order  *orders.Order
+	expect float64
+}
+
+func TestLegacyDiscountRate(t *testing.T) {
+	vat := &taxes.TaxRate{Title: "VAT", Rate: 7}
+	coffee := &products.Product{Id: "P000001", Price: 100}
+
+	tests := []testLegacyDiscountRate{
+		{label: "no discount", order: &orders.Order{Products: []*orders.ProductsOrder{{Qty: 2, Product: coffee}}}, expect: 0},
+		{label: "order before snapshots", order: &orders.Order{Discount: 20, Products: []*orders.ProductsOrder{{Qty: 2, Product: coffee}}}, expect: 0.1},
+		{
+			label: "discount held by the snapshots",
+			order: &orders.Order{Discount: 20, Products: []*orders.ProductsOrder{
+				{Qty: 2, Product: coffee, Tax: taxes.CalculateDiscounted(vat, 100, 2, taxes.Inclusive, 20)},
+			}},
+			expect: 0,
+		},
+		{
+			label: "mixed lines",
+			order: &orders.Order{Discount: 30, Products: []*orders.ProductsOrder{
+				{Qty: 2, Product: coffee, Tax: taxes.CalculateDiscounted(vat, 100, 2, taxes.Inclusive, 20)},
+				{Qty: 1, Product: coffee},
+			}},
+			expect: 0.1,
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.order.LegacyDiscountRate(); math.Abs(got-test.expect) > 0.0001 {
+			t.Errorf("%s: expect: %v, got: %v", test.label, test.expect, got)
+		}
+	}
+}
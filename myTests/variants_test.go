@@ -0,0 +1,67 @@
+package myTests
+
+import (
+	"go_learn_project_rest_api/modules/products"
+	"testing"
+)
+
+type testValidateVariants struct {
+	label    string
+	variants []*products.Variant
+	isErr    bool
+}
+
+func TestValidateVariants(t *testing.T) {
+	options := []*products.Option{
+		{Name: "Size", Values: []string{"S", "M"}},
+		{Name: "Color", Values: []string{"Red"}},
+	}
+	price := 200.0
+	negative := -1
+
+	tests := []testValidateVariants{
+		{label: "valid", variants: []*products.Variant{
+			{Sku: "SHIRT-S-RED", Options: map[string]string{"Size": "S", "Color": "Red"}},
+			{Sku: "SHIRT-M-RED", Options: map[string]string{"Size": "M", "Color": "Red"}, Price: &price},
+		}},
+		{label: "missing sku", isErr: true, variants: []*products.Variant{
+			{Options: map[string]string{"Size": "S", "Color": "Red"}},
+		}},
+		{label: "duplicated sku", isErr: true, variants: []*products.Variant{
+			{Sku: "SHIRT", Options: map[string]string{"Size": "S", "Color": "Red"}},
+			{Sku: "SHIRT", Options: map[string]string{"Size": "M", "Color": "Red"}},
+		}},
+		{label: "unknown value", isErr: true, variants: []*products.Variant{
+			{Sku: "SHIRT-L-RED", Options: map[string]string{"Size": "L", "Color": "Red"}},
+		}},
+		{label: "missing option", isErr: true, variants: []*products.Variant{
+			{Sku: "SHIRT-S", Options: map[string]string{"Size": "S"}},
+		}},
+		{label: "duplicated combination", isErr: true, variants: []*products.Variant{
+			{Sku: "SHIRT-1", Options: map[string]string{"Size": "S", "Color": "Red"}},
+			{Sku: "SHIRT-2", Options: map[string]string{"Size": "S", "Color": "Red"}},
+		}},
+		{label: "negative stock", isErr: true, variants: []*products.Variant{
+			{Sku: "SHIRT-S-RED", Options: map[string]string{"Size": "S", "Color": "Red"}, Stock: &negative},
+		}},
+	}
+
+	for _, test := range tests {
+		err := products.ValidateVariants(options, test.variants)
+		if (err != nil) != test.isErr {
+			t.Errorf("%s: expect error: %v, got: %v", test.label, test.isErr, err)
+		}
+	}
+
+	product := &products.Product{Price: 150}
+	variant := tests[0].variants[1]
+	if got := variant.PriceOf(product); got != price {
+		t.Errorf("expect: %v, got: %v", price, got)
+	}
+	if got := tests[0].variants[0].PriceOf(product); got != product.Price {
+		t.Errorf("expect: %v, got: %v", product.Price, got)
+	}
+	if got := variant.Label(); got != "Color: Red, Size: M" {
+		t.Errorf("expect: %v, got: %v", "Color: Red, Size: M", got)
+	}
+}
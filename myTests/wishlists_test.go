@@ -0,0 +1,85 @@
+package myTests
+
+import (
+	"go_learn_project_rest_api/modules/products"
+	"go_learn_project_rest_api/modules/wishlists"
+	"go_learn_project_rest_api/modules/wishlists/wishlistRepositories"
+	"go_learn_project_rest_api/modules/wishlists/wishlistUsecases"
+	"reflect"
+	"testing"
+)
+
+type testIsAvailable struct {
+	label   string
+	product *products.Product
+	expect  bool
+}
+
+func TestIsAvailable(t *testing.T) {
+	zero, ten := 0, 10
+
+	tests := []testIsAvailable{
+		{label: "not stock tracked", product: &products.Product{Visible: true}, expect: true},
+		{label: "in stock", product: &products.Product{Visible: true, Stock: &ten}, expect: true},
+		{label: "out of stock", product: &products.Product{Visible: true, Stock: &zero}, expect: false},
+		{label: "hidden", product: &products.Product{Visible: false}, expect: false},
+		{label: "variant in stock", product: &products.Product{Visible: true, Stock: &zero, Variants: []*products.Variant{
+			{Sku: "A", Stock: &zero},
+			{Sku: "B", Stock: &ten},
+		}}, expect: true},
+		{label: "variants out of stock", product: &products.Product{Visible: true, Variants: []*products.Variant{
+			{Sku: "A", Stock: &zero},
+		}}, expect: false},
+	}
+
+	for _, test := range tests {
+		if got := wishlists.IsAvailable(test.product); got != test.expect {
+			t.Errorf("%s: expect: %v, got: %v", test.label, test.expect, got)
+		}
+	}
+}
+
+type fakeWishlistRepository struct {
+	wishlistRepositories.IWishlistRepository
+	items []*wishlists.WishlistItem
+}
+
+func (r *fakeWishlistRepository) FindWishlist(userId string) ([]*wishlists.WishlistItem, error) {
+	return r.items, nil
+}
+
+// The products come in one query, a product deleted since is left out
+func TestFindWishlist(t *testing.T) {
+	zero := 0
+	productRepo := &fakeRecommendationProductRepository{products: map[string]*products.Product{
+		"P000001": {Id: "P000001", Title: "Coffee", Visible: true},
+		"P000003": {Id: "P000003", Title: "Tea", Visible: true, Stock: &zero},
+	}}
+	wishlistRepo := &fakeWishlistRepository{items: []*wishlists.WishlistItem{
+		{ProductId: "P000003"},
+		{ProductId: "P000002"},
+		{ProductId: "P000001"},
+	}}
+	usecase := wishlistUsecases.WishlistUsecases(wishlistRepo, productRepo)
+
+	result, err := usecase.FindWishlist("U000001")
+	if err != nil {
+		t.Fatalf("find wishlist: %v", err)
+	}
+	if productRepo.calls != 1 {
+		t.Errorf("expect 1 product query, got: %d", productRepo.calls)
+	}
+
+	got := make([]string, 0)
+	available := make([]bool, 0)
+	for _, item := range result {
+		got = append(got, item.Product.Id)
+		available = append(available, item.Available)
+	}
+	if expect := []string{"P000003", "P000001"}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect products: %v, got: %v", expect, got)
+	}
+	if expect := []bool{false, true}; !reflect.DeepEqual(available, expect) {
+		t.Errorf("expect available: %v, got: %v", expect, available)
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type ICache interface {
+	Get(key string) (any, bool)
+	Set(key string, value any)
+	Delete(key string)
+}
+
+type item struct {
+	value     any
+	expiresAt time.Time
+}
+
+type cache struct {
+	mu    sync.RWMutex
+	ttl   time.Duration
+	items map[string]*item
+}
+
+func NewCache(ttl time.Duration) ICache {
+	return &cache{
+		ttl:   ttl,
+		items: make(map[string]*item),
+	}
+}
+
+func (c *cache) Get(key string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	it, ok := c.items[key]
+	if !ok || time.Now().After(it.expiresAt) {
+		return nil, false
+	}
+	return it.value, true
+}
+
+func (c *cache) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired items on write so the map does not grow forever
+	now := time.Now()
+	for k, it := range c.items {
+		if now.After(it.expiresAt) {
+			delete(c.items, k)
+		}
+	}
+
+	c.items[key] = &item{
+		value:     value,
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+func (c *cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
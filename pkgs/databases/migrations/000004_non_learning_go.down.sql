@@ -0,0 +1,15 @@
+BEGIN;
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "discount";
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "coupon_code";
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_coupons_table ON "coupons";
+
+DROP TABLE IF EXISTS "coupon_redemptions" CASCADE;
+DROP TABLE IF EXISTS "coupons_categories" CASCADE;
+DROP TABLE IF EXISTS "coupons_products" CASCADE;
+DROP TABLE IF EXISTS "coupons" CASCADE;
+
+DROP TYPE IF EXISTS "coupon_type";
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "tax_total";
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "tax";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "tax_mode";
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_tax_rates_table ON "tax_rates";
+
+DROP TABLE IF EXISTS "tax_rates" CASCADE;
+
+DROP TYPE IF EXISTS "tax_mode";
+
+COMMIT;
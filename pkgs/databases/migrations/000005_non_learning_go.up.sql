@@ -0,0 +1,32 @@
+BEGIN;
+
+CREATE TYPE tax_mode AS ENUM (
+    'inclusive',
+    'exclusive'
+);
+
+-- category_id and region are optional, NULL matches everything
+CREATE TABLE "tax_rates" (
+  "id" SERIAL PRIMARY KEY,
+  "title" VARCHAR NOT NULL,
+  "rate" FLOAT NOT NULL,
+  "category_id" INT,
+  "region" VARCHAR,
+  "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "tax_rates" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE CASCADE;
+
+CREATE TRIGGER set_updated_at_timestamp_tax_rates_table BEFORE UPDATE ON "tax_rates" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+ALTER TABLE "products" ADD COLUMN "tax_mode" tax_mode NOT NULL DEFAULT 'inclusive';
+
+ALTER TABLE "products_orders" ADD COLUMN "tax" jsonb;
+ALTER TABLE "orders" ADD COLUMN "tax_total" FLOAT NOT NULL DEFAULT 0;
+
+-- Thai VAT
+INSERT INTO "tax_rates" ("title", "rate") VALUES ('VAT', 7);
+
+COMMIT;
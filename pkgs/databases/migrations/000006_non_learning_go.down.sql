@@ -0,0 +1,21 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_payments_table ON "payments";
+
+DROP TABLE IF EXISTS "payments" CASCADE;
+
+DROP TYPE IF EXISTS "payment_status";
+
+-- enum values can not be dropped, paid orders fall back to waiting
+ALTER TABLE "orders" ALTER COLUMN "status" TYPE VARCHAR;
+UPDATE "orders" SET "status" = 'waiting' WHERE "status" = 'paid';
+DROP TYPE IF EXISTS "order_status";
+CREATE TYPE order_status AS ENUM (
+    'waiting',
+    'shipping',
+    'completed',
+    'canceled'
+);
+ALTER TABLE "orders" ALTER COLUMN "status" TYPE order_status USING "status"::order_status;
+
+COMMIT;
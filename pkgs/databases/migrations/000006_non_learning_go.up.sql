@@ -0,0 +1,32 @@
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'paid' AFTER 'waiting';
+
+BEGIN;
+
+CREATE TYPE payment_status AS ENUM (
+    'pending',
+    'succeeded',
+    'failed',
+    'refunded'
+);
+
+CREATE TABLE "payments" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "provider" VARCHAR NOT NULL,
+  "provider_ref" VARCHAR NOT NULL,
+  "amount" FLOAT NOT NULL,
+  "refunded_amount" FLOAT NOT NULL DEFAULT 0,
+  "status" payment_status NOT NULL DEFAULT 'pending',
+  "payload" jsonb,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "payments" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "payments_provider_provider_ref_idx" ON "payments" ("provider", "provider_ref");
+CREATE INDEX "payments_order_id_idx" ON "payments" ("order_id");
+
+CREATE TRIGGER set_updated_at_timestamp_payments_table BEFORE UPDATE ON "payments" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_notifications_table ON "notifications";
+
+DROP TABLE IF EXISTS "notifications" CASCADE;
+
+-- enum values can not be dropped, slips under review fall back to waiting
+ALTER TABLE "orders" ALTER COLUMN "status" TYPE VARCHAR;
+UPDATE "orders" SET "status" = 'waiting' WHERE "status" = 'pending_verification';
+DROP TYPE IF EXISTS "order_status";
+CREATE TYPE order_status AS ENUM (
+    'waiting',
+    'paid',
+    'shipping',
+    'completed',
+    'canceled'
+);
+ALTER TABLE "orders" ALTER COLUMN "status" TYPE order_status USING "status"::order_status;
+
+COMMIT;
@@ -0,0 +1,23 @@
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'pending_verification' AFTER 'waiting';
+
+BEGIN;
+
+CREATE TABLE "notifications" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "user_id" VARCHAR NOT NULL,
+  "order_id" VARCHAR,
+  "type" VARCHAR NOT NULL,
+  "message" VARCHAR NOT NULL,
+  "read_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "notifications" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "notifications_user_id_idx" ON "notifications" ("user_id", "created_at" DESC);
+
+CREATE TRIGGER set_updated_at_timestamp_notifications_table BEFORE UPDATE ON "notifications" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
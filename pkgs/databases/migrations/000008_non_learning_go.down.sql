@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_refunds_table ON "refunds";
+
+DROP TABLE IF EXISTS "refunds" CASCADE;
+
+DROP TYPE IF EXISTS "refund_status";
+
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "canceled_qty";
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "stock";
+
+COMMIT;
@@ -0,0 +1,33 @@
+BEGIN;
+
+-- NULL stock means the product is not stock tracked
+ALTER TABLE "products" ADD COLUMN "stock" INT CHECK ("stock" >= 0);
+
+ALTER TABLE "products_orders" ADD COLUMN "canceled_qty" INT NOT NULL DEFAULT 0;
+
+CREATE TYPE refund_status AS ENUM (
+    'pending',
+    'succeeded',
+    'failed'
+);
+
+CREATE TABLE "refunds" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "payment_id" uuid,
+  "amount" FLOAT NOT NULL DEFAULT 0,
+  "reason" VARCHAR NOT NULL DEFAULT '',
+  "status" refund_status NOT NULL DEFAULT 'pending',
+  "lines" jsonb NOT NULL DEFAULT '[]'::jsonb,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "refunds" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+ALTER TABLE "refunds" ADD FOREIGN KEY ("payment_id") REFERENCES "payments" ("id") ON DELETE SET NULL;
+
+CREATE INDEX "refunds_order_id_idx" ON "refunds" ("order_id");
+
+CREATE TRIGGER set_updated_at_timestamp_refunds_table BEFORE UPDATE ON "refunds" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_shipments_table ON "shipments";
+
+DROP TABLE IF EXISTS "shipments" CASCADE;
+
+DROP TYPE IF EXISTS "shipment_status";
+
+COMMIT;
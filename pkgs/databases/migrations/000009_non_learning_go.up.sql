@@ -0,0 +1,32 @@
+BEGIN;
+
+CREATE TYPE shipment_status AS ENUM (
+    'shipped',
+    'in_transit',
+    'delivered',
+    'failed'
+);
+
+CREATE TABLE "shipments" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "carrier" VARCHAR NOT NULL,
+  "tracking_number" VARCHAR NOT NULL,
+  "status" shipment_status NOT NULL DEFAULT 'shipped',
+  "parcels" jsonb NOT NULL DEFAULT '[]'::jsonb,
+  "events" jsonb NOT NULL DEFAULT '[]'::jsonb,
+  "shipped_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "delivered_at" TIMESTAMP,
+  "checked_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "shipments" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "shipments_carrier_tracking_number_idx" ON "shipments" ("carrier", "tracking_number");
+CREATE INDEX "shipments_order_id_idx" ON "shipments" ("order_id");
+
+CREATE TRIGGER set_updated_at_timestamp_shipments_table BEFORE UPDATE ON "shipments" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_invoices_table ON "invoices";
+
+DROP TABLE IF EXISTS "invoices" CASCADE;
+
+DROP TABLE IF EXISTS "invoice_counters" CASCADE;
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- One counter row per year, locked while a number is taken so numbers never skip or repeat
+CREATE TABLE "invoice_counters" (
+  "year" INT NOT NULL PRIMARY KEY,
+  "last_number" INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE "invoices" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL UNIQUE,
+  "number" VARCHAR NOT NULL UNIQUE,
+  "issued_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "invoices" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE RESTRICT;
+
+CREATE TRIGGER set_updated_at_timestamp_invoices_table BEFORE UPDATE ON "invoices" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_order_comments_table ON "order_comments";
+
+DROP TABLE IF EXISTS "order_comments" CASCADE;
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "note";
+
+COMMIT;
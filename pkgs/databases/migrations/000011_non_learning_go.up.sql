@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE "orders" ADD COLUMN "note" VARCHAR NOT NULL DEFAULT '';
+
+CREATE TABLE "order_comments" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "order_id" VARCHAR NOT NULL,
+  "user_id" VARCHAR NOT NULL,
+  "body" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "order_comments" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+ALTER TABLE "order_comments" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE INDEX "order_comments_order_id_idx" ON "order_comments" ("order_id", "created_at");
+
+CREATE TRIGGER set_updated_at_timestamp_order_comments_table BEFORE UPDATE ON "order_comments" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,16 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+BEGIN;
+
+ALTER TABLE "products" ADD COLUMN "search_vector" tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', COALESCE("title", '')), 'A') ||
+  setweight(to_tsvector('english', COALESCE("description", '')), 'B')
+) STORED;
+
+CREATE INDEX "products_search_vector_idx" ON "products" USING GIN ("search_vector");
+
+-- The english parser can not split Thai words, Thai searches fall back to substring matching on these
+CREATE INDEX "products_title_trgm_idx" ON "products" USING GIN ("title" gin_trgm_ops);
+CREATE INDEX "products_description_trgm_idx" ON "products" USING GIN ("description" gin_trgm_ops);
+
+COMMIT;
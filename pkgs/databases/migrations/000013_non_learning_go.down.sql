@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "variant";
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "variant_id";
+
+DELETE FROM "images" WHERE "variant_id" IS NOT NULL;
+ALTER TABLE "images" DROP COLUMN IF EXISTS "variant_id";
+
+DROP TABLE IF EXISTS "product_variants" CASCADE;
+DROP TABLE IF EXISTS "product_options" CASCADE;
+
+COMMIT;
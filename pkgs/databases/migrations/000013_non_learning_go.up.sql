@@ -0,0 +1,44 @@
+BEGIN;
+
+-- Option types of a product, e.g. Size with the values S, M and L
+CREATE TABLE "product_options" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "product_id" VARCHAR NOT NULL,
+  "name" VARCHAR NOT NULL,
+  "values" jsonb NOT NULL DEFAULT '[]'::jsonb,
+  "position" INT NOT NULL DEFAULT 0,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  UNIQUE ("product_id", "name")
+);
+
+-- NULL price uses the product price, NULL stock means the variant is not stock tracked
+CREATE TABLE "product_variants" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "product_id" VARCHAR NOT NULL,
+  "sku" VARCHAR NOT NULL UNIQUE,
+  "options" jsonb NOT NULL DEFAULT '{}'::jsonb,
+  "price" FLOAT CHECK ("price" >= 0),
+  "stock" INT CHECK ("stock" >= 0),
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "product_options" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+ALTER TABLE "product_variants" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "product_variants_product_id_idx" ON "product_variants" ("product_id");
+
+-- Images with a variant_id belong to that variant only
+ALTER TABLE "images" ADD COLUMN "variant_id" uuid;
+ALTER TABLE "images" ADD FOREIGN KEY ("variant_id") REFERENCES "product_variants" ("id") ON DELETE CASCADE;
+
+-- Order lines keep a snapshot of the variant, the reference is cleared if the variant is removed
+ALTER TABLE "products_orders" ADD COLUMN "variant_id" uuid;
+ALTER TABLE "products_orders" ADD COLUMN "variant" jsonb;
+ALTER TABLE "products_orders" ADD FOREIGN KEY ("variant_id") REFERENCES "product_variants" ("id") ON DELETE SET NULL;
+
+CREATE TRIGGER set_updated_at_timestamp_product_options_table BEFORE UPDATE ON "product_options" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+CREATE TRIGGER set_updated_at_timestamp_product_variants_table BEFORE UPDATE ON "product_variants" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
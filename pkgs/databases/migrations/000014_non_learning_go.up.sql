@@ -0,0 +1,6 @@
+BEGIN;
+
+-- The key of the product in the admin's own catalog, bulk imports upsert by it
+ALTER TABLE "products" ADD COLUMN "external_sku" VARCHAR UNIQUE;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE "products_categories" DROP CONSTRAINT IF EXISTS "products_categories_product_id_category_id_key";
+
+DROP INDEX IF EXISTS "categories_parent_id_idx";
+
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "sort_order";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "slug";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "parent_id";
+
+COMMIT;
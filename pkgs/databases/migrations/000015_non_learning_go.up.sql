@@ -0,0 +1,35 @@
+BEGIN;
+
+ALTER TABLE "categories" ADD COLUMN "parent_id" INT CHECK ("parent_id" <> "id");
+ALTER TABLE "categories" ADD COLUMN "slug" VARCHAR;
+ALTER TABLE "categories" ADD COLUMN "sort_order" INT NOT NULL DEFAULT 0;
+
+ALTER TABLE "categories" ADD FOREIGN KEY ("parent_id") REFERENCES "categories" ("id") ON DELETE SET NULL;
+
+-- Slugs of the existing categories come from their titles, a clash or an empty slug falls back to the id
+UPDATE "categories" SET "slug" = COALESCE(
+  NULLIF(TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE("title", '[^[:alnum:]]+', '-', 'g'))), ''),
+  'category-' || "id"
+);
+UPDATE "categories" c SET "slug" = c."slug" || '-' || c."id"
+WHERE EXISTS (
+  SELECT 1
+  FROM "categories" o
+  WHERE o."slug" = c."slug"
+  AND o."id" < c."id"
+);
+
+ALTER TABLE "categories" ALTER COLUMN "slug" SET NOT NULL;
+ALTER TABLE "categories" ADD CONSTRAINT "categories_slug_key" UNIQUE ("slug");
+
+CREATE INDEX "categories_parent_id_idx" ON "categories" ("parent_id");
+
+-- A product can be in several categories, but only once in each
+DELETE FROM "products_categories" a
+USING "products_categories" b
+WHERE a."product_id" = b."product_id"
+AND a."category_id" = b."category_id"
+AND a.ctid > b.ctid;
+ALTER TABLE "products_categories" ADD CONSTRAINT "products_categories_product_id_category_id_key" UNIQUE ("product_id", "category_id");
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "products_orders_product_id_idx";
+DROP INDEX IF EXISTS "products_status_publish_at_idx";
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "publish_at";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "status";
+
+DROP TYPE IF EXISTS "product_status";
+
+COMMIT;
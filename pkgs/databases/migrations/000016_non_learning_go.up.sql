@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TYPE "product_status" AS ENUM (
+  'draft',
+  'published',
+  'archived'
+);
+
+-- The existing products stay visible, new ones start as drafts
+ALTER TABLE "products" ADD COLUMN "status" "product_status" NOT NULL DEFAULT 'published';
+ALTER TABLE "products" ALTER COLUMN "status" SET DEFAULT 'draft';
+
+-- A published product with a publish_at in the future is hidden until that time
+ALTER TABLE "products" ADD COLUMN "publish_at" TIMESTAMPTZ;
+
+CREATE INDEX "products_status_publish_at_idx" ON "products" ("status", "publish_at");
+
+-- Order lines only keep the product as a snapshot, this finds the lines of a product before it is deleted
+CREATE INDEX "products_orders_product_id_idx" ON "products_orders" (("product"->>'id'));
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_reviews_table ON "reviews";
+DROP TABLE IF EXISTS "reviews";
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+-- One review per customer and product, only customers with a completed order of the product can write one
+CREATE TABLE "reviews" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "product_id" VARCHAR NOT NULL,
+  "user_id" VARCHAR NOT NULL,
+  "rating" INT NOT NULL CHECK ("rating" BETWEEN 1 AND 5),
+  "comment" VARCHAR NOT NULL DEFAULT '',
+  "is_hidden" BOOLEAN NOT NULL DEFAULT FALSE,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  UNIQUE ("product_id", "user_id")
+);
+
+ALTER TABLE "reviews" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+ALTER TABLE "reviews" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "reviews_product_id_visible_idx" ON "reviews" ("product_id") WHERE NOT "is_hidden";
+
+CREATE TRIGGER set_updated_at_timestamp_reviews_table BEFORE UPDATE ON "reviews" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+COMMIT;
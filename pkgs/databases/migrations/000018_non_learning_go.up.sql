@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE "wishlists" (
+  "user_id" VARCHAR NOT NULL,
+  "product_id" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("user_id", "product_id")
+);
+
+ALTER TABLE "wishlists" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "wishlists" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+-- The most wishlisted report groups by product
+CREATE INDEX "wishlists_product_id_idx" ON "wishlists" ("product_id");
+
+COMMIT;
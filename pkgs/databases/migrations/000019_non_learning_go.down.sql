@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP FUNCTION IF EXISTS product_current_price(VARCHAR, FLOAT);
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_product_scheduled_prices_table ON "product_scheduled_prices";
+DROP TABLE IF EXISTS "product_scheduled_prices";
+
+DROP TRIGGER IF EXISTS log_product_price_change_products_table ON "products";
+DROP FUNCTION IF EXISTS log_product_price_change();
+DROP TABLE IF EXISTS "product_price_history";
+
+COMMIT;
@@ -0,0 +1,66 @@
+BEGIN;
+
+-- Every change of the regular price, written by a trigger so imports and updates can not skip it
+CREATE TABLE "product_price_history" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "product_id" VARCHAR NOT NULL,
+  "price" FLOAT NOT NULL,
+  "previous_price" FLOAT,
+  "created_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "product_price_history" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "product_price_history_product_id_idx" ON "product_price_history" ("product_id", "created_at");
+
+CREATE OR REPLACE FUNCTION log_product_price_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.price IS NOT DISTINCT FROM NEW.price THEN
+        RETURN NULL;
+    END IF;
+    INSERT INTO "product_price_history" ("product_id", "price", "previous_price")
+    VALUES (NEW.id, NEW.price, CASE WHEN TG_OP = 'UPDATE' THEN OLD.price END);
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER log_product_price_change_products_table AFTER INSERT OR UPDATE OF "price" ON "products" FOR EACH ROW EXECUTE PROCEDURE log_product_price_change();
+
+-- The existing prices start the history
+INSERT INTO "product_price_history" ("product_id", "price", "created_at")
+SELECT "id", "price", "created_at" FROM "products";
+
+-- A price for a period, e.g. a sale, it replaces the regular price while it runs. ends_at NULL runs until removed
+CREATE TABLE "product_scheduled_prices" (
+  "id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+  "product_id" VARCHAR NOT NULL,
+  "price" FLOAT NOT NULL CHECK ("price" >= 0),
+  "starts_at" TIMESTAMP NOT NULL,
+  "ends_at" TIMESTAMP CHECK ("ends_at" > "starts_at"),
+  "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE "product_scheduled_prices" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "product_scheduled_prices_product_id_idx" ON "product_scheduled_prices" ("product_id", "starts_at");
+
+CREATE TRIGGER set_updated_at_timestamp_product_scheduled_prices_table BEFORE UPDATE ON "product_scheduled_prices" FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+-- The price a product sells for now, the read path uses it wherever the price is shown, filtered or sorted
+CREATE OR REPLACE FUNCTION product_current_price(product_id VARCHAR, regular_price FLOAT)
+RETURNS FLOAT AS $$
+    SELECT COALESCE((
+        SELECT
+            sp."price"
+        FROM "product_scheduled_prices" sp
+        WHERE sp."product_id" = $1
+        AND sp."starts_at" <= now()
+        AND (sp."ends_at" IS NULL OR sp."ends_at" > now())
+        ORDER BY sp."starts_at" DESC
+        LIMIT 1
+    ), $2);
+$$ language 'sql' STABLE;
+
+COMMIT;
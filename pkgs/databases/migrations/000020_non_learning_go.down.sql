@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS set_version_orders_table ON "orders";
+DROP TRIGGER IF EXISTS set_version_products_table ON "products";
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "version";
+
+DROP FUNCTION IF EXISTS set_version_products_column();
+DROP FUNCTION IF EXISTS set_version_column();
+
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+
+-- Every update of a row bumps its version, PATCH requests compare it with If-Match
+CREATE OR REPLACE FUNCTION set_version_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+ALTER TABLE "products" ADD COLUMN "version" INT NOT NULL DEFAULT 1;
+ALTER TABLE "orders" ADD COLUMN "version" INT NOT NULL DEFAULT 1;
+
+-- Orders and refunds move the stock of a product, that is not an edit of the product and must not fail the admin If-Match.
+-- The admin update bumps the version itself, so a stock change made there still counts.
+-- The row is compared inside the function because a trigger WHEN clause can not use the whole row of a table
+-- with generated columns, search_vector is left out as NEW does not hold its new value before the update
+CREATE OR REPLACE FUNCTION set_version_products_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (to_jsonb(OLD) - 'stock' - 'updated_at' - 'version' - 'search_vector') IS DISTINCT FROM (to_jsonb(NEW) - 'stock' - 'updated_at' - 'version' - 'search_vector') THEN
+        NEW.version = OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER set_version_products_table BEFORE UPDATE ON "products" FOR EACH ROW EXECUTE PROCEDURE set_version_products_column();
+CREATE TRIGGER set_version_orders_table BEFORE UPDATE ON "orders" FOR EACH ROW EXECUTE PROCEDURE set_version_column();
+
+COMMIT;
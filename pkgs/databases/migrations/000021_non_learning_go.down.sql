@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "product_co_purchases";
+
+COMMIT;
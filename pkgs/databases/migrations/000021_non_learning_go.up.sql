@@ -0,0 +1,19 @@
+BEGIN;
+
+-- How many orders had both products, refreshed by the recommendations job from products_orders
+CREATE TABLE "product_co_purchases" (
+  "product_id" VARCHAR NOT NULL,
+  "related_id" VARCHAR NOT NULL,
+  "orders_count" INT NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY ("product_id", "related_id"),
+  CHECK ("product_id" <> "related_id")
+);
+
+ALTER TABLE "product_co_purchases" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+ALTER TABLE "product_co_purchases" ADD FOREIGN KEY ("related_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+-- The recommendations of a product are read by the highest count first
+CREATE INDEX "product_co_purchases_product_id_count_idx" ON "product_co_purchases" ("product_id", "orders_count" DESC);
+
+COMMIT;
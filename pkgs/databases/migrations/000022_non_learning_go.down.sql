@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "payments_order_id_pending_idx";
+
+COMMIT;
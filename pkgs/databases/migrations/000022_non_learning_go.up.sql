@@ -0,0 +1,16 @@
+BEGIN;
+
+-- An order is paid through one pending payment at a time, older duplicates are failed so the newest is the one to pay
+UPDATE "payments" p SET "status" = 'failed'
+WHERE p."status" = 'pending'
+AND EXISTS (
+  SELECT 1
+  FROM "payments" n
+  WHERE n."order_id" = p."order_id"
+  AND n."status" = 'pending'
+  AND (n."created_at", n."id") > (p."created_at", p."id")
+);
+
+CREATE UNIQUE INDEX "payments_order_id_pending_idx" ON "payments" ("order_id") WHERE "status" = 'pending';
+
+COMMIT;
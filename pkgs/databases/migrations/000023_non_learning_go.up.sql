@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Set when a payment succeeds for an order that can not be paid anymore, staff refund or settle it by hand
+ALTER TABLE "payments" ADD COLUMN "review_reason" VARCHAR;
+
+COMMIT;
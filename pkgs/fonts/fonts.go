@@ -0,0 +1,11 @@
+// Package fonts embeds the TrueType font of the generated documents. It is read as UTF-8,
+// and GNU FreeSerif has glyphs for Thai as well as Latin, so Thai names, addresses and titles print as they are.
+// FreeSerif has no bold face here, documents draw bold text by filling and stroking the regular one.
+package fonts
+
+import _ "embed"
+
+const Family = "FreeSerif"
+
+//go:embed FreeSerif.ttf
+var Regular []byte
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Timeout  time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// ILocker keeps one instance running a job at a time, unlock is only set when the lock is taken
+type ILocker interface {
+	TryLock(ctx context.Context, name string) (unlock func(), err error)
+}
+
+type IScheduler interface {
+	Add(*Job)
+	Start()
+	Stop()
+}
+
+type scheduler struct {
+	locker ILocker
+	jobs   []*Job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(db *sqlx.DB) IScheduler {
+	return NewSchedulerWithLocker(&advisoryLocker{db: db})
+}
+
+func NewSchedulerWithLocker(locker ILocker) IScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &scheduler{
+		locker: locker,
+		jobs:   make([]*Job, 0),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Add skips a job without a positive interval, a ticker cannot run on it
+func (s *scheduler) Add(job *Job) {
+	if job.Interval <= 0 {
+		log.Printf("job %s skipped, interval must be positive, got: %v\n", job.Name, job.Interval)
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+func (s *scheduler) Start() {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+}
+
+// Stop waits for the running jobs to finish
+func (s *scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *scheduler) loop(job *Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.run(job); err != nil {
+				log.Printf("job %s failed: %v\n", job.Name, err)
+			}
+		}
+	}
+}
+
+func (s *scheduler) run(job *Job) error {
+	timeout := job.Timeout
+	if timeout <= 0 {
+		timeout = job.Interval
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	unlock, err := s.locker.TryLock(ctx, job.Name)
+	if err != nil {
+		return err
+	}
+	if unlock == nil {
+		return nil
+	}
+	defer unlock()
+
+	return job.Run(ctx)
+}
+
+type advisoryLocker struct {
+	db *sqlx.DB
+}
+
+// TryLock takes a postgres advisory lock, so only one instance runs the job at a time.
+// The lock belongs to the session, so lock and unlock share one connection held until unlock.
+func (l *advisoryLocker) TryLock(ctx context.Context, name string) (func(), error) {
+	conn, err := l.db.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get connection failed: %v", err)
+	}
+
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock(hashtext($1));`, name); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("lock job failed: %v", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil
+	}
+
+	return func() {
+		defer conn.Close()
+		// The job context may be done already, unlock with a fresh one
+		unlockCtx, unlockCancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer unlockCancel()
+		if _, err := conn.ExecContext(unlockCtx, `SELECT pg_advisory_unlock(hashtext($1));`, name); err != nil {
+			log.Printf("unlock job %s failed: %v\n", name, err)
+		}
+	}, nil
+}
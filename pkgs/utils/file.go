@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
@@ -15,3 +18,35 @@ func RandFileName(ext string) string {
 	}
 	return fileName
 }
+
+// SpoolFile writes a file to a temp file and gives it back read from the start with its size.
+// A download is written out before anything is sent, so it can still fail with an error instead of a cut file,
+// and it does not sit in memory. The file is unlinked already, it is gone once closed
+func SpoolFile(write func(io.Writer) error) (*os.File, int64, error) {
+	f, err := os.CreateTemp("", "spool_*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("create temp file failed: %v", err)
+	}
+	os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("write temp file failed: %v", err)
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("seek temp file failed: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("seek temp file failed: %v", err)
+	}
+	return f, size, nil
+}